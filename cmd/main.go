@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"testtask5/internal/app"
+	"testtask5/internal/metrics"
 	"testtask5/internal/middleware"
 	"time"
 
@@ -25,26 +27,41 @@ func main() {
 	//ГЛОБАЛЬНЫЙ ЛОГГЕР ПРИЛОЖЕНИЯ
 	appLogger := logger.Named("app")
 
+	//ТРЕЙСИНГ
+	shutdownTracing := app.InitTracing(appLogger)
+
 	//БАЗА ДАННЫХ
 	db, err := app.InitDb()
 	if err != nil {
-		appLogger.Error("не удалось подключиться к базе данных: ", zap.Error(err))
+		//без базы сервис не работает, оркестратор перезапустит процесс
+		appLogger.Fatal("не удалось подключиться к базе данных: ", zap.Error(err))
 	}
 	appLogger.Info("база данных создана ", zap.Time("started", time.Now()))
 
+	//МЕТРИКИ
+	sqlDB, err := db.DB()
+	if err != nil {
+		appLogger.Fatal("не удалось получить пул соединений базы данных", zap.Error(err))
+	}
+	appMetrics := metrics.NewMetrics(sqlDB)
+
 	//Создаём app для DI
-	appInstance := app.NewAppAppInstance(db, appLogger)
+	appInstance := app.NewAppAppInstance(db, appMetrics, appLogger)
 
 	//Создаём application для фоновых задач
-	application := app.NewApplication(db, logger)
+	application := app.NewApplication(appInstance, logger)
 	application.Start()
+	appInstance.Services.HealthService.AddCheck("workers", application.CheckWorkers)
 
 	router := chi.NewRouter()
 
-	router.Use(middleware.LoggingMiddleWare(appLogger))
-	router.Use(middleware.TimeoutMiddleware(2 * time.Second))
+	router.Use(middleware.RequestIDMiddleware)
+	router.Use(middleware.TracingMiddleware)
+	router.Use(middleware.LoggingMiddleWare(appLogger, appMetrics))
+	//запросы, не соответствующие спецификации, не доходят до хэндлеров
+	router.Use(appInstance.OpenAPIValidator.Middleware)
 
-	app.RegisterRoutes(router, appInstance)
+	app.RegisterRoutes(router, appInstance, appLogger)
 
 	srv := &http.Server{
 		Addr:    ":" + os.Getenv("HTTP_PORT"),
@@ -60,18 +77,46 @@ func main() {
 		}
 	}()
 
+	//gRPC API для внутренних сервисов, на своём порту
+	grpcSrv := app.NewGRPCServer(appInstance, appLogger)
+	grpcListener, err := net.Listen("tcp", ":"+os.Getenv("GRPC_PORT"))
+	if err != nil {
+		appLogger.Fatal("не удалось открыть порт gRPC", zap.Error(err))
+	}
+	go func() {
+		appLogger.Info("gRPC-сервер запущен и слушает ", zap.String("addr", grpcListener.Addr().String()))
+		if err := grpcSrv.Serve(grpcListener); err != nil {
+			appLogger.Fatal("Не удалось запустить gRPC-сервер", zap.Error(err))
+		}
+	}()
+
 	// Ждём Ctrl+C
 	<-ctx.Done()
 	appLogger.Info("Завершаем работу сервера")
 
+	//сначала /readyz начинает отвечать 503, и балансировщик успевает убрать под из трафика,
+	//пока сервер ещё принимает запросы
+	appInstance.Services.HealthService.StartShutdown()
+	time.Sleep(app.ShutdownDrainDelay(appLogger))
+
 	//Завершаем работу воркеров
 	application.Stop(10 * time.Second)
 
 	// Завершаем HTTP-сервер
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
 	defer cancel()
+	//сначала закрываем потоки событий: SSE-запросы и gRPC-потоки иначе не дадут серверам завершиться,
+	//а websocket-соединения Shutdown не отслеживает вовсе
+	if err := appInstance.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("не все websocket-соединения закрылись", zap.Error(err))
+	}
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		appLogger.Fatal("Не удалось корректно завершить работу", zap.Error(err))
 	}
+	app.StopGRPCServer(shutdownCtx, grpcSrv, appLogger)
+	//досылаем спаны последних запросов
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		appLogger.Error("не удалось отправить трейсы", zap.Error(err))
+	}
 
 }
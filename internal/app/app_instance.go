@@ -1,7 +1,14 @@
 package app
 
 import (
+	"context"
+	"os"
+	"testtask5/internal/auth"
+	grpcHandlers "testtask5/internal/interfaces/grpcAPI"
 	httpHandlers "testtask5/internal/interfaces/httpAPI"
+	"testtask5/internal/metrics"
+	"testtask5/internal/middleware"
+	"testtask5/internal/realtime"
 	"testtask5/internal/repo/postgres"
 	"testtask5/internal/services"
 
@@ -13,39 +20,154 @@ type AppInstance struct {
 	Repos    *AppRepos
 	Services *AppServices
 	API      *AppAPIs
+	Events   *realtime.Broadcaster
+	Metrics  *metrics.Metrics
+
+	RateLimiter *middleware.RateLimiter
+	RateLimits  RateLimits
+
+	OpenAPIValidator *middleware.OpenAPIValidator
 }
 
 type AppRepos struct {
 	ChatRepo    *postgres.ChatRepoPostgres
 	MessageRepo *postgres.MessageRepoPostgres
+	UserRepo    *postgres.UserRepoPostgres
+	MemberRepo  *postgres.ChatMemberRepoPostgres
+	EventRepo   *postgres.ChatEventRepoPostgres
+	ThumbRepo   *postgres.ThumbnailRepoPostgres
+	PinRepo     *postgres.PinRepoPostgres
+	IdemRepo    *postgres.IdempotencyRepoPostgres
+	RateRepo    *postgres.RateLimitRepoPostgres
+	HealthRepo  *postgres.HealthRepoPostgres
 }
 
 type AppServices struct {
 	ChatService    *services.ChatService
 	MessageService *services.MessageService
+	AuthService    *services.AuthService
+	ThumbService   *services.ThumbnailService
+	IdemService    *services.IdempotencyService
+	HealthService  *services.HealthService
 }
 
 type AppAPIs struct {
 	ChatAPI    *httpHandlers.ChatAPIHTTP
 	MessageAPI *httpHandlers.MessageAPIHTTP
+	AuthAPI    *httpHandlers.AuthAPIHTTP
+	ChatWSAPI  *httpHandlers.ChatWSHTTP
+	ChatSSEAPI *httpHandlers.ChatSSEHTTP
+	HealthAPI  *httpHandlers.HealthAPIHTTP
+	DocsAPI    *httpHandlers.DocsAPIHTTP
+
+	ChatGRPC    *grpcHandlers.ChatAPIGRPC
+	MessageGRPC *grpcHandlers.MessageAPIGRPC
 }
 
-func NewAppAppInstance(db *gorm.DB, appLogger *zap.Logger) *AppInstance {
+func NewAppAppInstance(db *gorm.DB, appMetrics *metrics.Metrics, appLogger *zap.Logger) *AppInstance {
 	appRepos := &AppRepos{
 		ChatRepo:    postgres.NewChatRepoPostgres(db, appLogger),
 		MessageRepo: postgres.NewMessageRepoPostgres(db, appLogger),
+		UserRepo:    postgres.NewUserRepoPostgres(db, appLogger),
+		MemberRepo:  postgres.NewChatMemberRepoPostgres(db, appLogger),
+		EventRepo:   postgres.NewChatEventRepoPostgres(db, appLogger),
+		ThumbRepo:   postgres.NewThumbnailRepoPostgres(db, appLogger),
+		PinRepo:     postgres.NewPinRepoPostgres(db, appLogger),
+		IdemRepo:    postgres.NewIdempotencyRepoPostgres(db, appLogger),
+		RateRepo:    postgres.NewRateLimitRepoPostgres(db, appLogger),
+		HealthRepo:  postgres.NewHealthRepoPostgres(db, appLogger),
 	}
+	tokenManager := auth.NewTokenManager(
+		jwtSecretFromEnv(appLogger),
+		secondsFromEnv("JWT_ACCESS_TTL", 900, appLogger),
+		secondsFromEnv("JWT_REFRESH_TTL", 30*24*3600, appLogger),
+	)
+	events := realtime.NewBroadcaster(intFromEnv("WS_SEND_BUFFER", 64, appLogger), appLogger)
+	eventStream := services.NewEventStream(appRepos.EventRepo, events, appLogger)
+	attachmentLimits := attachmentLimitsFromEnv(appLogger)
+	attachments := services.NewAttachmentStorage(
+		blobStoreFromEnv(appLogger),
+		attachmentLimits,
+		secondsFromEnv("BLOB_TIMEOUT", 60, appLogger),
+		appLogger,
+	)
 	appServices := &AppServices{
-		ChatService:    services.NewChatService(appRepos.MessageRepo, appRepos.ChatRepo, appLogger),
-		MessageService: services.NewMessageService(appRepos.MessageRepo, appLogger),
+		ChatService: services.NewChatService(
+			appRepos.MessageRepo, appRepos.ChatRepo, appRepos.MemberRepo, appRepos.PinRepo,
+			eventStream, attachments, intFromEnv("CHAT_PIN_LIMIT", 20, appLogger),
+			secondsFromEnv("CHAT_ARCHIVE_RETENTION", 30*24*3600, appLogger), appLogger,
+		),
+		MessageService: services.NewMessageService(appRepos.MessageRepo, appRepos.MemberRepo, eventStream, attachments, secondsFromEnv("MESSAGE_RESTORE_WINDOW", 900, appLogger), appLogger),
+		AuthService:    services.NewAuthService(appRepos.UserRepo, tokenManager, appLogger),
+		ThumbService:   services.NewThumbnailService(appRepos.ThumbRepo, attachments, thumbnailConfigFromEnv(appLogger), appLogger),
+		IdemService:    services.NewIdempotencyService(appRepos.IdemRepo, secondsFromEnv("IDEMPOTENCY_TTL", 24*3600, appLogger), secondsFromEnv("IDEMPOTENCY_LEASE", 60, appLogger), appLogger),
+		HealthService:  services.NewHealthService(secondsFromEnv("HEALTH_CHECK_TIMEOUT", 2, appLogger), appLogger),
 	}
+	addStorageHealthChecks(appServices.HealthService, appRepos.HealthRepo, appLogger)
+	claimOrphanChats(appRepos.MemberRepo, appLogger)
+	docsAPI, openAPIValidator := initOpenAPI(appLogger)
+
 	appAPIs := &AppAPIs{
 		ChatAPI:    httpHandlers.NewChatAPIHTTP(appServices.ChatService, appLogger),
 		MessageAPI: httpHandlers.NewMessageAPIHTTP(appServices.MessageService, appLogger),
+		AuthAPI:    httpHandlers.NewAuthAPIHTTP(appServices.AuthService, appLogger),
+		ChatWSAPI:  httpHandlers.NewChatWSHTTP(appServices.ChatService, appLogger),
+		ChatSSEAPI: httpHandlers.NewChatSSEHTTP(appServices.ChatService, appLogger),
+		HealthAPI:  httpHandlers.NewHealthAPIHTTP(appServices.HealthService, appLogger),
+		DocsAPI:    docsAPI,
+
+		ChatGRPC:    grpcHandlers.NewChatAPIGRPC(appServices.ChatService, appLogger),
+		MessageGRPC: grpcHandlers.NewMessageAPIGRPC(appServices.MessageService, appLogger),
 	}
 	return &AppInstance{
 		Repos:    appRepos,
 		Services: appServices,
 		API:      appAPIs,
+		Events:   events,
+		Metrics:  appMetrics,
+
+		RateLimiter: middleware.NewRateLimiter(
+			rateLimitStoreFromEnv(appRepos.RateRepo, appLogger),
+			os.Getenv("RATE_LIMIT_TRUST_PROXY") == "true",
+			appLogger,
+		),
+		RateLimits: rateLimitsFromEnv(appLogger),
+
+		OpenAPIValidator: openAPIValidator,
+	}
+}
+
+// addStorageHealthChecks добавляет в готовность доступность базы и версию схемы
+func addStorageHealthChecks(health *services.HealthService, healthRepo *postgres.HealthRepoPostgres, logger *zap.Logger) {
+	health.AddCheck("database", healthRepo.Ping)
+
+	expectedVersion, err := latestMigrationVersion()
+	if err != nil {
+		logger.Fatal("не удалось прочитать миграции", zap.Error(err))
+	}
+	health.AddCheck("migrations", func(ctx context.Context) error {
+		return healthRepo.CheckSchemaVersion(ctx, expectedVersion)
+	})
+}
+
+// claimOrphanChats назначает пользователя из CHAT_ORPHAN_OWNER владельцем чатов, оставшихся без владельца.
+// Без владельца чат недоступен никому: доступ проверяется по участникам
+func claimOrphanChats(memberRepo *postgres.ChatMemberRepoPostgres, logger *zap.Logger) {
+	username := os.Getenv("CHAT_ORPHAN_OWNER")
+	if username == "" {
+		return
 	}
+
+	claimed, err := memberRepo.ClaimOrphanChats(context.Background(), username)
+	if err != nil {
+		logger.Fatal("не удалось назначить владельца чатам без владельца", zap.Error(err))
+	}
+	logger.Info("назначен владелец чатов без владельца", zap.String("username", username), zap.Int64("chats", claimed))
+}
+
+// Shutdown закрывает подписки на события: SSE-потоки завершаются сами,
+// websocket-соединения закрываются отдельно, их и ждём
+func (a *AppInstance) Shutdown(ctx context.Context) error {
+	a.Events.Close()
+	return a.API.ChatWSAPI.Shutdown(ctx)
 }
@@ -2,13 +2,16 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"os"
-	"strconv"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
 type Application struct {
@@ -18,22 +21,43 @@ type Application struct {
 	wg     sync.WaitGroup
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	workersMu sync.Mutex
+	workers   map[string]*workerState
+}
+
+// workerState - живость воркера для проверки готовности
+type workerState struct {
+	interval time.Duration
+	lastTick atomic.Int64 // unix nano последнего запуска
+	stopped  atomic.Bool
 }
 
-func NewApplication(db *gorm.DB, logger *zap.Logger) *Application {
+// workerStallFactor - во сколько интервалов воркер может не запускаться, прежде чем считаться зависшим
+const workerStallFactor = 3
+
+// NewApplication запускает воркеры поверх того же AppInstance, что обслуживает запросы:
+// у них общие шина событий, проверки готовности и остальные зависимости
+func NewApplication(appInstance *AppInstance, logger *zap.Logger) *Application {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Application{
-		instance: NewAppAppInstance(db, logger),
+		instance: appInstance,
 		logger:   logger,
 		cancel:   cancel,
 		ctx:      ctx,
+		workers:  make(map[string]*workerState),
 	}
 }
 
 // Start запускает все долгоживущие компоненты
 func (a *Application) Start() {
 	a.startMetricsWorker()
+	a.startMessagePurgeWorker()
+	a.startThumbnailWorker()
+	a.startChatRetentionWorker()
+	a.startIdempotencyPurgeWorker()
+	a.startRateLimitPurgeWorker()
 }
 
 // Stop корректно завершает всё
@@ -57,29 +81,159 @@ func (a *Application) Stop(timeout time.Duration) {
 	}
 }
 
-func (a *Application) startMetricsWorker() {
+// runPeriodically запускает воркер по тикеру до остановки приложения. Время запуска, длительность
+// и ошибки каждого прогона уходят в метрики под именем worker, живость - в проверку готовности
+func (a *Application) runPeriodically(worker string, interval time.Duration, run func() error) {
+	state := &workerState{interval: interval}
+	start := time.Now()
+	state.lastTick.Store(start.UnixNano())
+	a.workersMu.Lock()
+	a.workers[worker] = state
+	a.workersMu.Unlock()
+	a.instance.Metrics.SetWorkerInterval(worker, interval)
+	a.instance.Metrics.ObserveWorkerStart(worker, start)
+
 	a.wg.Add(1)
 	go func() {
 		defer a.wg.Done()
-		workersTimer, err := strconv.Atoi(os.Getenv("WORKERS_TIMER"))
-		if err != nil {
-			a.logger.Error("не удалось распарсить workers timer из окружения", zap.Error(err))
-			workersTimer = 15 // дефолтное значение
-		}
-		ticker := time.NewTicker(time.Duration(workersTimer) * time.Second)
+		defer state.stopped.Store(true)
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ticker.C:
-				chatCount := a.instance.Repos.ChatRepo.Count(context.Background())
-				msgCount := a.instance.Repos.MessageRepo.Count(context.Background())
-				a.logger.Info("метрики",
-					zap.Int64("chats_total", chatCount),
-					zap.Int64("messages_total", msgCount))
+				start := time.Now()
+				state.lastTick.Store(start.UnixNano())
+				a.instance.Metrics.ObserveWorkerStart(worker, start)
+				err := run()
+				a.instance.Metrics.ObserveWorkerRun(worker, time.Since(start), err)
 			case <-a.ctx.Done():
 				return
 			}
 		}
 	}()
 }
+
+// CheckWorkers проверяет, что все воркеры работают. Зависшим считается воркер, который долго
+// не запускался: значит, предыдущий прогон так и не закончился
+func (a *Application) CheckWorkers(ctx context.Context) error {
+	a.workersMu.Lock()
+	defer a.workersMu.Unlock()
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(a.workers)) {
+		state := a.workers[name]
+		if state.stopped.Load() {
+			errs = append(errs, fmt.Errorf("воркер %s остановлен", name))
+			continue
+		}
+		idle := time.Since(time.Unix(0, state.lastTick.Load()))
+		if idle > workerStallFactor*state.interval {
+			errs = append(errs, fmt.Errorf("воркер %s не запускался %s", name, idle.Round(time.Second)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// startMetricsWorker обновляет количество чатов и сообщений для /metrics
+func (a *Application) startMetricsWorker() {
+	a.runPeriodically("totals", secondsFromEnv("WORKERS_TIMER", 15, a.logger), func() error {
+		chatCount := a.instance.Repos.ChatRepo.Count(a.ctx)
+		msgCount := a.instance.Repos.MessageRepo.Count(a.ctx)
+		a.instance.Metrics.SetTotals(chatCount, msgCount)
+		return nil
+	})
+}
+
+// startMessagePurgeWorker периодически удаляет tombstone-ы с истёкшим окном восстановления
+// и вложения, которые после этого остались без сообщения
+func (a *Application) startMessagePurgeWorker() {
+	a.runPeriodically("message_purge", secondsFromEnv("MESSAGE_PURGE_TIMER", 60, a.logger), func() error {
+		purged, err := a.instance.Services.MessageService.PurgeExpiredMessages(a.ctx)
+		if err != nil {
+			a.logger.Error("не удалось очистить удалённые сообщения", zap.Error(err))
+			return err
+		}
+		if purged > 0 {
+			a.logger.Info("удалённые сообщения очищены", zap.Int64("purged", purged))
+		}
+
+		attachments, err := a.instance.Services.MessageService.PurgeOrphanAttachments(a.ctx)
+		if err != nil {
+			a.logger.Error("не удалось очистить вложения удалённых сообщений", zap.Error(err))
+			return err
+		}
+		if attachments > 0 {
+			a.logger.Info("вложения удалённых сообщений очищены", zap.Int("purged", attachments))
+		}
+		return nil
+	})
+}
+
+// startThumbnailWorker строит превью для новых картинок
+func (a *Application) startThumbnailWorker() {
+	a.runPeriodically("thumbnails", secondsFromEnv("THUMBNAIL_TIMER", 5, a.logger), func() error {
+		processed, err := a.instance.Services.ThumbService.ProcessPending(a.ctx)
+		if err != nil {
+			a.logger.Error("не удалось построить превью картинок", zap.Error(err))
+			return err
+		}
+		if processed > 0 {
+			a.logger.Info("превью картинок построены", zap.Int("processed", processed))
+		}
+		return nil
+	})
+}
+
+// startChatRetentionWorker безвозвратно удаляет чаты с истёкшим сроком хранения в архиве
+func (a *Application) startChatRetentionWorker() {
+	a.runPeriodically("chat_retention", secondsFromEnv("CHAT_PURGE_TIMER", 3600, a.logger), func() error {
+		purged, err := a.instance.Services.ChatService.PurgeArchivedChats(a.ctx)
+		if err != nil {
+			a.logger.Error("не удалось очистить архивные чаты", zap.Error(err))
+			return err
+		}
+		if purged > 0 {
+			a.logger.Info("архивные чаты очищены", zap.Int64("purged", purged))
+		}
+		return nil
+	})
+}
+
+// startIdempotencyPurgeWorker удаляет ключи идемпотентности с истёкшим сроком хранения
+func (a *Application) startIdempotencyPurgeWorker() {
+	a.runPeriodically("idempotency_purge", secondsFromEnv("IDEMPOTENCY_PURGE_TIMER", 600, a.logger), func() error {
+		purged, err := a.instance.Services.IdemService.PurgeExpired(a.ctx)
+		if err != nil {
+			a.logger.Error("не удалось очистить ключи идемпотентности", zap.Error(err))
+			return err
+		}
+		if purged > 0 {
+			a.logger.Info("ключи идемпотентности очищены", zap.Int64("purged", purged))
+		}
+		return nil
+	})
+}
+
+// startRateLimitPurgeWorker удаляет из базы ведра лимитов, к которым давно не обращались.
+// Нужен только для RATE_LIMIT_STORE=postgres, ведра в памяти чистит само хранилище
+func (a *Application) startRateLimitPurgeWorker() {
+	if os.Getenv("RATE_LIMIT_STORE") != "postgres" {
+		return
+	}
+
+	a.runPeriodically("rate_limit_purge", secondsFromEnv("RATE_LIMIT_PURGE_TIMER", 600, a.logger), func() error {
+		//за самый длинный период любое ведро успевает наполниться
+		idleBefore := time.Now().Add(-a.instance.RateLimits.MaxPeriod())
+		purged, err := a.instance.Repos.RateRepo.PurgeIdle(a.ctx, idleBefore)
+		if err != nil {
+			a.logger.Error("не удалось очистить ведра лимитов запросов", zap.Error(err))
+			return err
+		}
+		if purged > 0 {
+			a.logger.Info("ведра лимитов запросов очищены", zap.Int64("purged", purged))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"testing"
+	"testtask5/internal/metrics"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestApplication_CheckWorkers(t *testing.T) {
+	application := NewApplication(&AppInstance{Metrics: metrics.NewMetrics(nil)}, zap.NewNop())
+
+	release := make(chan struct{})
+	application.runPeriodically("healthy", 10*time.Millisecond, func() error { return nil })
+	//прогон не заканчивается, и воркер перестаёт запускаться
+	application.runPeriodically("stalled", 10*time.Millisecond, func() error {
+		<-release
+		return nil
+	})
+
+	require.NoError(t, application.CheckWorkers(t.Context()))
+
+	time.Sleep(60 * time.Millisecond)
+	err := application.CheckWorkers(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "воркер stalled не запускался")
+	assert.NotContains(t, err.Error(), "healthy")
+
+	close(release)
+	application.Stop(time.Second)
+	assert.ErrorContains(t, application.CheckWorkers(t.Context()), "воркер healthy остановлен")
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"crypto/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testtask5/internal/domain"
+	"testtask5/internal/middleware"
+	"testtask5/internal/repo"
+	"testtask5/internal/repo/blobstore"
+	"testtask5/internal/repo/postgres"
+	"testtask5/internal/repo/ratelimit"
+	"testtask5/internal/services"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// secondsFromEnv читает из окружения интервал в секундах, при ошибке возвращает дефолт
+func secondsFromEnv(name string, defaultSeconds int, logger *zap.Logger) time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || seconds <= 0 {
+		logger.Warn("не удалось распарсить интервал из окружения, используется дефолт",
+			zap.String("env", name), zap.Int("default_seconds", defaultSeconds))
+		seconds = defaultSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// ShutdownDrainDelay - сколько при остановке ждать после перевода /readyz в 503, прежде чем закрывать сервер.
+// SHUTDOWN_DRAIN_DELAY в секундах, 0 - не ждать
+func ShutdownDrainDelay(logger *zap.Logger) time.Duration {
+	if os.Getenv("SHUTDOWN_DRAIN_DELAY") == "0" {
+		return 0
+	}
+	return secondsFromEnv("SHUTDOWN_DRAIN_DELAY", 5, logger)
+}
+
+// intFromEnv читает из окружения положительное целое, при ошибке возвращает дефолт
+func intFromEnv(name string, defaultValue int, logger *zap.Logger) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		logger.Warn("не удалось распарсить значение из окружения, используется дефолт",
+			zap.String("env", name), zap.Int("default", defaultValue))
+		return defaultValue
+	}
+	return value
+}
+
+// jwtSecretFromEnv читает ключ подписи токенов. Без JWT_SECRET генерируется случайный ключ,
+// и все токены перестают быть валидными после рестарта
+func jwtSecretFromEnv(logger *zap.Logger) []byte {
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	logger.Warn("JWT_SECRET не задан, используется случайный ключ")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		logger.Fatal("не удалось сгенерировать ключ для JWT", zap.Error(err))
+	}
+	return secret
+}
+
+// blobStoreFromEnv выбирает хранилище вложений по BLOB_STORE: local (по умолчанию) или s3
+func blobStoreFromEnv(logger *zap.Logger) repo.BlobStore {
+	switch kind := os.Getenv("BLOB_STORE"); kind {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/attachments"
+		}
+		store, err := blobstore.NewLocalBlobStore(dir, logger)
+		if err != nil {
+			logger.Fatal("не удалось открыть локальное хранилище вложений", zap.Error(err))
+		}
+		return store
+	case "s3":
+		store, err := blobstore.NewS3BlobStore(blobstore.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+		}, logger)
+		if err != nil {
+			logger.Fatal("не удалось подключиться к S3", zap.Error(err))
+		}
+		return store
+	default:
+		logger.Fatal("неизвестный тип хранилища вложений", zap.String("BLOB_STORE", kind))
+		return nil
+	}
+}
+
+// attachmentLimitsFromEnv читает ограничения на вложения, ATTACHMENT_ALLOWED_TYPES - список типов через запятую
+func attachmentLimitsFromEnv(logger *zap.Logger) services.AttachmentLimits {
+	allowed := os.Getenv("ATTACHMENT_ALLOWED_TYPES")
+	if allowed == "" {
+		allowed = "image/*,application/pdf,text/plain"
+	}
+
+	var allowedTypes []string
+	for _, contentType := range strings.Split(allowed, ",") {
+		if contentType = strings.TrimSpace(contentType); contentType != "" {
+			allowedTypes = append(allowedTypes, contentType)
+		}
+	}
+
+	return services.AttachmentLimits{
+		MaxSize:      int64(intFromEnv("ATTACHMENT_MAX_SIZE", 10<<20, logger)),
+		MaxCount:     intFromEnv("ATTACHMENT_MAX_COUNT", 10, logger),
+		AllowedTypes: allowedTypes,
+	}
+}
+
+// thumbnailConfigFromEnv читает размеры превью из THUMBNAIL_SIZES - список длин большей стороны через запятую
+func thumbnailConfigFromEnv(logger *zap.Logger) services.ThumbnailConfig {
+	config := services.ThumbnailConfig{
+		MaxPixels: intFromEnv("THUMBNAIL_MAX_PIXELS", 50_000_000, logger),
+	}
+
+	for _, sizeStr := range strings.Split(os.Getenv("THUMBNAIL_SIZES"), ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(sizeStr))
+		if err != nil || size <= 0 {
+			continue
+		}
+		config.Sizes = append(config.Sizes, size)
+	}
+
+	if len(config.Sizes) == 0 {
+		logger.Warn("не удалось распарсить THUMBNAIL_SIZES, используются размеры по умолчанию")
+		config.Sizes = []int{160, 480, 1024}
+	}
+
+	return config
+}
+
+// RateLimits - лимиты запросов по группам маршрутов
+type RateLimits struct {
+	Auth     domain.RateLimit // вход, регистрация и обновление токена, по IP
+	API      domain.RateLimit // все запросы с access-токеном
+	Messages domain.RateLimit // отправка сообщений, поверх API
+}
+
+// MaxPeriod - самый длинный период среди лимитов
+func (rl RateLimits) MaxPeriod() time.Duration {
+	return max(rl.Auth.Period, rl.API.Period, rl.Messages.Period)
+}
+
+// rateLimitsFromEnv читает лимиты в формате "запросов/секунд", например RATE_LIMIT_API=300/60.
+// Лимит 0 выключает ограничение группы
+func rateLimitsFromEnv(logger *zap.Logger) RateLimits {
+	return RateLimits{
+		Auth:     rateLimitFromEnv("RATE_LIMIT_AUTH", domain.RateLimit{Burst: 10, Period: time.Minute}, logger),
+		API:      rateLimitFromEnv("RATE_LIMIT_API", domain.RateLimit{Burst: 300, Period: time.Minute}, logger),
+		Messages: rateLimitFromEnv("RATE_LIMIT_MESSAGES", domain.RateLimit{Burst: 30, Period: time.Minute}, logger),
+	}
+}
+
+func rateLimitFromEnv(name string, defaultLimit domain.RateLimit, logger *zap.Logger) domain.RateLimit {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "0" {
+		return domain.RateLimit{}
+	}
+
+	burstStr, periodStr, _ := strings.Cut(value, "/")
+	burst, burstErr := strconv.Atoi(burstStr)
+	seconds, periodErr := strconv.Atoi(periodStr)
+	if burstErr != nil || periodErr != nil || burst <= 0 || seconds <= 0 {
+		logger.Warn("не удалось распарсить лимит запросов из окружения, используется дефолт",
+			zap.String("env", name), zap.Int("default_burst", defaultLimit.Burst),
+			zap.Duration("default_period", defaultLimit.Period))
+		return defaultLimit
+	}
+
+	return domain.RateLimit{Burst: burst, Period: time.Duration(seconds) * time.Second}
+}
+
+// rateLimitStoreFromEnv выбирает хранилище лимитов по RATE_LIMIT_STORE: memory (по умолчанию) или postgres.
+// С memory каждая реплика считает лимиты отдельно, с postgres лимит общий
+func rateLimitStoreFromEnv(sharedStore *postgres.RateLimitRepoPostgres, logger *zap.Logger) middleware.RateLimitStore {
+	switch kind := os.Getenv("RATE_LIMIT_STORE"); kind {
+	case "", "memory":
+		return ratelimit.NewMemoryRateLimitStore()
+	case "postgres":
+		return sharedStore
+	default:
+		logger.Fatal("неизвестный тип хранилища лимитов", zap.String("RATE_LIMIT_STORE", kind))
+		return nil
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"database/sql"
 	"errors"
 	"os"
+	repoPostgres "testtask5/internal/repo/postgres"
 
 	"github.com/pressly/goose/v3"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// migrationsDir - каталог миграций goose, в образе лежит рядом с бинарником
+const migrationsDir = "./migrations"
+
 func InitDb() (*gorm.DB, error) {
 	dbURL := os.Getenv("DATABASE_URL")
 	if dbURL == "" {
@@ -22,12 +26,30 @@ func InitDb() (*gorm.DB, error) {
 	}
 	defer sqlDB.Close()
 
-	if err := goose.Up(sqlDB, "./migrations"); err != nil {
+	if err := goose.Up(sqlDB, migrationsDir); err != nil {
 		return nil, err
 	}
 	gormDB, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
+	//спаны на каждый запрос к базе
+	if err := gormDB.Use(repoPostgres.NewTracingPlugin()); err != nil {
+		return nil, err
+	}
 	return gormDB, nil
 }
+
+// latestMigrationVersion - версия последней миграции, которую знает код. Проверка готовности
+// сверяет с ней версию схемы в базе
+func latestMigrationVersion() (int64, error) {
+	migrations, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, err
+	}
+	return last.Version, nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	grpcHandlers "testtask5/internal/interfaces/grpcAPI"
+	"testtask5/internal/interfaces/grpcAPI/messengerpb"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// grpcKeepaliveTime - как часто пинговать клиента без активности, чтобы прокси не рвали долгие потоки,
+// как heartbeat у SSE
+const grpcKeepaliveTime = 15 * time.Second
+
+// NewGRPCServer собирает gRPC-сервер поверх тех же сервисов, что и HTTP API
+func NewGRPCServer(appInstance *AppInstance, appLogger *zap.Logger) *grpc.Server {
+	//лимиты, идемпотентность и таймаут те же, что у HTTP API
+	interceptors := grpcHandlers.NewServerInterceptors(
+		appInstance.Services.AuthService,
+		appInstance.RateLimiter,
+		grpcHandlers.CallLimits{API: appInstance.RateLimits.API, Messages: appInstance.RateLimits.Messages},
+		appInstance.Services.IdemService,
+		requestTimeout,
+		appLogger,
+	)
+
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(interceptors.Unary),
+		grpc.ChainStreamInterceptor(interceptors.Stream),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: grpcKeepaliveTime}),
+	)
+	messengerpb.RegisterChatServiceServer(srv, appInstance.API.ChatGRPC)
+	messengerpb.RegisterMessageServiceServer(srv, appInstance.API.MessageGRPC)
+	return srv
+}
+
+// StopGRPCServer дожидается текущих вызовов, а по истечении ctx обрывает оставшиеся.
+// Потоки сообщений к этому моменту уже закрыты через AppInstance.Shutdown
+func StopGRPCServer(ctx context.Context, srv *grpc.Server, logger *zap.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Error("gRPC-сервер не завершился вовремя, вызовы прерваны")
+		srv.Stop()
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"os"
+	httpHandlers "testtask5/internal/interfaces/httpAPI"
+	"testtask5/internal/middleware"
+	"testtask5/internal/openapi"
+
+	"go.uber.org/zap"
+)
+
+// initOpenAPI загружает вшитую спецификацию и строит по ней страницу документации и валидатор.
+// Ответы проверяются только с OPENAPI_VALIDATE_RESPONSES=true: это тестовый режим, ответы в нём буферизуются
+func initOpenAPI(logger *zap.Logger) (*httpHandlers.DocsAPIHTTP, *middleware.OpenAPIValidator) {
+	doc, err := openapi.Load()
+	if err != nil {
+		logger.Fatal("не удалось загрузить спецификацию OpenAPI", zap.Error(err))
+	}
+
+	docsAPI, err := httpHandlers.NewDocsAPIHTTP(doc, logger)
+	if err != nil {
+		logger.Fatal("не удалось закодировать спецификацию OpenAPI", zap.Error(err))
+	}
+
+	validator, err := middleware.NewOpenAPIValidator(doc, os.Getenv("OPENAPI_VALIDATE_RESPONSES") == "true", logger)
+	if err != nil {
+		logger.Fatal("не удалось создать валидатор OpenAPI", zap.Error(err))
+	}
+	return docsAPI, validator
+}
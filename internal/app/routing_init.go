@@ -1,17 +1,116 @@
 package app
 
 import (
+	"net/http"
+	"testtask5/internal/middleware"
+	"time"
+
 	"github.com/go-chi/chi"
+	"go.uber.org/zap"
 )
 
-func RegisterRoutes(r chi.Router, app *AppInstance) {
+// requestTimeout - сколько может обрабатываться обычный запрос
+const requestTimeout = 2 * time.Second
+
+func RegisterRoutes(r chi.Router, app *AppInstance, appLogger *zap.Logger) {
+	timeout := middleware.TimeoutMiddleware(requestTimeout)
+
+	//Потоки событий и загрузка вложений регистрируются без таймаута, остальные маршруты - с ним
+	r.Group(func(r chi.Router) {
+		r.Use(timeout)
+		registerPublicRoutes(r, app)
+	})
+
+	//Всё остальное доступно только с access-токеном
+	requireAuth := middleware.AuthMiddleware(app.Services.AuthService)
+	//лимит ставится после аутентификации, чтобы считать запросы по пользователю
+	apiLimit := app.RateLimiter.Limit("api", app.RateLimits.API)
+	//повтор запроса с тем же Idempotency-Key не создаёт дубликат
+	idempotent := middleware.IdempotencyMiddleware(app.Services.IdemService, app.Services.ChatService.MaxUploadSize(), appLogger)
+	//на отправку сообщений отдельный, более строгий лимит против спама
+	messagesLimit := app.RateLimiter.Limit("messages", app.RateLimits.Messages)
+
 	r.Route("/chats", func(r chi.Router) {
+		//потоки живут, пока открыто соединение. Браузер не умеет ставить заголовки при открытии
+		//WebSocket и EventSource, поэтому только здесь токен принимается и из ?access_token=
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.StreamAuthMiddleware(app.Services.AuthService), apiLimit)
 
-		//Хэндлеры чата
-		r.Post("/", app.API.ChatAPI.CreateChat)
-		r.Post("/{id}", app.API.ChatAPI.GetChat)
-		r.Delete("/{id}", app.API.ChatAPI.DeleteChat)
-		r.Post("/{id}/messages/", app.API.ChatAPI.SendMessage)
+			r.Get("/{id}/ws", app.API.ChatWSAPI.Subscribe)
+			r.Get("/{id}/events", app.API.ChatSSEAPI.Stream)
+		})
 
+		r.Group(func(r chi.Router) {
+			r.Use(requireAuth, apiLimit)
+
+			//загрузка вложений может идти дольше таймаута, её размер ограничивает сам хэндлер
+			r.With(messagesLimit, idempotent).Post("/{id}/messages/", app.API.ChatAPI.SendMessage)
+
+			r.Group(func(r chi.Router) {
+				r.Use(timeout)
+				registerChatRoutes(r, app, idempotent)
+			})
+		})
 	})
+
+	r.With(requireAuth, apiLimit, timeout).Get("/search/messages", app.API.MessageAPI.SearchMessages)
+}
+
+func registerPublicRoutes(r chi.Router, app *AppInstance) {
+	//Метрики для Prometheus
+	r.Method("GET", "/metrics", app.Metrics.Handler())
+
+	//Документация API
+	r.Get("/openapi.json", app.API.DocsAPI.Spec)
+	r.Get("/docs", app.API.DocsAPI.UI)
+
+	//Пробы оркестратора
+	r.Get("/healthz", app.API.HealthAPI.Healthz)
+	r.Get("/readyz", app.API.HealthAPI.Readyz)
+
+	//Публичные хэндлеры аутентификации
+	r.Route("/auth", func(r chi.Router) {
+		//подбор паролей и массовая регистрация ограничиваются по IP
+		r.Use(app.RateLimiter.Limit("auth", app.RateLimits.Auth))
+
+		r.Post("/register", app.API.AuthAPI.Register)
+		r.Post("/login", app.API.AuthAPI.Login)
+		r.Post("/refresh", app.API.AuthAPI.Refresh)
+	})
+}
+
+// registerChatRoutes - маршруты /chats, которые укладываются в таймаут
+func registerChatRoutes(r chi.Router, app *AppInstance, idempotent func(http.Handler) http.Handler) {
+	//Хэндлеры чата
+	r.Get("/", app.API.ChatAPI.ListChats)
+	r.With(idempotent).Post("/", app.API.ChatAPI.CreateChat)
+	r.Get("/{id}", app.API.ChatAPI.GetChat)
+	r.Post("/{id}", app.API.ChatAPI.GetChat)
+	r.Patch("/{id}", app.API.ChatAPI.RenameChat)
+	r.Delete("/{id}", app.API.ChatAPI.DeleteChat)
+	r.Post("/{id}/restore", app.API.ChatAPI.RestoreChat)
+	r.Get("/{id}/changes", app.API.MessageAPI.GetChanges)
+
+	//Хэндлеры закреплённых сообщений
+	r.Get("/{id}/pins", app.API.ChatAPI.ListPinned)
+	r.Post("/{id}/pins/{msgID}", app.API.ChatAPI.PinMessage)
+	r.Delete("/{id}/pins/{msgID}", app.API.ChatAPI.UnpinMessage)
+
+	//Хэндлеры участников
+	r.Get("/{id}/members", app.API.ChatAPI.ListMembers)
+	r.Post("/{id}/members", app.API.ChatAPI.AddMember)
+	r.Patch("/{id}/members/{userID}", app.API.ChatAPI.ChangeMemberRole)
+	r.Delete("/{id}/members/{userID}", app.API.ChatAPI.RemoveMember)
+	r.Post("/{id}/transfer-ownership", app.API.ChatAPI.TransferOwnership)
+
+	//Хэндлеры сообщений
+	r.Patch("/{id}/messages/{msgID}", app.API.MessageAPI.EditMessage)
+	r.Delete("/{id}/messages/{msgID}", app.API.MessageAPI.DeleteMessage)
+	r.Post("/{id}/messages/{msgID}/restore", app.API.MessageAPI.RestoreMessage)
+	r.Get("/{id}/messages/{msgID}/revisions", app.API.MessageAPI.GetRevisions)
+	r.Get("/{id}/messages/{msgID}/thread", app.API.MessageAPI.GetThread)
+	r.Post("/{id}/messages/{msgID}/reactions", app.API.MessageAPI.AddReaction)
+	r.Delete("/{id}/messages/{msgID}/reactions", app.API.MessageAPI.RemoveReaction)
+	r.Get("/{id}/messages/{msgID}/attachments/{attID}", app.API.MessageAPI.DownloadAttachment)
+	r.Get("/{id}/messages/{msgID}/attachments/{attID}/thumbnails/{size}", app.API.MessageAPI.DownloadThumbnail)
 }
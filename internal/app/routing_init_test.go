@@ -0,0 +1,59 @@
+package app
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"testtask5/internal/metrics"
+	"testtask5/internal/middleware"
+	"testtask5/internal/openapi"
+	"testtask5/internal/services"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// routesInstance - AppInstance, которого хватает, чтобы собрать роутер. Хэндлеры не вызываются
+func routesInstance() *AppInstance {
+	logger := zap.NewNop()
+	attachments := services.NewAttachmentStorage(nil, services.AttachmentLimits{MaxSize: 1, MaxCount: 1}, time.Second, logger)
+	return &AppInstance{
+		Services: &AppServices{
+			ChatService: services.NewChatService(nil, nil, nil, nil, nil, attachments, 1, time.Second, logger),
+		},
+		API:         &AppAPIs{},
+		Metrics:     metrics.NewMetrics(nil),
+		RateLimiter: middleware.NewRateLimiter(nil, false, logger),
+	}
+}
+
+func TestRegisterRoutes_MatchOpenAPISpec(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	RegisterRoutes(router, routesInstance(), zap.NewNop())
+
+	var routes []string
+	err = chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		//chi добавляет /* к смонтированным подроутерам, если маршрут объявлен внутри Route
+		routes = append(routes, method+" "+strings.ReplaceAll(route, "/*/", "/"))
+		return nil
+	})
+	require.NoError(t, err)
+
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	assert.Equal(t, documented, routes, "маршруты RegisterRoutes и openapi.yaml разошлись")
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testtask5/internal/tracing"
+
+	"go.uber.org/zap"
+)
+
+// InitTracing настраивает экспорт трейсов по TRACING_EXPORTER: none (по умолчанию), stdout, file или otlp.
+// Возвращает функцию, которая досылает спаны при остановке
+func InitTracing(logger *zap.Logger) func(context.Context) error {
+	serviceName := os.Getenv("SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = "messanger"
+	}
+
+	filePath := os.Getenv("TRACING_FILE")
+	if filePath == "" {
+		filePath = fmt.Sprintf("logs/%s/traces.jsonl", serviceName)
+	}
+
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: serviceName,
+		Exporter:    os.Getenv("TRACING_EXPORTER"),
+		FilePath:    filePath,
+		SampleRatio: sampleRatioFromEnv(logger),
+	})
+	if err != nil {
+		logger.Fatal("не удалось настроить трейсинг", zap.Error(err))
+	}
+	return shutdown
+}
+
+// sampleRatioFromEnv читает TRACING_SAMPLE_RATIO - долю трейсов от 0 до 1, по умолчанию пишутся все
+func sampleRatioFromEnv(logger *zap.Logger) float64 {
+	value := os.Getenv("TRACING_SAMPLE_RATIO")
+	if value == "" {
+		return 1
+	}
+
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		logger.Warn("не удалось распарсить TRACING_SAMPLE_RATIO, пишутся все трейсы", zap.String("value", value))
+		return 1
+	}
+	return ratio
+}
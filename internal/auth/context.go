@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"context"
+	"testtask5/internal/domain"
+)
+
+type userCtxKey struct{}
+
+// WithUser кладёт аутентифицированного пользователя в контекст запроса
+func WithUser(ctx context.Context, user *domain.UserDomain) context.Context {
+	return context.WithValue(ctx, userCtxKey{}, user)
+}
+
+// UserFromContext достаёт пользователя, положенного middleware аутентификации
+func UserFromContext(ctx context.Context) (*domain.UserDomain, bool) {
+	user, ok := ctx.Value(userCtxKey{}).(*domain.UserDomain)
+	return user, ok && user != nil
+}
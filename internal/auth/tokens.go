@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"testtask5/internal/domain"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+// tokenClaims - полезная нагрузка JWT, Type не даёт использовать refresh-токен вместо access
+type tokenClaims struct {
+	Username string `json:"username"`
+	Type     string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager выпускает и проверяет подписанные HS256 access и refresh токены
+type TokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenManager(secret []byte, accessTTL time.Duration, refreshTTL time.Duration) *TokenManager {
+	return &TokenManager{
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// IssuePair выпускает новую пару токенов для пользователя
+func (tm *TokenManager) IssuePair(user *domain.UserDomain) (*domain.TokenPair, error) {
+	now := time.Now()
+
+	access, err := tm.sign(user, tokenTypeAccess, now, tm.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := tm.sign(user, tokenTypeRefresh, now, tm.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    tm.accessTTL,
+	}, nil
+}
+
+func (tm *TokenManager) ParseAccessToken(token string) (*domain.UserDomain, error) {
+	return tm.parse(token, tokenTypeAccess)
+}
+
+func (tm *TokenManager) ParseRefreshToken(token string) (*domain.UserDomain, error) {
+	return tm.parse(token, tokenTypeRefresh)
+}
+
+func (tm *TokenManager) sign(user *domain.UserDomain, tokenType string, now time.Time, ttl time.Duration) (string, error) {
+	claims := &tokenClaims{
+		Username: user.Username,
+		Type:     tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(tm.secret)
+}
+
+func (tm *TokenManager) parse(token string, tokenType string) (*domain.UserDomain, error) {
+	claims := &tokenClaims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return tm.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, errors.Join(domain.ErrInvalidToken, err)
+	}
+
+	if claims.Type != tokenType {
+		return nil, domain.ErrInvalidToken
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 {
+		return nil, domain.ErrInvalidToken
+	}
+
+	return &domain.UserDomain{
+		ID:             userID,
+		Username:       claims.Username,
+		TokenExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
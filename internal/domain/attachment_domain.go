@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"io"
+	"time"
+)
+
+// AttachmentDomain - файл, приложенный к сообщению. Содержимое лежит в хранилище под StorageKey
+type AttachmentDomain struct {
+	ID          int
+	MessageID   int
+	FileName    string
+	ContentType string
+	Size        int64
+	StorageKey  string `json:"-"` // внутренний ключ хранилища наружу не отдаём
+	CreatedAt   time.Time
+	Width       int // размеры известны только у картинок, для которых уже построены превью
+	Height      int
+	Thumbnails  []*ThumbnailDomain
+}
+
+// ThumbnailDomain - уменьшенная копия картинки, вписанная в квадрат MaxSide x MaxSide.
+// Лежит в хранилище рядом с оригиналом
+type ThumbnailDomain struct {
+	MaxSide     int
+	Width       int
+	Height      int
+	ContentType string
+	Size        int64
+	StorageKey  string `json:"-"`
+}
+
+// NeedsThumbnails - для jpeg, png и gif строятся превью
+func (a *AttachmentDomain) NeedsThumbnails() bool {
+	switch a.ContentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Thumbnail возвращает превью заданного размера
+func (a *AttachmentDomain) Thumbnail(maxSide int) (*ThumbnailDomain, bool) {
+	for _, thumbnail := range a.Thumbnails {
+		if thumbnail.MaxSide == maxSide {
+			return thumbnail, true
+		}
+	}
+	return nil, false
+}
+
+// AttachmentUpload - загружаемый файл до сохранения в хранилище.
+// ContentType определяется по содержимому, а не по заголовку клиента
+type AttachmentUpload struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	Content     io.Reader
+}
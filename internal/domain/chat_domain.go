@@ -5,8 +5,21 @@ import (
 )
 
 type ChatDomain struct {
-	ID        int
-	Title     string
-	CreatedAt time.Time
-	Messages  []*MessageDomain
+	ID         int
+	Title      string
+	OwnerID    int // при создании - пользователь, который станет владельцем
+	Version    int // растёт при каждом изменении чата, для оптимистичных блокировок
+	CreatedAt  time.Time
+	ArchivedAt *time.Time // чат в архиве: скрыт из чтения и закрыт для новых сообщений
+	Messages   []*MessageDomain
+	Pinned     []*PinDomain
+	NextCursor *int // id для запроса более старых сообщений (?before=)
+	PrevCursor *int // id для запроса более новых сообщений (?after=)
+}
+
+// ChatList - страница списка чатов
+type ChatList struct {
+	Chats      []*ChatDomain
+	Total      int64
+	NextCursor string
 }
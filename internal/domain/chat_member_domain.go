@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+type ChatRole string
+
+const (
+	RoleOwner  ChatRole = "owner"
+	RoleAdmin  ChatRole = "admin"
+	RoleMember ChatRole = "member"
+)
+
+// roleRank задаёт старшинство ролей для проверок "не ниже чем"
+var roleRank = map[ChatRole]int{
+	RoleMember: 1,
+	RoleAdmin:  2,
+	RoleOwner:  3,
+}
+
+func (r ChatRole) IsValid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// AtLeast сообщает, что роль не ниже required
+func (r ChatRole) AtLeast(required ChatRole) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+type ChatMemberDomain struct {
+	ChatID    int
+	UserID    int
+	Role      ChatRole
+	CreatedAt time.Time
+}
@@ -3,7 +3,38 @@ package domain
 import "errors"
 
 var (
-	ErrChatNotFound      = errors.New("чат не найден")
-	ErrChatAlreadyExists = errors.New("чат уже существует")
-	ErrFieldIsNotAllowed = errors.New("не разрешенное для фильтрации поле")
+	ErrChatNotFound       = errors.New("чат не найден")
+	ErrChatAlreadyExists  = errors.New("чат уже существует")
+	ErrChatArchived       = errors.New("чат в архиве")
+	ErrChatNotArchived    = errors.New("чат не в архиве")
+	ErrVersionMismatch    = errors.New("версия чата не совпадает")
+	ErrFieldIsNotAllowed  = errors.New("не разрешенное для фильтрации поле")
+	ErrInvalidCursor      = errors.New("некорректный курсор")
+	ErrMessageNotFound    = errors.New("сообщение не найдено")
+	ErrMessageDeleted     = errors.New("сообщение удалено")
+	ErrMessageNotDeleted  = errors.New("сообщение не удалено")
+	ErrRestoreExpired     = errors.New("время на восстановление сообщения истекло")
+	ErrParentNotInChat    = errors.New("родительское сообщение не найдено в этом чате")
+	ErrUserAlreadyExists  = errors.New("пользователь уже существует")
+	ErrUserNotFound       = errors.New("пользователь не найден")
+	ErrInvalidCredentials = errors.New("неверный логин или пароль")
+	ErrInvalidToken       = errors.New("некорректный или просроченный токен")
+	ErrUnauthorized       = errors.New("требуется аутентификация")
+	ErrNotChatMember      = errors.New("пользователь не состоит в чате")
+	ErrForbidden          = errors.New("недостаточно прав")
+	ErrMemberNotFound     = errors.New("участник не найден")
+	ErrMemberExists       = errors.New("пользователь уже состоит в чате")
+	ErrInvalidRole        = errors.New("некорректная роль")
+	ErrOwnerCannotLeave   = errors.New("владелец не может покинуть чат, сначала передайте владение")
+	ErrResyncRequired     = errors.New("изменения уже очищены, требуется полная синхронизация")
+	ErrAttachmentNotFound = errors.New("вложение не найдено")
+	ErrAttachmentTooLarge = errors.New("вложение превышает допустимый размер")
+	ErrAttachmentType     = errors.New("недопустимый тип вложения")
+	ErrTooManyAttachments = errors.New("слишком много вложений")
+	ErrEmptyMessage       = errors.New("сообщение без текста и вложений")
+	ErrAlreadyPinned      = errors.New("сообщение уже закреплено")
+	ErrNotPinned          = errors.New("сообщение не закреплено")
+	ErrPinLimitReached    = errors.New("достигнут лимит закреплённых сообщений")
+	ErrIdempotencyReused  = errors.New("ключ идемпотентности уже использован с другим запросом")
+	ErrIdempotencyBusy    = errors.New("запрос с этим ключом идемпотентности ещё выполняется")
 )
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+type ChatEventType string
+
+const (
+	EventMessageCreated  ChatEventType = "message.created"
+	EventMessageEdited   ChatEventType = "message.edited"
+	EventMessageDeleted  ChatEventType = "message.deleted"
+	EventMessageRestored ChatEventType = "message.restored"
+	EventMessagePinned   ChatEventType = "message.pinned"
+	EventMessageUnpinned ChatEventType = "message.unpinned"
+	EventChatRenamed     ChatEventType = "chat.renamed"
+	EventChatArchived    ChatEventType = "chat.archived"
+	EventChatRestored    ChatEventType = "chat.restored"
+	EventChatDeleted     ChatEventType = "chat.deleted"
+	EventMemberRemoved   ChatEventType = "member.removed"
+)
+
+// EndsStream - после такого события подписчикам больше нечего ждать от чата
+func (t ChatEventType) EndsStream() bool {
+	return t == EventChatDeleted || t == EventChatArchived
+}
+
+// ChatEvent - изменение в чате, которое рассылается подписчикам в реальном времени.
+// ID присваивается при сохранении в журнал событий и растёт монотонно в пределах чата
+type ChatEvent struct {
+	ID         int64
+	Type       ChatEventType
+	ChatID     int
+	Message    *MessageDomain // для событий message.*
+	Chat       *ChatDomain    // для событий chat.*
+	ActorID    *int           // кто выполнил действие, заполняется у закрепления и исключения
+	MemberID   *int           // для member.removed - кого исключили или кто вышел
+	OccurredAt time.Time
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// HealthCheckResult - итог одной проверки готовности. Err пустой, если проверка прошла
+type HealthCheckResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// HealthReport - итог всех проверок готовности
+type HealthReport struct {
+	Checks []HealthCheckResult
+}
+
+func (r *HealthReport) Ready() bool {
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord - запрос с заголовком Idempotency-Key и сохранённый ответ на него.
+// Scope - метод и путь запроса, RequestHash - sha256 тела
+type IdempotencyRecord struct {
+	UserID      int
+	Key         string
+	Scope       string
+	RequestHash string
+	StatusCode  int // 0, пока первый запрос ещё выполняется
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -3,8 +3,77 @@ package domain
 import "time"
 
 type MessageDomain struct {
+	ID              int
+	Text            string
+	ChatID          int
+	Seq             int64 // номер последнего изменения сообщения, растёт монотонно в пределах чата
+	ParentMessageID *int  // nil у корневых сообщений, иначе id корня треда
+	AuthorID        *int  // nil у сообщений, отправленных до появления аутентификации
+	CreatedAt       time.Time
+	EditedAt        *time.Time
+	DeletedAt       *time.Time // у удалённого сообщения (tombstone) текст пустой
+	ReplyCount      int        // заполняется только у корневых сообщений
+	LastReplyAt     *time.Time
+	Reactions       []ReactionCount
+	Attachments     []*AttachmentDomain
+}
+
+// MessagePage - страница истории чата, сообщения отсортированы от новых к старым
+type MessagePage struct {
+	Messages []*MessageDomain
+	HasOlder bool
+	HasNewer bool
+}
+
+// Cursors возвращает id крайних сообщений страницы для запроса более старых (?before=) и более новых (?after=)
+func (p *MessagePage) Cursors() (next *int, prev *int) {
+	if len(p.Messages) == 0 {
+		return nil, nil
+	}
+	if p.HasOlder {
+		oldest := p.Messages[len(p.Messages)-1].ID
+		next = &oldest
+	}
+	if p.HasNewer {
+		newest := p.Messages[0].ID
+		prev = &newest
+	}
+	return next, prev
+}
+
+// MessageChanges - изменения чата после since_seq в порядке возрастания seq.
+// Каждое сообщение приходит в последнем состоянии, удалённые - tombstone-ами
+type MessageChanges struct {
+	Messages []*MessageDomain
+	ChatSeq  int64 // последний выданный seq чата
+	HasMore  bool
+}
+
+// MessageThread - корневое сообщение и страница ответов на него
+type MessageThread struct {
+	Root       *MessageDomain
+	Replies    []*MessageDomain
+	NextCursor *int
+	PrevCursor *int
+}
+
+// MessageRevisionDomain - предыдущая версия текста сообщения, CreatedAt - момент правки
+type MessageRevisionDomain struct {
 	ID        int
+	MessageID int
 	Text      string
-	ChatID    int
 	CreatedAt time.Time
 }
+
+// MessageSearchHit - найденное сообщение с релевантностью и фрагментом с подсветкой
+type MessageSearchHit struct {
+	Message *MessageDomain
+	Rank    float64
+	Snippet string
+}
+
+// MessageSearchResult - страница результатов поиска, отсортированных по релевантности
+type MessageSearchResult struct {
+	Hits       []*MessageSearchHit
+	NextCursor string
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// PinDomain - закреплённое сообщение чата
+type PinDomain struct {
+	ChatID    int
+	MessageID int
+	PinnedBy  *int // nil, если закрепивший пользователь удалён
+	PinnedAt  time.Time
+	Message   *MessageDomain
+}
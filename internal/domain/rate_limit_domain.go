@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit - лимит запросов по алгоритму token bucket: в ведре помещается Burst токенов,
+// пустое ведро наполняется за Period, каждый запрос забирает один токен
+type RateLimit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// RateLimitResult - итог попытки забрать токен
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // через сколько появится следующий токен, если запрос не пропущен
+	ResetAfter time.Duration // через сколько ведро наполнится целиком
+}
+
+// Take пополняет ведро, в котором было tokens на момент refilledAt, и забирает из него токен.
+// Возвращает новое количество токенов
+func (l RateLimit) Take(tokens float64, refilledAt time.Time, now time.Time) (float64, RateLimitResult) {
+	perSecond := float64(l.Burst) / l.Period.Seconds()
+	if elapsed := now.Sub(refilledAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(l.Burst), tokens+elapsed*perSecond)
+	}
+
+	result := RateLimitResult{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsDuration((1 - tokens) / perSecond)
+	}
+
+	result.Remaining = int(tokens)
+	result.ResetAfter = secondsDuration((float64(l.Burst) - tokens) / perSecond)
+	return tokens, result
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
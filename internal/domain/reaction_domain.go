@@ -0,0 +1,14 @@
+package domain
+
+// ReactionDomain - реакция одного участника на сообщение
+type ReactionDomain struct {
+	MessageID int
+	Reactor   string
+	Emoji     string
+}
+
+// ReactionCount - сколько раз сообщению поставили эмодзи
+type ReactionCount struct {
+	Emoji string
+	Count int
+}
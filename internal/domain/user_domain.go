@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+type UserDomain struct {
+	ID           int
+	Username     string
+	PasswordHash string
+	CreatedAt    time.Time
+
+	TokenExpiresAt time.Time // срок access-токена, с которым пришёл запрос, заполняется при его разборе
+}
+
+// TokenPair - выданные пользователю токены, ExpiresIn - время жизни access-токена
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
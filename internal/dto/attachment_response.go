@@ -0,0 +1,19 @@
+package dto
+
+type AttachmentResponse struct {
+	ID          int                  `json:"id"`
+	FileName    string               `json:"file_name"`
+	ContentType string               `json:"content_type"`
+	Size        int64                `json:"size"`
+	URL         string               `json:"url"`
+	Width       int                  `json:"width,omitempty"`
+	Height      int                  `json:"height,omitempty"`
+	Thumbnails  []*ThumbnailResponse `json:"thumbnails,omitempty"`
+}
+
+type ThumbnailResponse struct {
+	MaxSide int    `json:"max_side"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	URL     string `json:"url"`
+}
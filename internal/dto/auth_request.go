@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,50}$`)
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (req *RegisterRequest) Validate() error {
+	req.Username = strings.TrimSpace(req.Username)
+	if !usernamePattern.MatchString(req.Username) {
+		return errors.New("username должен быть от 3 до 50 символов: латиница, цифры, _ . -")
+	}
+	if len(req.Password) < 8 {
+		return errors.New("password слишком короткий(8 минимум)")
+	}
+	// bcrypt учитывает только первые 72 байта пароля
+	if len(req.Password) > 72 {
+		return errors.New("password слишком длинный(72 максимум)")
+	}
+	return nil
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (req *LoginRequest) Validate() error {
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" || req.Password == "" {
+		return errors.New("username и password обязательны")
+	}
+	return nil
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (req *RefreshRequest) Validate() error {
+	if strings.TrimSpace(req.RefreshToken) == "" {
+		return errors.New("refresh_token не может быть пустым")
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package dto
+
+type MessageChangeResponse struct {
+	Seq     int64            `json:"seq"`
+	Type    string           `json:"type"` // created, edited или deleted - по последнему состоянию сообщения
+	Message *MessageResponse `json:"message"`
+}
+
+type ChangesResponse struct {
+	ChatID       int                      `json:"chat_id"`
+	Changes      []*MessageChangeResponse `json:"changes"`
+	NextSinceSeq int64                    `json:"next_since_seq"`
+	ChatSeq      int64                    `json:"chat_seq"`
+	HasMore      bool                     `json:"has_more"`
+}
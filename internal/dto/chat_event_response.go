@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+type ChatEventResponse struct {
+	ID         int64            `json:"id,omitempty"`
+	Type       string           `json:"type"`
+	ChatID     int              `json:"chat_id"`
+	Message    *MessageResponse `json:"message,omitempty"`
+	Title      string           `json:"title,omitempty"`
+	ActorID    *int             `json:"actor_id,omitempty"`
+	MemberID   *int             `json:"member_id,omitempty"`
+	OccurredAt time.Time        `json:"occurred_at"`
+}
@@ -1,13 +1,14 @@
 package dto
 
-import (
-	"testtask5/internal/domain"
-	"time"
-)
+import "time"
 
 type CreateChatResponse struct {
-	ID        int                     `json:"id"`
-	Title     string                  `json:"title"`
-	CreatedAt time.Time               `json:"created_at"`
-	Messages  []*domain.MessageDomain `json:"messages"`
+	ID         int                `json:"id"`
+	Title      string             `json:"title"`
+	Version    int                `json:"version"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Messages   []*MessageResponse `json:"messages"`
+	Pinned     []*PinResponse     `json:"pinned,omitempty"`
+	NextCursor *int               `json:"next_cursor,omitempty"`
+	PrevCursor *int               `json:"prev_cursor,omitempty"`
 }
@@ -6,16 +6,29 @@ import (
 )
 
 type CreateMessageRequest struct {
-	Text string `json:"text"`
+	Text            string `json:"text"`
+	ParentMessageID *int   `json:"parent_message_id,omitempty"`
 }
 
 func (req *CreateMessageRequest) Validate() error {
+	return req.validate(false)
+}
+
+// ValidateWithAttachments - проверка для сообщения с вложениями, текст у него необязателен
+func (req *CreateMessageRequest) ValidateWithAttachments() error {
+	return req.validate(true)
+}
+
+func (req *CreateMessageRequest) validate(allowEmptyText bool) error {
 	req.Text = strings.TrimSpace(req.Text)
-	if req.Text == "" {
+	if req.Text == "" && !allowEmptyText {
 		return errors.New("text не может быть пустым")
 	}
 	if len(req.Text) > 5000 {
 		return errors.New("text слишком длинный(5000 максимум)")
 	}
+	if req.ParentMessageID != nil && *req.ParentMessageID <= 0 {
+		return errors.New("parent_message_id должен быть положительным числом")
+	}
 	return nil
 }
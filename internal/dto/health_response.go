@@ -0,0 +1,17 @@
+package dto
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type ReadinessResponse struct {
+	Status string                 `json:"status"`
+	Checks []*HealthCheckResponse `json:"checks"`
+}
+
+type HealthCheckResponse struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+type ChatListItem struct {
+	ID         int        `json:"id"`
+	Title      string     `json:"title"`
+	Version    int        `json:"version"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+type ListChatsResponse struct {
+	Chats      []*ChatListItem `json:"chats"`
+	Total      int64           `json:"total"`
+	Limit      int             `json:"limit"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
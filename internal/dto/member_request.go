@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+)
+
+type AddMemberRequest struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (req *AddMemberRequest) Validate() error {
+	if req.UserID <= 0 {
+		return errors.New("user_id должен быть положительным числом")
+	}
+	req.Role = strings.TrimSpace(req.Role)
+	if req.Role == "" {
+		req.Role = "member"
+	}
+	return nil
+}
+
+type ChangeMemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (req *ChangeMemberRoleRequest) Validate() error {
+	req.Role = strings.TrimSpace(req.Role)
+	if req.Role == "" {
+		return errors.New("role не может быть пустым")
+	}
+	return nil
+}
+
+type TransferOwnershipRequest struct {
+	UserID int `json:"user_id"`
+}
+
+func (req *TransferOwnershipRequest) Validate() error {
+	if req.UserID <= 0 {
+		return errors.New("user_id должен быть положительным числом")
+	}
+	return nil
+}
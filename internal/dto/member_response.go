@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type MemberResponse struct {
+	ChatID    int       `json:"chat_id"`
+	UserID    int       `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
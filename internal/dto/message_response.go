@@ -0,0 +1,19 @@
+package dto
+
+import "time"
+
+type MessageResponse struct {
+	ID              int                      `json:"id"`
+	ChatID          int                      `json:"chat_id"`
+	Seq             int64                    `json:"seq"`
+	ParentMessageID *int                     `json:"parent_message_id,omitempty"`
+	AuthorID        *int                     `json:"author_id,omitempty"`
+	Text            string                   `json:"text"`
+	CreatedAt       time.Time                `json:"created_at"`
+	EditedAt        *time.Time               `json:"edited_at"`
+	DeletedAt       *time.Time               `json:"deleted_at,omitempty"`
+	ReplyCount      int                      `json:"reply_count,omitempty"`
+	LastReplyAt     *time.Time               `json:"last_reply_at,omitempty"`
+	Reactions       []*ReactionCountResponse `json:"reactions,omitempty"`
+	Attachments     []*AttachmentResponse    `json:"attachments,omitempty"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type MessageRevisionResponse struct {
+	ID        int       `json:"id"`
+	MessageID int       `json:"message_id"`
+	Text      string    `json:"text"`
+	EditedAt  time.Time `json:"edited_at"`
+}
@@ -0,0 +1,10 @@
+package dto
+
+import "time"
+
+type PinResponse struct {
+	MessageID int              `json:"message_id"`
+	PinnedBy  *int             `json:"pinned_by,omitempty"`
+	PinnedAt  time.Time        `json:"pinned_at"`
+	Message   *MessageResponse `json:"message,omitempty"`
+}
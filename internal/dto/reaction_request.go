@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji"`
+}
+
+func (req *ReactionRequest) Validate() error {
+	req.Emoji = strings.TrimSpace(req.Emoji)
+
+	if req.Emoji == "" {
+		return errors.New("emoji не может быть пустым")
+	}
+	if len(req.Emoji) > 32 || utf8.RuneCountInString(req.Emoji) > 10 {
+		return errors.New("emoji слишком длинный")
+	}
+
+	// эмодзи - это символы вне ASCII, допускаются модификаторы и ZWJ-последовательности
+	hasSymbol := false
+	for _, r := range req.Emoji {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || unicode.IsLetter(r) {
+			return errors.New("emoji должен содержать только эмодзи")
+		}
+		if r >= 0x2000 {
+			hasSymbol = true
+		}
+	}
+	if !hasSymbol {
+		return errors.New("emoji должен содержать только эмодзи")
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package dto
+
+type ReactionCountResponse struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+type ReactionsResponse struct {
+	MessageID int                      `json:"message_id"`
+	Reactions []*ReactionCountResponse `json:"reactions"`
+}
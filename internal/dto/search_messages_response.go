@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+type MessageSearchHit struct {
+	ID        int       `json:"id"`
+	ChatID    int       `json:"chat_id"`
+	Text      string    `json:"text"`
+	Snippet   string    `json:"snippet"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SearchMessagesResponse struct {
+	Hits       []*MessageSearchHit `json:"hits"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+type ThreadResponse struct {
+	Root       *MessageResponse   `json:"root"`
+	Replies    []*MessageResponse `json:"replies"`
+	NextCursor *int               `json:"next_cursor,omitempty"`
+	PrevCursor *int               `json:"prev_cursor,omitempty"`
+}
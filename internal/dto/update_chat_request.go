@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"errors"
+	"strings"
+)
+
+type UpdateChatRequest struct {
+	Title string `json:"title"`
+}
+
+func (req *UpdateChatRequest) Validate() error {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return errors.New("title не может быть пустым")
+	}
+	if len(req.Title) > 200 {
+		return errors.New("title слишком длинный(200 максимум)")
+	}
+	return nil
+}
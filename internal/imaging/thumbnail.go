@@ -0,0 +1,86 @@
+// Package imaging декодирует картинки и строит их уменьшенные копии без cgo
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/draw"
+)
+
+// ErrUnsupportedFormat - формат, для которого превью не строятся
+var ErrUnsupportedFormat = errors.New("неподдерживаемый формат картинки")
+
+// ErrTooManyPixels - картинка слишком большая для декодирования в память
+var ErrTooManyPixels = errors.New("слишком большое разрешение картинки")
+
+const jpegQuality = 85
+
+// Decode читает картинку целиком. Размер проверяется по заголовку до декодирования,
+// чтобы маленький файл с огромным разрешением не занял всю память
+func Decode(r io.Reader, contentType string, maxPixels int) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case "image/png":
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case "image/gif":
+		//у анимированного gif берётся первый кадр
+		decodeConfig, decode = gif.DecodeConfig, gif.Decode
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	return decode(bytes.NewReader(data))
+}
+
+// Fit уменьшает картинку так, чтобы большая сторона стала maxSide, с сохранением пропорций.
+// Картинки, которые уже помещаются, не увеличиваются: ok = false
+func Fit(src image.Image, maxSide int) (dst image.Image, ok bool) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return nil, false
+	}
+
+	if width >= height {
+		height = max(1, height*maxSide/width)
+		width = maxSide
+	} else {
+		width = max(1, width*maxSide/height)
+		height = maxSide
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(rgba, rgba.Bounds(), src, bounds, draw.Src, nil)
+	return rgba, true
+}
+
+// Encode кодирует превью: jpeg остаётся jpeg, png и gif сохраняются в png, чтобы не потерять прозрачность.
+// Возвращает тип содержимого результата
+func Encode(w io.Writer, img image.Image, sourceType string) (string, error) {
+	if sourceType == "image/jpeg" {
+		return "image/jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return "image/png", png.Encode(w, img)
+}
@@ -0,0 +1,102 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDecode(t *testing.T) {
+	img := testImage(40, 20)
+
+	encoders := map[string]func(*bytes.Buffer) error{
+		"image/jpeg": func(buf *bytes.Buffer) error { return jpeg.Encode(buf, img, nil) },
+		"image/png":  func(buf *bytes.Buffer) error { return png.Encode(buf, img) },
+		"image/gif":  func(buf *bytes.Buffer) error { return gif.Encode(buf, img, nil) },
+	}
+
+	for contentType, encode := range encoders {
+		t.Run(contentType, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, encode(&buf))
+
+			decoded, err := Decode(&buf, contentType, 1000)
+
+			require.NoError(t, err)
+			assert.Equal(t, image.Rect(0, 0, 40, 20), decoded.Bounds())
+		})
+	}
+
+	t.Run("разрешение проверяется до декодирования", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, img))
+
+		_, err := Decode(&buf, "image/png", 799)
+
+		assert.ErrorIs(t, err, ErrTooManyPixels)
+	})
+
+	t.Run("неподдерживаемый формат", func(t *testing.T) {
+		_, err := Decode(bytes.NewReader([]byte("RIFF")), "image/webp", 1000)
+
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+}
+
+func TestFit(t *testing.T) {
+	t.Run("широкая картинка", func(t *testing.T) {
+		thumb, ok := Fit(testImage(400, 100), 100)
+
+		assert.True(t, ok)
+		assert.Equal(t, image.Rect(0, 0, 100, 25), thumb.Bounds())
+	})
+
+	t.Run("высокая картинка", func(t *testing.T) {
+		thumb, ok := Fit(testImage(30, 300), 100)
+
+		assert.True(t, ok)
+		assert.Equal(t, image.Rect(0, 0, 10, 100), thumb.Bounds())
+	})
+
+	t.Run("узкая сторона не схлопывается в ноль", func(t *testing.T) {
+		thumb, ok := Fit(testImage(1000, 1), 100)
+
+		assert.True(t, ok)
+		assert.Equal(t, image.Rect(0, 0, 100, 1), thumb.Bounds())
+	})
+
+	t.Run("маленькая картинка не увеличивается", func(t *testing.T) {
+		thumb, ok := Fit(testImage(50, 80), 100)
+
+		assert.False(t, ok)
+		assert.Nil(t, thumb)
+	})
+}
+
+func TestEncode(t *testing.T) {
+	var buf bytes.Buffer
+
+	contentType, err := Encode(&buf, testImage(10, 10), "image/gif")
+
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	_, err = png.Decode(&buf)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,172 @@
+package grpcHandlers
+
+import (
+	"context"
+	"errors"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/repo"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// defaultLimit - размер страницы, если клиент его не указал, как и в HTTP API
+	defaultLimit = 20
+	// maxLimit - самая большая страница, больший limit урезается
+	maxLimit = 100
+)
+
+type baseAPIGRPC struct {
+	apiLogger *zap.Logger
+}
+
+// logger возвращает логгер хэндлера с полями вызова: request_id, user_id, trace_id
+func (b *baseAPIGRPC) logger(ctx context.Context) *zap.Logger {
+	return logging.Logger(ctx, b.apiLogger)
+}
+
+// invalidArgument - ошибка валидации запроса
+func invalidArgument(message string) error {
+	return status.Error(codes.InvalidArgument, message)
+}
+
+// parseID проверяет ID из запроса и приводит его к int, как ID в домене
+func parseID(name string, value int64) (int, error) {
+	if value <= 0 {
+		return 0, invalidArgument(name + " должен быть положительным числом")
+	}
+	return int(value), nil
+}
+
+// parseVersion - версия чата, которую видел клиент. 0 - без проверки
+func parseVersion(value int64) (int, error) {
+	if value < 0 {
+		return 0, invalidArgument("expected_version не может быть отрицательным")
+	}
+	return int(value), nil
+}
+
+// parseLimit возвращает limit не больше maxLimit или дефолтное значение
+func parseLimit(limit int32) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	return min(int(limit), maxLimit)
+}
+
+// parseCursor разбирает keyset-пагинацию before/after так же, как HTTP API
+func parseCursor(cursor interface {
+	GetBefore() int64
+	GetAfter() int64
+	GetLimit() int32
+}) (repo.CursorParam, error) {
+	param := repo.CursorParam{
+		Before: int(cursor.GetBefore()),
+		After:  int(cursor.GetAfter()),
+		Limit:  parseLimit(cursor.GetLimit()),
+	}
+	if param.Before < 0 || param.After < 0 {
+		return param, invalidArgument("before и after должны быть положительными числами")
+	}
+	if param.Before != 0 && param.After != 0 {
+		return param, invalidArgument("нельзя одновременно указывать before и after")
+	}
+	return param, nil
+}
+
+// handleDomainError маппит ошибки домена на коды gRPC. Тексты те же, что и в HTTP API
+func (b *baseAPIGRPC) handleDomainError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrChatNotFound):
+		return status.Error(codes.NotFound, "чат не найден")
+	case errors.Is(err, domain.ErrChatArchived):
+		return status.Error(codes.FailedPrecondition, "чат в архиве")
+	case errors.Is(err, domain.ErrChatNotArchived):
+		return status.Error(codes.FailedPrecondition, "чат не в архиве")
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return status.Error(codes.Aborted, "чат изменён другим запросом, загрузите его заново")
+	case errors.Is(err, domain.ErrMessageNotFound):
+		return status.Error(codes.NotFound, "сообщение не найдено")
+	case errors.Is(err, domain.ErrMessageDeleted):
+		return status.Error(codes.FailedPrecondition, "сообщение удалено")
+	case errors.Is(err, domain.ErrMessageNotDeleted):
+		return status.Error(codes.FailedPrecondition, "сообщение не удалено")
+	case errors.Is(err, domain.ErrAttachmentNotFound):
+		return status.Error(codes.NotFound, "вложение не найдено")
+	case errors.Is(err, domain.ErrAttachmentTooLarge):
+		return status.Error(codes.InvalidArgument, "вложение превышает допустимый размер")
+	case errors.Is(err, domain.ErrAttachmentType):
+		return status.Error(codes.InvalidArgument, "недопустимый тип вложения")
+	case errors.Is(err, domain.ErrTooManyAttachments):
+		return status.Error(codes.InvalidArgument, "слишком много вложений")
+	case errors.Is(err, domain.ErrEmptyMessage):
+		return status.Error(codes.InvalidArgument, "сообщение должно содержать текст или вложения")
+	case errors.Is(err, domain.ErrAlreadyPinned):
+		return status.Error(codes.AlreadyExists, "сообщение уже закреплено")
+	case errors.Is(err, domain.ErrNotPinned):
+		return status.Error(codes.NotFound, "сообщение не закреплено")
+	case errors.Is(err, domain.ErrPinLimitReached):
+		return status.Error(codes.FailedPrecondition, "достигнут лимит закреплённых сообщений")
+	case errors.Is(err, domain.ErrResyncRequired):
+		return status.Error(codes.FailedPrecondition, "изменения уже очищены, загрузите историю чата заново")
+	case errors.Is(err, domain.ErrRestoreExpired):
+		return status.Error(codes.FailedPrecondition, "время на восстановление сообщения истекло")
+	case errors.Is(err, domain.ErrParentNotInChat):
+		return status.Error(codes.InvalidArgument, "родительское сообщение не найдено в этом чате")
+	case errors.Is(err, domain.ErrUserAlreadyExists):
+		return status.Error(codes.AlreadyExists, "пользователь с таким именем уже существует")
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, "неверный логин или пароль")
+	case errors.Is(err, domain.ErrInvalidToken):
+		return status.Error(codes.Unauthenticated, "некорректный или просроченный токен")
+	case errors.Is(err, domain.ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, "требуется аутентификация")
+	case errors.Is(err, domain.ErrNotChatMember):
+		return status.Error(codes.PermissionDenied, "вы не состоите в этом чате")
+	case errors.Is(err, domain.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "недостаточно прав")
+	case errors.Is(err, domain.ErrMemberNotFound):
+		return status.Error(codes.NotFound, "участник не найден")
+	case errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, "пользователь не найден")
+	case errors.Is(err, domain.ErrMemberExists):
+		return status.Error(codes.AlreadyExists, "пользователь уже состоит в чате")
+	case errors.Is(err, domain.ErrInvalidRole):
+		return status.Error(codes.InvalidArgument, "некорректная роль")
+	case errors.Is(err, domain.ErrOwnerCannotLeave):
+		return status.Error(codes.FailedPrecondition, "владелец не может покинуть чат, сначала передайте владение")
+	case errors.Is(err, domain.ErrChatAlreadyExists):
+		return status.Error(codes.AlreadyExists, "чат с таким названием уже существует")
+	case errors.Is(err, domain.ErrFieldIsNotAllowed):
+		return status.Error(codes.InvalidArgument, "поле не разрешено для фильтрации или сортировки")
+	case errors.Is(err, domain.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, "некорректный курсор")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		b.logger(ctx).Error("внутренняя ошибка сервера", zap.Error(err))
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
+
+// timestamp возвращает nil для незаполненного времени, чтобы поле не пришло клиенту
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// optionalID переводит необязательный ID домена в optional-поле protobuf
+func optionalID(id *int) *int64 {
+	if id == nil {
+		return nil
+	}
+	value := int64(*id)
+	return &value
+}
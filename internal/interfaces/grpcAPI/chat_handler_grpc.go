@@ -0,0 +1,296 @@
+package grpcHandlers
+
+import (
+	"context"
+	"testtask5/internal/domain"
+	"testtask5/internal/dto"
+	"testtask5/internal/interfaces/grpcAPI/messengerpb"
+	"testtask5/internal/repo"
+	"testtask5/internal/services"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// streamReplayBatch - по сколько событий дочитывается журнал при переподключении, как в SSE
+const streamReplayBatch = 500
+
+type ChatAPIGRPC struct {
+	messengerpb.UnimplementedChatServiceServer
+	baseAPIGRPC
+	chatService *services.ChatService
+}
+
+func NewChatAPIGRPC(cService *services.ChatService, appLogger *zap.Logger) *ChatAPIGRPC {
+	return &ChatAPIGRPC{
+		baseAPIGRPC: baseAPIGRPC{apiLogger: appLogger.Named("chat_api_grpc")},
+		chatService: cService,
+	}
+}
+
+// Создать чат
+func (ch *ChatAPIGRPC) CreateChat(ctx context.Context, req *messengerpb.CreateChatRequest) (*messengerpb.Chat, error) {
+	body := dto.CreateChatRequest{Title: req.GetTitle()}
+	if err := body.Validate(); err != nil {
+		return nil, invalidArgument("ошибка валидации title: " + err.Error())
+	}
+
+	result, err := ch.chatService.CreateChat(ctx, &domain.ChatDomain{Title: body.Title})
+	if err != nil {
+		return nil, ch.handleDomainError(ctx, err)
+	}
+	return toChatProto(result), nil
+}
+
+// Получить чат и страницу его сообщений
+func (ch *ChatAPIGRPC) GetChat(ctx context.Context, req *messengerpb.GetChatRequest) (*messengerpb.GetChatResponse, error) {
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := parseCursor(req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ch.chatService.GetChatById(ctx, chatID, cursor)
+	if err != nil {
+		return nil, ch.handleDomainError(ctx, err)
+	}
+
+	return &messengerpb.GetChatResponse{
+		Chat:       toChatProto(result),
+		Messages:   toMessageProtos(result.Messages),
+		NextCursor: optionalID(result.NextCursor),
+		PrevCursor: optionalID(result.PrevCursor),
+	}, nil
+}
+
+// Список чатов пользователя с сортировкой и keyset-пагинацией
+func (ch *ChatAPIGRPC) ListChats(ctx context.Context, req *messengerpb.ListChatsRequest) (*messengerpb.ListChatsResponse, error) {
+	params := repo.ListParam{
+		Archived:  req.GetArchived(),
+		SortField: req.GetSort(),
+		SortDesc:  req.GetDesc(),
+		Cursor:    req.GetCursor(),
+		Limit:     parseLimit(req.GetLimit()),
+	}
+	if prefix := req.GetTitlePrefix(); prefix != "" {
+		params.Filters = append(params.Filters, repo.FilterParam{Field: "title", Value: prefix, Operator: repo.FilterPrefix})
+	}
+
+	result, err := ch.chatService.ListChats(ctx, params)
+	if err != nil {
+		return nil, ch.handleDomainError(ctx, err)
+	}
+
+	resp := &messengerpb.ListChatsResponse{
+		Chats:      make([]*messengerpb.Chat, 0, len(result.Chats)),
+		Total:      result.Total,
+		NextCursor: result.NextCursor,
+	}
+	for _, chat := range result.Chats {
+		resp.Chats = append(resp.Chats, toChatProto(chat))
+	}
+	return resp, nil
+}
+
+// Переименование чата, с expected_version - только если чат не менялся с тех пор, как клиент его прочитал
+func (ch *ChatAPIGRPC) RenameChat(ctx context.Context, req *messengerpb.RenameChatRequest) (*messengerpb.Chat, error) {
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := parseVersion(req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	body := dto.UpdateChatRequest{Title: req.GetTitle()}
+	if err := body.Validate(); err != nil {
+		return nil, invalidArgument("ошибка валидации title: " + err.Error())
+	}
+
+	chat, err := ch.chatService.RenameChat(ctx, chatID, body.Title, version)
+	if err != nil {
+		return nil, ch.handleDomainError(ctx, err)
+	}
+	return toChatProto(chat), nil
+}
+
+// Удаление чата: по умолчанию чат переносится в архив, purge удаляет его безвозвратно
+func (ch *ChatAPIGRPC) DeleteChat(ctx context.Context, req *messengerpb.DeleteChatRequest) (*messengerpb.DeleteChatResponse, error) {
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := parseVersion(req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetPurge() {
+		if err := ch.chatService.DeleteChatByID(ctx, chatID, version); err != nil {
+			return nil, ch.handleDomainError(ctx, err)
+		}
+		return &messengerpb.DeleteChatResponse{}, nil
+	}
+
+	chat, err := ch.chatService.ArchiveChat(ctx, chatID, version)
+	if err != nil {
+		return nil, ch.handleDomainError(ctx, err)
+	}
+	return &messengerpb.DeleteChatResponse{Chat: toChatProto(chat)}, nil
+}
+
+// Восстановление чата из архива
+func (ch *ChatAPIGRPC) RestoreChat(ctx context.Context, req *messengerpb.RestoreChatRequest) (*messengerpb.Chat, error) {
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := parseVersion(req.GetExpectedVersion())
+	if err != nil {
+		return nil, err
+	}
+
+	chat, err := ch.chatService.RestoreChat(ctx, chatID, version)
+	if err != nil {
+		return nil, ch.handleDomainError(ctx, err)
+	}
+	return toChatProto(chat), nil
+}
+
+// Отправка сообщения, вложения через gRPC не принимаются
+func (ch *ChatAPIGRPC) SendMessage(ctx context.Context, req *messengerpb.SendMessageRequest) (*messengerpb.Message, error) {
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+
+	body := dto.CreateMessageRequest{Text: req.GetText()}
+	if req.ParentMessageId != nil {
+		parentID, err := parseID("parent_message_id", req.GetParentMessageId())
+		if err != nil {
+			return nil, err
+		}
+		body.ParentMessageID = &parentID
+	}
+	if err := body.Validate(); err != nil {
+		return nil, invalidArgument("ошибка валидации text: " + err.Error())
+	}
+
+	result, err := ch.chatService.SendMessage(ctx, &domain.MessageDomain{
+		ChatID:          chatID,
+		Text:            body.Text,
+		ParentMessageID: body.ParentMessageID,
+	})
+	if err != nil {
+		return nil, ch.handleDomainError(ctx, err)
+	}
+	return toMessageProto(result), nil
+}
+
+// Поток сообщений чата. После переподключения события, пропущенные
+// после after_event_id, дочитываются из журнала, как в SSE
+func (ch *ChatAPIGRPC) StreamMessages(req *messengerpb.StreamMessagesRequest, stream messengerpb.ChatService_StreamMessagesServer) error {
+	ctx := stream.Context()
+
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return err
+	}
+	lastID := req.GetAfterEventId()
+	if lastID < 0 {
+		return invalidArgument("after_event_id не может быть отрицательным")
+	}
+
+	//подписываемся до чтения журнала, чтобы не потерять события между повтором и живым потоком
+	sub, err := ch.chatService.SubscribeChat(ctx, chatID)
+	if err != nil {
+		return ch.handleDomainError(ctx, err)
+	}
+	defer ch.chatService.Unsubscribe(sub)
+
+	if lastID > 0 {
+		for {
+			events, err := ch.chatService.ReplayEvents(ctx, chatID, lastID, streamReplayBatch)
+			if err != nil {
+				return ch.handleDomainError(ctx, err)
+			}
+			for _, event := range events {
+				if err := sendMessageEvent(stream, event); err != nil {
+					return err
+				}
+				lastID = event.ID
+				if event.Type.EndsStream() {
+					return nil
+				}
+			}
+			if len(events) < streamReplayBatch {
+				break
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case event, ok := <-sub.Events:
+			if !ok {
+				//сервер останавливается или клиент не успевал читать - он переподключится с after_event_id
+				return status.Error(codes.Unavailable, "поток событий прерван, переподключитесь с after_event_id")
+			}
+			//события, уже отданные из журнала
+			if event.ID != 0 && event.ID <= lastID {
+				continue
+			}
+			if err := sendMessageEvent(stream, event); err != nil {
+				return err
+			}
+			if event.ID > lastID {
+				lastID = event.ID
+			}
+			if event.Type.EndsStream() {
+				return nil
+			}
+		}
+	}
+}
+
+// sendMessageEvent отправляет в поток события сообщений, остальные события чата пропускаются
+func sendMessageEvent(stream messengerpb.ChatService_StreamMessagesServer, event *domain.ChatEvent) error {
+	eventType, ok := messageEventTypes[event.Type]
+	if !ok || event.Message == nil {
+		return nil
+	}
+	return stream.Send(&messengerpb.MessageEvent{
+		EventId: event.ID,
+		Type:    eventType,
+		Message: toMessageProto(event.Message),
+	})
+}
+
+var messageEventTypes = map[domain.ChatEventType]messengerpb.MessageEvent_Type{
+	domain.EventMessageCreated:  messengerpb.MessageEvent_TYPE_CREATED,
+	domain.EventMessageEdited:   messengerpb.MessageEvent_TYPE_EDITED,
+	domain.EventMessageDeleted:  messengerpb.MessageEvent_TYPE_DELETED,
+	domain.EventMessageRestored: messengerpb.MessageEvent_TYPE_RESTORED,
+}
+
+func toChatProto(chat *domain.ChatDomain) *messengerpb.Chat {
+	return &messengerpb.Chat{
+		Id:         int64(chat.ID),
+		Title:      chat.Title,
+		Version:    int64(chat.Version),
+		CreatedAt:  timestamppb.New(chat.CreatedAt),
+		ArchivedAt: timestamp(chat.ArchivedAt),
+	}
+}
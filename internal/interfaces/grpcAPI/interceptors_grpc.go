@@ -0,0 +1,263 @@
+package grpcHandlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/interfaces/grpcAPI/messengerpb"
+	"testtask5/internal/logging"
+	"testtask5/internal/middleware"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// requestIDKey - метаданные с ID запроса, аналог заголовка X-Request-ID
+	requestIDKey = "x-request-id"
+	// idempotencyKey - метаданные с ключом идемпотентности, аналог заголовка Idempotency-Key
+	idempotencyKey = "idempotency-key"
+	// grpcContentType - тип сохранённого ответа, по нему ответы gRPC отличаются от HTTP
+	grpcContentType = "application/grpc+proto"
+	// maxIdempotencyKeyLength - ограничение колонки idempotency_keys.key
+	maxIdempotencyKeyLength = 255
+)
+
+// CallLimits - лимиты вызовов, общие с HTTP API
+type CallLimits struct {
+	API      domain.RateLimit // все вызовы
+	Messages domain.RateLimit // отправка сообщений, поверх API
+}
+
+// idempotentMethods - вызовы, которые, как POST /chats и отправка сообщения в HTTP API, выполняются
+// один раз на ключ idempotency-key. Значение создаёт пустой ответ, в который читается сохранённый
+var idempotentMethods = map[string]func() proto.Message{
+	messengerpb.ChatService_CreateChat_FullMethodName:  func() proto.Message { return &messengerpb.Chat{} },
+	messengerpb.ChatService_SendMessage_FullMethodName: func() proto.Message { return &messengerpb.Message{} },
+}
+
+// ServerInterceptors делают для gRPC то же, что middleware HTTP API: ID запроса, лог вызова,
+// пользователь по Bearer-токену из метаданных authorization, лимиты запросов, идемпотентность
+// и таймаут обычных вызовов
+type ServerInterceptors struct {
+	tokens      middleware.TokenParser
+	limiter     *middleware.RateLimiter
+	limits      CallLimits
+	idempotency middleware.IdempotencyStore
+	timeout     time.Duration
+	logger      *zap.Logger
+}
+
+// timeout - дедлайн обычного вызова, если клиент не поставил более короткий. Потоки живут без него
+func NewServerInterceptors(tokens middleware.TokenParser, limiter *middleware.RateLimiter, limits CallLimits,
+	idempotency middleware.IdempotencyStore, timeout time.Duration, appLogger *zap.Logger) *ServerInterceptors {
+	return &ServerInterceptors{
+		tokens:      tokens,
+		limiter:     limiter,
+		limits:      limits,
+		idempotency: idempotency,
+		timeout:     timeout,
+		logger:      appLogger.Named("grpc"),
+	}
+}
+
+// Unary - перехватчик для обычных вызовов
+func (si *ServerInterceptors) Unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, finish := si.startCall(ctx, info.FullMethod)
+
+	ctx, err := si.authenticate(ctx)
+	if err == nil {
+		err = si.rateLimit(ctx, info.FullMethod)
+	}
+	if err != nil {
+		finish(err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, si.timeout)
+	defer cancel()
+
+	resp, err := si.idempotent(ctx, req, info.FullMethod, handler)
+	finish(err)
+	return resp, err
+}
+
+// Stream - перехватчик для потоков, поток логируется целиком: от подписки до закрытия
+func (si *ServerInterceptors) Stream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, finish := si.startCall(stream.Context(), info.FullMethod)
+
+	ctx, err := si.authenticate(ctx)
+	if err == nil {
+		err = si.rateLimit(ctx, info.FullMethod)
+	}
+	if err != nil {
+		finish(err)
+		return err
+	}
+
+	err = handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	finish(err)
+	return err
+}
+
+// startCall берёт ID запроса из метаданных x-request-id или генерирует новый и возвращает его клиенту
+// в заголовке ответа. finish логирует итоговый код и длительность вызова
+func (si *ServerInterceptors) startCall(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := logging.RequestIDOrNew(firstValue(md, requestIDKey))
+	ctx = logging.WithRequestID(ctx, requestID)
+	//ошибка возможна, только если заголовки уже отправлены
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+	callLogger := logging.Logger(ctx, si.logger).With(zap.String("method", method))
+	callLogger.Info("request started")
+
+	return ctx, func(err error) {
+		callLogger.Info("request finished", zap.String("code", status.Code(err).String()), zap.Duration("duration", time.Since(start)))
+	}
+}
+
+// authenticate проверяет Bearer access-токен из метаданных authorization и кладёт пользователя в контекст
+func (si *ServerInterceptors) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token, found := strings.CutPrefix(firstValue(md, "authorization"), "Bearer ")
+	if !found || strings.TrimSpace(token) == "" {
+		return ctx, status.Error(codes.Unauthenticated, "требуется аутентификация")
+	}
+
+	user, err := si.tokens.ParseAccessToken(strings.TrimSpace(token))
+	if err != nil {
+		return ctx, status.Error(codes.Unauthenticated, "некорректный или просроченный токен")
+	}
+
+	ctx = auth.WithUser(ctx, user)
+	ctx = logging.WithFields(ctx, zap.Int("user_id", user.ID))
+	return ctx, nil
+}
+
+// rateLimit расходует общий лимит пользователя, а на отправку сообщений - ещё и отдельный
+func (si *ServerInterceptors) rateLimit(ctx context.Context, method string) error {
+	if err := si.take(ctx, "api", si.limits.API); err != nil {
+		return err
+	}
+	if method == messengerpb.ChatService_SendMessage_FullMethodName {
+		return si.take(ctx, "messages", si.limits.Messages)
+	}
+	return nil
+}
+
+// take забирает токен из ведра пользователя. Как и в HTTP, без хранилища лимитов вызов пропускается
+func (si *ServerInterceptors) take(ctx context.Context, group string, limit domain.RateLimit) error {
+	user, _ := auth.UserFromContext(ctx)
+	result, err := si.limiter.AllowUser(ctx, group, limit, user.ID)
+	if err != nil {
+		logging.Logger(ctx, si.logger).Error("не удалось проверить лимит запросов", zap.String("group", group), zap.Error(err))
+		return nil
+	}
+	if !result.Allowed {
+		retryAfter := strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+		return status.Error(codes.ResourceExhausted, "слишком много запросов")
+	}
+	return nil
+}
+
+// idempotent выполняет вызов с метаданными idempotency-key один раз, а на повторы отдаёт сохранённый ответ.
+// Вызов сравнивается по методу и хэшу запроса. Ошибки не сохраняются: такой вызов ничего не создал,
+// и повтор выполнится заново
+func (si *ServerInterceptors) idempotent(ctx context.Context, req any, method string, handler grpc.UnaryHandler) (any, error) {
+	newResponse, ok := idempotentMethods[method]
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := firstValue(md, idempotencyKey)
+	if !ok || key == "" {
+		return handler(ctx, req)
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, invalidArgument("слишком длинный idempotency-key")
+	}
+
+	request, err := proto.MarshalOptions{Deterministic: true}.Marshal(req.(proto.Message))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	requestHash := sha256.Sum256(request)
+
+	record, err := si.idempotency.Begin(ctx, key, method, hex.EncodeToString(requestHash[:]))
+	switch {
+	case errors.Is(err, domain.ErrIdempotencyReused):
+		return nil, status.Error(codes.FailedPrecondition, "idempotency-key уже использован с другим запросом")
+	case errors.Is(err, domain.ErrIdempotencyBusy):
+		return nil, status.Error(codes.Aborted, "запрос с этим idempotency-key ещё выполняется")
+	case errors.Is(err, domain.ErrUnauthorized):
+		return nil, status.Error(codes.Unauthenticated, "требуется аутентификация")
+	case err != nil:
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	if record != nil {
+		resp := newResponse()
+		if err := proto.Unmarshal(record.Body, resp); err != nil {
+			logging.Logger(ctx, si.logger).Error("не удалось прочитать сохранённый ответ", zap.Error(err))
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs("idempotent-replayed", "true"))
+		return resp, nil
+	}
+
+	resp, err := handler(ctx, req)
+
+	//вызов мог упереться в дедлайн, а ответ сохранить нужно
+	storeCtx := context.WithoutCancel(ctx)
+	var body []byte
+	if err == nil {
+		body, err = proto.Marshal(resp.(proto.Message))
+	}
+	if err != nil {
+		si.releaseKey(storeCtx, key)
+		return resp, err
+	}
+	if err := si.idempotency.Complete(storeCtx, key, http.StatusOK, grpcContentType, body); err != nil {
+		//ответ не сохранился, ключ освобождается, чтобы повтор не получал ABORTED до истечения срока
+		logging.Logger(storeCtx, si.logger).Error("не удалось сохранить ответ по idempotency-key, ключ освобождается", zap.Error(err))
+		si.releaseKey(storeCtx, key)
+	}
+	return resp, nil
+}
+
+// releaseKey освобождает ключ. Если и это не удалось, ключ занят до истечения срока хранения
+func (si *ServerInterceptors) releaseKey(ctx context.Context, key string) {
+	if err := si.idempotency.Release(ctx, key); err != nil {
+		logging.Logger(ctx, si.logger).Error("не удалось освободить idempotency-key", zap.Error(err))
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// contextStream подменяет контекст потока, чтобы обработчик видел пользователя и ID запроса
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (cs *contextStream) Context() context.Context {
+	return cs.ctx
+}
@@ -0,0 +1,216 @@
+package grpcHandlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/interfaces/grpcAPI/messengerpb"
+	"testtask5/internal/middleware"
+	"testtask5/internal/repo/ratelimit"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeTokens struct{}
+
+func (fakeTokens) ParseAccessToken(token string) (*domain.UserDomain, error) {
+	if token != "valid" {
+		return nil, domain.ErrInvalidToken
+	}
+	return &domain.UserDomain{ID: 7}, nil
+}
+
+// fakeIdempotency хранит ключи в памяти, как IdempotencyService с одним пользователем
+type fakeIdempotency struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+}
+
+func (f *fakeIdempotency) Begin(ctx context.Context, key string, scope string, requestHash string) (*domain.IdempotencyRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.records[key]
+	if !ok {
+		f.records[key] = &domain.IdempotencyRecord{Key: key, Scope: scope, RequestHash: requestHash}
+		return nil, nil
+	}
+	if existing.Scope != scope || existing.RequestHash != requestHash {
+		return nil, domain.ErrIdempotencyReused
+	}
+	if !existing.Completed() {
+		return nil, domain.ErrIdempotencyBusy
+	}
+	return existing, nil
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record := f.records[key]
+	record.StatusCode, record.ContentType, record.Body = statusCode, contentType, body
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.records, key)
+	return nil
+}
+
+func newTestInterceptors(limits CallLimits) *ServerInterceptors {
+	limiter := middleware.NewRateLimiter(ratelimit.NewMemoryRateLimitStore(), false, zap.NewNop())
+	idempotency := &fakeIdempotency{records: map[string]*domain.IdempotencyRecord{}}
+	return NewServerInterceptors(fakeTokens{}, limiter, limits, idempotency, 2*time.Second, zap.NewNop())
+}
+
+func authorizedContext(pairs ...string) context.Context {
+	md := metadata.Pairs(append([]string{"authorization", "Bearer valid"}, pairs...)...)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestServerInterceptors_Unary(t *testing.T) {
+	interceptors := newTestInterceptors(CallLimits{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/messenger.v1.ChatService/GetChat"}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      codes.Code
+		wantMessage   string
+	}{
+		{"без токена", "", codes.Unauthenticated, "требуется аутентификация"},
+		{"не Bearer", "Basic dXNlcg==", codes.Unauthenticated, "требуется аутентификация"},
+		{"просроченный токен", "Bearer expired", codes.Unauthenticated, "некорректный или просроченный токен"},
+		{"валидный токен", "Bearer valid", codes.OK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tt.authorization != "" {
+				md.Set("authorization", tt.authorization)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			var userID int
+			_, err := interceptors.Unary(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+				user, ok := auth.UserFromContext(ctx)
+				require.True(t, ok)
+				userID = user.ID
+				return nil, nil
+			})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantMessage, status.Convert(err).Message())
+				return
+			}
+			assert.Equal(t, 7, userID)
+		})
+	}
+}
+
+func TestServerInterceptors_Deadline(t *testing.T) {
+	interceptors := newTestInterceptors(CallLimits{})
+	info := &grpc.UnaryServerInfo{FullMethod: messengerpb.ChatService_GetChat_FullMethodName}
+
+	_, err := interceptors.Unary(authorizedContext(), nil, info, func(ctx context.Context, req any) (any, error) {
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(2*time.Second), deadline, time.Second)
+		return nil, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestServerInterceptors_RateLimit(t *testing.T) {
+	interceptors := newTestInterceptors(CallLimits{
+		API:      domain.RateLimit{Burst: 3, Period: time.Minute},
+		Messages: domain.RateLimit{Burst: 1, Period: time.Minute},
+	})
+	ok := func(ctx context.Context, req any) (any, error) { return nil, nil }
+	call := func(method string) codes.Code {
+		_, err := interceptors.Unary(authorizedContext(), nil, &grpc.UnaryServerInfo{FullMethod: method}, ok)
+		return status.Code(err)
+	}
+
+	assert.Equal(t, codes.OK, call(messengerpb.ChatService_SendMessage_FullMethodName))
+	//лимит на сообщения исчерпан, а общий ещё нет
+	assert.Equal(t, codes.ResourceExhausted, call(messengerpb.ChatService_SendMessage_FullMethodName))
+	assert.Equal(t, codes.OK, call(messengerpb.ChatService_GetChat_FullMethodName))
+	assert.Equal(t, codes.ResourceExhausted, call(messengerpb.ChatService_GetChat_FullMethodName))
+}
+
+func TestServerInterceptors_Idempotency(t *testing.T) {
+	interceptors := newTestInterceptors(CallLimits{})
+	info := &grpc.UnaryServerInfo{FullMethod: messengerpb.ChatService_CreateChat_FullMethodName}
+
+	calls := 0
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		return &messengerpb.Chat{Id: int64(calls), Title: req.(*messengerpb.CreateChatRequest).GetTitle()}, nil
+	}
+	call := func(key string, title string) (*messengerpb.Chat, error) {
+		resp, err := interceptors.Unary(authorizedContext("idempotency-key", key), &messengerpb.CreateChatRequest{Title: title}, info, handler)
+		if err != nil {
+			return nil, err
+		}
+		return resp.(*messengerpb.Chat), nil
+	}
+
+	first, err := call("k1", "чат")
+	require.NoError(t, err)
+
+	t.Run("повтор отдаёт сохранённый ответ", func(t *testing.T) {
+		replayed, err := call("k1", "чат")
+		require.NoError(t, err)
+		assert.Equal(t, first.GetId(), replayed.GetId())
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("тот же ключ с другим запросом", func(t *testing.T) {
+		_, err := call("k1", "другой чат")
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("новый ключ выполняет вызов", func(t *testing.T) {
+		created, err := call("k2", "чат")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), created.GetId())
+	})
+}
+
+func TestHandleDomainError(t *testing.T) {
+	base := &baseAPIGRPC{apiLogger: zap.NewNop()}
+
+	tests := []struct {
+		err  error
+		want codes.Code
+	}{
+		{domain.ErrChatNotFound, codes.NotFound},
+		{fmt.Errorf("обёртка: %w", domain.ErrMessageNotFound), codes.NotFound},
+		{domain.ErrChatArchived, codes.FailedPrecondition},
+		{domain.ErrVersionMismatch, codes.Aborted},
+		{domain.ErrParentNotInChat, codes.InvalidArgument},
+		{domain.ErrNotChatMember, codes.PermissionDenied},
+		{domain.ErrUnauthorized, codes.Unauthenticated},
+		{domain.ErrAlreadyPinned, codes.AlreadyExists},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{fmt.Errorf("соединение с базой потеряно"), codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.want, status.Code(base.handleDomainError(context.Background(), tt.err)))
+		})
+	}
+}
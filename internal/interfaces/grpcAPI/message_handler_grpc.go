@@ -0,0 +1,285 @@
+package grpcHandlers
+
+import (
+	"context"
+	"strings"
+	"testtask5/internal/domain"
+	"testtask5/internal/dto"
+	"testtask5/internal/interfaces/grpcAPI/messengerpb"
+	"testtask5/internal/repo"
+	"testtask5/internal/services"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	changesDefaultLimit = 100
+	changesMaxLimit     = 1000
+	searchMaxQuery      = 500
+)
+
+type MessageAPIGRPC struct {
+	messengerpb.UnimplementedMessageServiceServer
+	baseAPIGRPC
+	messageService *services.MessageService
+}
+
+func NewMessageAPIGRPC(mService *services.MessageService, appLogger *zap.Logger) *MessageAPIGRPC {
+	return &MessageAPIGRPC{
+		baseAPIGRPC:    baseAPIGRPC{apiLogger: appLogger.Named("message_api_grpc")},
+		messageService: mService,
+	}
+}
+
+// Редактирование сообщения
+func (mh *MessageAPIGRPC) EditMessage(ctx context.Context, req *messengerpb.EditMessageRequest) (*messengerpb.Message, error) {
+	chatID, messageID, err := parseMessageRef(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := dto.UpdateMessageRequest{Text: req.GetText()}
+	if err := body.Validate(); err != nil {
+		return nil, invalidArgument("ошибка валидации text: " + err.Error())
+	}
+
+	result, err := mh.messageService.EditMessage(ctx, &domain.MessageDomain{
+		ID:     messageID,
+		ChatID: chatID,
+		Text:   body.Text,
+	})
+	if err != nil {
+		return nil, mh.handleDomainError(ctx, err)
+	}
+	return toMessageProto(result), nil
+}
+
+// Мягкое удаление сообщения, в ответе tombstone
+func (mh *MessageAPIGRPC) DeleteMessage(ctx context.Context, req *messengerpb.MessageRef) (*messengerpb.Message, error) {
+	chatID, messageID, err := parseMessageRef(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := mh.messageService.DeleteMessage(ctx, chatID, messageID)
+	if err != nil {
+		return nil, mh.handleDomainError(ctx, err)
+	}
+	return toMessageProto(result), nil
+}
+
+// Восстановление удалённого сообщения в пределах окна восстановления
+func (mh *MessageAPIGRPC) RestoreMessage(ctx context.Context, req *messengerpb.MessageRef) (*messengerpb.Message, error) {
+	chatID, messageID, err := parseMessageRef(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := mh.messageService.RestoreMessage(ctx, chatID, messageID)
+	if err != nil {
+		return nil, mh.handleDomainError(ctx, err)
+	}
+	return toMessageProto(result), nil
+}
+
+// Тред: корневое сообщение и ответы на него с той же пагинацией, что и история чата
+func (mh *MessageAPIGRPC) GetThread(ctx context.Context, req *messengerpb.GetThreadRequest) (*messengerpb.GetThreadResponse, error) {
+	chatID, messageID, err := parseMessageRef(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := parseCursor(req.GetCursor())
+	if err != nil {
+		return nil, err
+	}
+
+	thread, err := mh.messageService.GetThread(ctx, chatID, messageID, cursor)
+	if err != nil {
+		return nil, mh.handleDomainError(ctx, err)
+	}
+
+	return &messengerpb.GetThreadResponse{
+		Root:       toMessageProto(thread.Root),
+		Replies:    toMessageProtos(thread.Replies),
+		NextCursor: optionalID(thread.NextCursor),
+		PrevCursor: optionalID(thread.PrevCursor),
+	}, nil
+}
+
+// Дельта-синхронизация: изменения сообщений чата после since_seq
+func (mh *MessageAPIGRPC) GetChanges(ctx context.Context, req *messengerpb.GetChangesRequest) (*messengerpb.GetChangesResponse, error) {
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return nil, err
+	}
+
+	sinceSeq := req.GetSinceSeq()
+	if sinceSeq < 0 {
+		return nil, invalidArgument("since_seq должен быть неотрицательным числом")
+	}
+
+	limit := changesDefaultLimit
+	if req.GetLimit() > 0 {
+		limit = min(int(req.GetLimit()), changesMaxLimit)
+	}
+
+	changes, err := mh.messageService.GetChanges(ctx, chatID, sinceSeq, limit)
+	if err != nil {
+		return nil, mh.handleDomainError(ctx, err)
+	}
+
+	resp := &messengerpb.GetChangesResponse{
+		Messages:     toMessageProtos(changes.Messages),
+		NextSinceSeq: sinceSeq,
+		ChatSeq:      changes.ChatSeq,
+		HasMore:      changes.HasMore,
+	}
+	if n := len(changes.Messages); n > 0 {
+		resp.NextSinceSeq = changes.Messages[n-1].Seq
+	}
+	return resp, nil
+}
+
+// Полнотекстовый поиск по сообщениям
+func (mh *MessageAPIGRPC) SearchMessages(ctx context.Context, req *messengerpb.SearchMessagesRequest) (*messengerpb.SearchMessagesResponse, error) {
+	params := repo.SearchParam{
+		Query:  strings.TrimSpace(req.GetQuery()),
+		Cursor: req.GetCursor(),
+		Limit:  parseLimit(req.GetLimit()),
+	}
+	if params.Query == "" {
+		return nil, invalidArgument("query не может быть пустым")
+	}
+	if len(params.Query) > searchMaxQuery {
+		return nil, invalidArgument("query слишком длинный (500 максимум)")
+	}
+	if req.GetChatId() != 0 {
+		chatID, err := parseID("chat_id", req.GetChatId())
+		if err != nil {
+			return nil, err
+		}
+		params.ChatID = chatID
+	}
+
+	result, err := mh.messageService.SearchMessages(ctx, params)
+	if err != nil {
+		return nil, mh.handleDomainError(ctx, err)
+	}
+
+	resp := &messengerpb.SearchMessagesResponse{
+		Hits:       make([]*messengerpb.SearchMessagesResponse_Hit, 0, len(result.Hits)),
+		NextCursor: result.NextCursor,
+	}
+	for _, hit := range result.Hits {
+		resp.Hits = append(resp.Hits, &messengerpb.SearchMessagesResponse_Hit{
+			Message: toMessageProto(hit.Message),
+			Rank:    hit.Rank,
+			Snippet: hit.Snippet,
+		})
+	}
+	return resp, nil
+}
+
+// Поставить реакцию на сообщение
+func (mh *MessageAPIGRPC) AddReaction(ctx context.Context, req *messengerpb.ReactionRequest) (*messengerpb.ReactionsResponse, error) {
+	return mh.handleReaction(ctx, req, mh.messageService.AddReaction)
+}
+
+// Снять реакцию с сообщения
+func (mh *MessageAPIGRPC) RemoveReaction(ctx context.Context, req *messengerpb.ReactionRequest) (*messengerpb.ReactionsResponse, error) {
+	return mh.handleReaction(ctx, req, mh.messageService.RemoveReaction)
+}
+
+// handleReaction - общий разбор запроса для постановки и снятия реакции
+func (mh *MessageAPIGRPC) handleReaction(
+	ctx context.Context,
+	req *messengerpb.ReactionRequest,
+	apply func(ctx context.Context, chatID int, reaction *domain.ReactionDomain) ([]domain.ReactionCount, error),
+) (*messengerpb.ReactionsResponse, error) {
+	chatID, messageID, err := parseMessageRef(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := dto.ReactionRequest{Emoji: req.GetEmoji()}
+	if err := body.Validate(); err != nil {
+		return nil, invalidArgument("ошибка валидации реакции: " + err.Error())
+	}
+
+	counts, err := apply(ctx, chatID, &domain.ReactionDomain{
+		MessageID: messageID,
+		Emoji:     body.Emoji,
+	})
+	if err != nil {
+		return nil, mh.handleDomainError(ctx, err)
+	}
+
+	return &messengerpb.ReactionsResponse{
+		MessageId: int64(messageID),
+		Reactions: toReactionCountProtos(counts),
+	}, nil
+}
+
+// parseMessageRef проверяет ID чата и ID сообщения из запроса
+func parseMessageRef(req interface {
+	GetChatId() int64
+	GetMessageId() int64
+}) (int, int, error) {
+	chatID, err := parseID("chat_id", req.GetChatId())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	messageID, err := parseID("message_id", req.GetMessageId())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return chatID, messageID, nil
+}
+
+func toMessageProto(message *domain.MessageDomain) *messengerpb.Message {
+	resp := &messengerpb.Message{
+		Id:              int64(message.ID),
+		ChatId:          int64(message.ChatID),
+		Seq:             message.Seq,
+		ParentMessageId: optionalID(message.ParentMessageID),
+		AuthorId:        optionalID(message.AuthorID),
+		Text:            message.Text,
+		CreatedAt:       timestamppb.New(message.CreatedAt),
+		EditedAt:        timestamp(message.EditedAt),
+		DeletedAt:       timestamp(message.DeletedAt),
+		ReplyCount:      int32(message.ReplyCount),
+		LastReplyAt:     timestamp(message.LastReplyAt),
+		Reactions:       toReactionCountProtos(message.Reactions),
+	}
+	for _, attachment := range message.Attachments {
+		resp.Attachments = append(resp.Attachments, &messengerpb.Attachment{
+			Id:          int64(attachment.ID),
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			Width:       int32(attachment.Width),
+			Height:      int32(attachment.Height),
+		})
+	}
+	return resp
+}
+
+func toMessageProtos(messages []*domain.MessageDomain) []*messengerpb.Message {
+	resp := make([]*messengerpb.Message, 0, len(messages))
+	for _, message := range messages {
+		resp = append(resp, toMessageProto(message))
+	}
+	return resp
+}
+
+func toReactionCountProtos(counts []domain.ReactionCount) []*messengerpb.ReactionCount {
+	resp := make([]*messengerpb.ReactionCount, 0, len(counts))
+	for _, c := range counts {
+		resp = append(resp, &messengerpb.ReactionCount{Emoji: c.Emoji, Count: int32(c.Count)})
+	}
+	return resp
+}
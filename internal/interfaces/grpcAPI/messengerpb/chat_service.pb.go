@@ -0,0 +1,951 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: messenger/v1/chat_service.proto
+
+package messengerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MessageEvent_Type int32
+
+const (
+	MessageEvent_TYPE_UNSPECIFIED MessageEvent_Type = 0
+	MessageEvent_TYPE_CREATED     MessageEvent_Type = 1
+	MessageEvent_TYPE_EDITED      MessageEvent_Type = 2
+	MessageEvent_TYPE_DELETED     MessageEvent_Type = 3
+	MessageEvent_TYPE_RESTORED    MessageEvent_Type = 4
+)
+
+// Enum value maps for MessageEvent_Type.
+var (
+	MessageEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_EDITED",
+		3: "TYPE_DELETED",
+		4: "TYPE_RESTORED",
+	}
+	MessageEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_EDITED":      2,
+		"TYPE_DELETED":     3,
+		"TYPE_RESTORED":    4,
+	}
+)
+
+func (x MessageEvent_Type) Enum() *MessageEvent_Type {
+	p := new(MessageEvent_Type)
+	*p = x
+	return p
+}
+
+func (x MessageEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MessageEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_messenger_v1_chat_service_proto_enumTypes[0].Descriptor()
+}
+
+func (MessageEvent_Type) Type() protoreflect.EnumType {
+	return &file_messenger_v1_chat_service_proto_enumTypes[0]
+}
+
+func (x MessageEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MessageEvent_Type.Descriptor instead.
+func (MessageEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type CreateChatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateChatRequest) Reset() {
+	*x = CreateChatRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateChatRequest) ProtoMessage() {}
+
+func (x *CreateChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateChatRequest.ProtoReflect.Descriptor instead.
+func (*CreateChatRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateChatRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+type GetChatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	Cursor        *PageCursor            `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChatRequest) Reset() {
+	*x = GetChatRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChatRequest) ProtoMessage() {}
+
+func (x *GetChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChatRequest.ProtoReflect.Descriptor instead.
+func (*GetChatRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetChatRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *GetChatRequest) GetCursor() *PageCursor {
+	if x != nil {
+		return x.Cursor
+	}
+	return nil
+}
+
+type GetChatResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Chat  *Chat                  `protobuf:"bytes,1,opt,name=chat,proto3" json:"chat,omitempty"`
+	// от новых к старым
+	Messages []*Message `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`
+	// id для запроса более старых сообщений (before)
+	NextCursor *int64 `protobuf:"varint,3,opt,name=next_cursor,json=nextCursor,proto3,oneof" json:"next_cursor,omitempty"`
+	// id для запроса более новых сообщений (after)
+	PrevCursor    *int64 `protobuf:"varint,4,opt,name=prev_cursor,json=prevCursor,proto3,oneof" json:"prev_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChatResponse) Reset() {
+	*x = GetChatResponse{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChatResponse) ProtoMessage() {}
+
+func (x *GetChatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChatResponse.ProtoReflect.Descriptor instead.
+func (*GetChatResponse) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetChatResponse) GetChat() *Chat {
+	if x != nil {
+		return x.Chat
+	}
+	return nil
+}
+
+func (x *GetChatResponse) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *GetChatResponse) GetNextCursor() int64 {
+	if x != nil && x.NextCursor != nil {
+		return *x.NextCursor
+	}
+	return 0
+}
+
+func (x *GetChatResponse) GetPrevCursor() int64 {
+	if x != nil && x.PrevCursor != nil {
+		return *x.PrevCursor
+	}
+	return 0
+}
+
+type ListChatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// по умолчанию 20
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	// next_cursor из предыдущей страницы
+	Cursor string `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// поле сортировки, как в HTTP API
+	Sort string `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	Desc bool   `protobuf:"varint,4,opt,name=desc,proto3" json:"desc,omitempty"`
+	// true - только архивные чаты
+	Archived      bool   `protobuf:"varint,5,opt,name=archived,proto3" json:"archived,omitempty"`
+	TitlePrefix   string `protobuf:"bytes,6,opt,name=title_prefix,json=titlePrefix,proto3" json:"title_prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChatsRequest) Reset() {
+	*x = ListChatsRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChatsRequest) ProtoMessage() {}
+
+func (x *ListChatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChatsRequest.ProtoReflect.Descriptor instead.
+func (*ListChatsRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListChatsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListChatsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListChatsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListChatsRequest) GetDesc() bool {
+	if x != nil {
+		return x.Desc
+	}
+	return false
+}
+
+func (x *ListChatsRequest) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *ListChatsRequest) GetTitlePrefix() string {
+	if x != nil {
+		return x.TitlePrefix
+	}
+	return ""
+}
+
+type ListChatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Chats         []*Chat                `protobuf:"bytes,1,rep,name=chats,proto3" json:"chats,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,3,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChatsResponse) Reset() {
+	*x = ListChatsResponse{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChatsResponse) ProtoMessage() {}
+
+func (x *ListChatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChatsResponse.ProtoReflect.Descriptor instead.
+func (*ListChatsResponse) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListChatsResponse) GetChats() []*Chat {
+	if x != nil {
+		return x.Chats
+	}
+	return nil
+}
+
+func (x *ListChatsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListChatsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type RenameChatRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ChatId int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	Title  string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// версия чата, которую видел клиент, 0 - без проверки
+	ExpectedVersion int64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RenameChatRequest) Reset() {
+	*x = RenameChatRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameChatRequest) ProtoMessage() {}
+
+func (x *RenameChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameChatRequest.ProtoReflect.Descriptor instead.
+func (*RenameChatRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RenameChatRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *RenameChatRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *RenameChatRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type DeleteChatRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ChatId          int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	ExpectedVersion int64                  `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	Purge           bool                   `protobuf:"varint,3,opt,name=purge,proto3" json:"purge,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteChatRequest) Reset() {
+	*x = DeleteChatRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteChatRequest) ProtoMessage() {}
+
+func (x *DeleteChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteChatRequest.ProtoReflect.Descriptor instead.
+func (*DeleteChatRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteChatRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *DeleteChatRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *DeleteChatRequest) GetPurge() bool {
+	if x != nil {
+		return x.Purge
+	}
+	return false
+}
+
+type DeleteChatResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// чат в архиве; у удалённого безвозвратно не заполнено
+	Chat          *Chat `protobuf:"bytes,1,opt,name=chat,proto3" json:"chat,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteChatResponse) Reset() {
+	*x = DeleteChatResponse{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteChatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteChatResponse) ProtoMessage() {}
+
+func (x *DeleteChatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteChatResponse.ProtoReflect.Descriptor instead.
+func (*DeleteChatResponse) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteChatResponse) GetChat() *Chat {
+	if x != nil {
+		return x.Chat
+	}
+	return nil
+}
+
+type RestoreChatRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	ChatId          int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	ExpectedVersion int64                  `protobuf:"varint,2,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RestoreChatRequest) Reset() {
+	*x = RestoreChatRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreChatRequest) ProtoMessage() {}
+
+func (x *RestoreChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreChatRequest.ProtoReflect.Descriptor instead.
+func (*RestoreChatRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RestoreChatRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *RestoreChatRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+type SendMessageRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ChatId int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	Text   string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// ответ в треде
+	ParentMessageId *int64 `protobuf:"varint,3,opt,name=parent_message_id,json=parentMessageId,proto3,oneof" json:"parent_message_id,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SendMessageRequest) Reset() {
+	*x = SendMessageRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendMessageRequest) ProtoMessage() {}
+
+func (x *SendMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendMessageRequest.ProtoReflect.Descriptor instead.
+func (*SendMessageRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SendMessageRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *SendMessageRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SendMessageRequest) GetParentMessageId() int64 {
+	if x != nil && x.ParentMessageId != nil {
+		return *x.ParentMessageId
+	}
+	return 0
+}
+
+type StreamMessagesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ChatId int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	// события после этого id дочитываются из журнала перед живым потоком
+	AfterEventId  int64 `protobuf:"varint,2,opt,name=after_event_id,json=afterEventId,proto3" json:"after_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamMessagesRequest) Reset() {
+	*x = StreamMessagesRequest{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamMessagesRequest) ProtoMessage() {}
+
+func (x *StreamMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamMessagesRequest.ProtoReflect.Descriptor instead.
+func (*StreamMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *StreamMessagesRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *StreamMessagesRequest) GetAfterEventId() int64 {
+	if x != nil {
+		return x.AfterEventId
+	}
+	return 0
+}
+
+type MessageEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id события в журнале чата, для after_event_id
+	EventId       int64             `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Type          MessageEvent_Type `protobuf:"varint,2,opt,name=type,proto3,enum=messenger.v1.MessageEvent_Type" json:"type,omitempty"`
+	Message       *Message          `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageEvent) Reset() {
+	*x = MessageEvent{}
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageEvent) ProtoMessage() {}
+
+func (x *MessageEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_chat_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageEvent.ProtoReflect.Descriptor instead.
+func (*MessageEvent) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_chat_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MessageEvent) GetEventId() int64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *MessageEvent) GetType() MessageEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return MessageEvent_TYPE_UNSPECIFIED
+}
+
+func (x *MessageEvent) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+var File_messenger_v1_chat_service_proto protoreflect.FileDescriptor
+
+const file_messenger_v1_chat_service_proto_rawDesc = "" +
+	"\n" +
+	"\x1fmessenger/v1/chat_service.proto\x12\fmessenger.v1\x1a\x18messenger/v1/types.proto\")\n" +
+	"\x11CreateChatRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\"[\n" +
+	"\x0eGetChatRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x120\n" +
+	"\x06cursor\x18\x02 \x01(\v2\x18.messenger.v1.PageCursorR\x06cursor\"\xd8\x01\n" +
+	"\x0fGetChatResponse\x12&\n" +
+	"\x04chat\x18\x01 \x01(\v2\x12.messenger.v1.ChatR\x04chat\x121\n" +
+	"\bmessages\x18\x02 \x03(\v2\x15.messenger.v1.MessageR\bmessages\x12$\n" +
+	"\vnext_cursor\x18\x03 \x01(\x03H\x00R\n" +
+	"nextCursor\x88\x01\x01\x12$\n" +
+	"\vprev_cursor\x18\x04 \x01(\x03H\x01R\n" +
+	"prevCursor\x88\x01\x01B\x0e\n" +
+	"\f_next_cursorB\x0e\n" +
+	"\f_prev_cursor\"\xa7\x01\n" +
+	"\x10ListChatsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12\x12\n" +
+	"\x04desc\x18\x04 \x01(\bR\x04desc\x12\x1a\n" +
+	"\barchived\x18\x05 \x01(\bR\barchived\x12!\n" +
+	"\ftitle_prefix\x18\x06 \x01(\tR\vtitlePrefix\"t\n" +
+	"\x11ListChatsResponse\x12(\n" +
+	"\x05chats\x18\x01 \x03(\v2\x12.messenger.v1.ChatR\x05chats\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\x12\x1f\n" +
+	"\vnext_cursor\x18\x03 \x01(\tR\n" +
+	"nextCursor\"m\n" +
+	"\x11RenameChatRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\"m\n" +
+	"\x11DeleteChatRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12)\n" +
+	"\x10expected_version\x18\x02 \x01(\x03R\x0fexpectedVersion\x12\x14\n" +
+	"\x05purge\x18\x03 \x01(\bR\x05purge\"<\n" +
+	"\x12DeleteChatResponse\x12&\n" +
+	"\x04chat\x18\x01 \x01(\v2\x12.messenger.v1.ChatR\x04chat\"X\n" +
+	"\x12RestoreChatRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12)\n" +
+	"\x10expected_version\x18\x02 \x01(\x03R\x0fexpectedVersion\"\x88\x01\n" +
+	"\x12SendMessageRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12/\n" +
+	"\x11parent_message_id\x18\x03 \x01(\x03H\x00R\x0fparentMessageId\x88\x01\x01B\x14\n" +
+	"\x12_parent_message_id\"V\n" +
+	"\x15StreamMessagesRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12$\n" +
+	"\x0eafter_event_id\x18\x02 \x01(\x03R\fafterEventId\"\xf5\x01\n" +
+	"\fMessageEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\x03R\aeventId\x123\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1f.messenger.v1.MessageEvent.TypeR\x04type\x12/\n" +
+	"\amessage\x18\x03 \x01(\v2\x15.messenger.v1.MessageR\amessage\"d\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x0f\n" +
+	"\vTYPE_EDITED\x10\x02\x12\x10\n" +
+	"\fTYPE_DELETED\x10\x03\x12\x11\n" +
+	"\rTYPE_RESTORED\x10\x042\xdc\x04\n" +
+	"\vChatService\x12A\n" +
+	"\n" +
+	"CreateChat\x12\x1f.messenger.v1.CreateChatRequest\x1a\x12.messenger.v1.Chat\x12F\n" +
+	"\aGetChat\x12\x1c.messenger.v1.GetChatRequest\x1a\x1d.messenger.v1.GetChatResponse\x12L\n" +
+	"\tListChats\x12\x1e.messenger.v1.ListChatsRequest\x1a\x1f.messenger.v1.ListChatsResponse\x12A\n" +
+	"\n" +
+	"RenameChat\x12\x1f.messenger.v1.RenameChatRequest\x1a\x12.messenger.v1.Chat\x12O\n" +
+	"\n" +
+	"DeleteChat\x12\x1f.messenger.v1.DeleteChatRequest\x1a .messenger.v1.DeleteChatResponse\x12C\n" +
+	"\vRestoreChat\x12 .messenger.v1.RestoreChatRequest\x1a\x12.messenger.v1.Chat\x12F\n" +
+	"\vSendMessage\x12 .messenger.v1.SendMessageRequest\x1a\x15.messenger.v1.Message\x12S\n" +
+	"\x0eStreamMessages\x12#.messenger.v1.StreamMessagesRequest\x1a\x1a.messenger.v1.MessageEvent0\x01B?Z=testtask5/internal/interfaces/grpcAPI/messengerpb;messengerpbb\x06proto3"
+
+var (
+	file_messenger_v1_chat_service_proto_rawDescOnce sync.Once
+	file_messenger_v1_chat_service_proto_rawDescData []byte
+)
+
+func file_messenger_v1_chat_service_proto_rawDescGZIP() []byte {
+	file_messenger_v1_chat_service_proto_rawDescOnce.Do(func() {
+		file_messenger_v1_chat_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_messenger_v1_chat_service_proto_rawDesc), len(file_messenger_v1_chat_service_proto_rawDesc)))
+	})
+	return file_messenger_v1_chat_service_proto_rawDescData
+}
+
+var file_messenger_v1_chat_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_messenger_v1_chat_service_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_messenger_v1_chat_service_proto_goTypes = []any{
+	(MessageEvent_Type)(0),        // 0: messenger.v1.MessageEvent.Type
+	(*CreateChatRequest)(nil),     // 1: messenger.v1.CreateChatRequest
+	(*GetChatRequest)(nil),        // 2: messenger.v1.GetChatRequest
+	(*GetChatResponse)(nil),       // 3: messenger.v1.GetChatResponse
+	(*ListChatsRequest)(nil),      // 4: messenger.v1.ListChatsRequest
+	(*ListChatsResponse)(nil),     // 5: messenger.v1.ListChatsResponse
+	(*RenameChatRequest)(nil),     // 6: messenger.v1.RenameChatRequest
+	(*DeleteChatRequest)(nil),     // 7: messenger.v1.DeleteChatRequest
+	(*DeleteChatResponse)(nil),    // 8: messenger.v1.DeleteChatResponse
+	(*RestoreChatRequest)(nil),    // 9: messenger.v1.RestoreChatRequest
+	(*SendMessageRequest)(nil),    // 10: messenger.v1.SendMessageRequest
+	(*StreamMessagesRequest)(nil), // 11: messenger.v1.StreamMessagesRequest
+	(*MessageEvent)(nil),          // 12: messenger.v1.MessageEvent
+	(*PageCursor)(nil),            // 13: messenger.v1.PageCursor
+	(*Chat)(nil),                  // 14: messenger.v1.Chat
+	(*Message)(nil),               // 15: messenger.v1.Message
+}
+var file_messenger_v1_chat_service_proto_depIdxs = []int32{
+	13, // 0: messenger.v1.GetChatRequest.cursor:type_name -> messenger.v1.PageCursor
+	14, // 1: messenger.v1.GetChatResponse.chat:type_name -> messenger.v1.Chat
+	15, // 2: messenger.v1.GetChatResponse.messages:type_name -> messenger.v1.Message
+	14, // 3: messenger.v1.ListChatsResponse.chats:type_name -> messenger.v1.Chat
+	14, // 4: messenger.v1.DeleteChatResponse.chat:type_name -> messenger.v1.Chat
+	0,  // 5: messenger.v1.MessageEvent.type:type_name -> messenger.v1.MessageEvent.Type
+	15, // 6: messenger.v1.MessageEvent.message:type_name -> messenger.v1.Message
+	1,  // 7: messenger.v1.ChatService.CreateChat:input_type -> messenger.v1.CreateChatRequest
+	2,  // 8: messenger.v1.ChatService.GetChat:input_type -> messenger.v1.GetChatRequest
+	4,  // 9: messenger.v1.ChatService.ListChats:input_type -> messenger.v1.ListChatsRequest
+	6,  // 10: messenger.v1.ChatService.RenameChat:input_type -> messenger.v1.RenameChatRequest
+	7,  // 11: messenger.v1.ChatService.DeleteChat:input_type -> messenger.v1.DeleteChatRequest
+	9,  // 12: messenger.v1.ChatService.RestoreChat:input_type -> messenger.v1.RestoreChatRequest
+	10, // 13: messenger.v1.ChatService.SendMessage:input_type -> messenger.v1.SendMessageRequest
+	11, // 14: messenger.v1.ChatService.StreamMessages:input_type -> messenger.v1.StreamMessagesRequest
+	14, // 15: messenger.v1.ChatService.CreateChat:output_type -> messenger.v1.Chat
+	3,  // 16: messenger.v1.ChatService.GetChat:output_type -> messenger.v1.GetChatResponse
+	5,  // 17: messenger.v1.ChatService.ListChats:output_type -> messenger.v1.ListChatsResponse
+	14, // 18: messenger.v1.ChatService.RenameChat:output_type -> messenger.v1.Chat
+	8,  // 19: messenger.v1.ChatService.DeleteChat:output_type -> messenger.v1.DeleteChatResponse
+	14, // 20: messenger.v1.ChatService.RestoreChat:output_type -> messenger.v1.Chat
+	15, // 21: messenger.v1.ChatService.SendMessage:output_type -> messenger.v1.Message
+	12, // 22: messenger.v1.ChatService.StreamMessages:output_type -> messenger.v1.MessageEvent
+	15, // [15:23] is the sub-list for method output_type
+	7,  // [7:15] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_messenger_v1_chat_service_proto_init() }
+func file_messenger_v1_chat_service_proto_init() {
+	if File_messenger_v1_chat_service_proto != nil {
+		return
+	}
+	file_messenger_v1_types_proto_init()
+	file_messenger_v1_chat_service_proto_msgTypes[2].OneofWrappers = []any{}
+	file_messenger_v1_chat_service_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_messenger_v1_chat_service_proto_rawDesc), len(file_messenger_v1_chat_service_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_messenger_v1_chat_service_proto_goTypes,
+		DependencyIndexes: file_messenger_v1_chat_service_proto_depIdxs,
+		EnumInfos:         file_messenger_v1_chat_service_proto_enumTypes,
+		MessageInfos:      file_messenger_v1_chat_service_proto_msgTypes,
+	}.Build()
+	File_messenger_v1_chat_service_proto = out.File
+	file_messenger_v1_chat_service_proto_goTypes = nil
+	file_messenger_v1_chat_service_proto_depIdxs = nil
+}
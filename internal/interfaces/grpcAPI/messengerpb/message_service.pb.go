@@ -0,0 +1,843 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: messenger/v1/message_service.proto
+
+package messengerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MessageRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	MessageId     int64                  `protobuf:"varint,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessageRef) Reset() {
+	*x = MessageRef{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessageRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageRef) ProtoMessage() {}
+
+func (x *MessageRef) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageRef.ProtoReflect.Descriptor instead.
+func (*MessageRef) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MessageRef) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *MessageRef) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+type EditMessageRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	MessageId     int64                  `protobuf:"varint,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EditMessageRequest) Reset() {
+	*x = EditMessageRequest{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EditMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditMessageRequest) ProtoMessage() {}
+
+func (x *EditMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditMessageRequest.ProtoReflect.Descriptor instead.
+func (*EditMessageRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EditMessageRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *EditMessageRequest) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *EditMessageRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type GetThreadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	MessageId     int64                  `protobuf:"varint,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Cursor        *PageCursor            `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThreadRequest) Reset() {
+	*x = GetThreadRequest{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThreadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThreadRequest) ProtoMessage() {}
+
+func (x *GetThreadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThreadRequest.ProtoReflect.Descriptor instead.
+func (*GetThreadRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetThreadRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *GetThreadRequest) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *GetThreadRequest) GetCursor() *PageCursor {
+	if x != nil {
+		return x.Cursor
+	}
+	return nil
+}
+
+type GetThreadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          *Message               `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Replies       []*Message             `protobuf:"bytes,2,rep,name=replies,proto3" json:"replies,omitempty"`
+	NextCursor    *int64                 `protobuf:"varint,3,opt,name=next_cursor,json=nextCursor,proto3,oneof" json:"next_cursor,omitempty"`
+	PrevCursor    *int64                 `protobuf:"varint,4,opt,name=prev_cursor,json=prevCursor,proto3,oneof" json:"prev_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetThreadResponse) Reset() {
+	*x = GetThreadResponse{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetThreadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetThreadResponse) ProtoMessage() {}
+
+func (x *GetThreadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetThreadResponse.ProtoReflect.Descriptor instead.
+func (*GetThreadResponse) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetThreadResponse) GetRoot() *Message {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+func (x *GetThreadResponse) GetReplies() []*Message {
+	if x != nil {
+		return x.Replies
+	}
+	return nil
+}
+
+func (x *GetThreadResponse) GetNextCursor() int64 {
+	if x != nil && x.NextCursor != nil {
+		return *x.NextCursor
+	}
+	return 0
+}
+
+func (x *GetThreadResponse) GetPrevCursor() int64 {
+	if x != nil && x.PrevCursor != nil {
+		return *x.PrevCursor
+	}
+	return 0
+}
+
+type GetChangesRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ChatId   int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	SinceSeq int64                  `protobuf:"varint,2,opt,name=since_seq,json=sinceSeq,proto3" json:"since_seq,omitempty"`
+	// по умолчанию 100, больше 1000 не отдаётся
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChangesRequest) Reset() {
+	*x = GetChangesRequest{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChangesRequest) ProtoMessage() {}
+
+func (x *GetChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChangesRequest.ProtoReflect.Descriptor instead.
+func (*GetChangesRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetChangesRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *GetChangesRequest) GetSinceSeq() int64 {
+	if x != nil {
+		return x.SinceSeq
+	}
+	return 0
+}
+
+func (x *GetChangesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type GetChangesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// сообщения в последнем состоянии по возрастанию seq
+	Messages      []*Message `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	NextSinceSeq  int64      `protobuf:"varint,2,opt,name=next_since_seq,json=nextSinceSeq,proto3" json:"next_since_seq,omitempty"`
+	ChatSeq       int64      `protobuf:"varint,3,opt,name=chat_seq,json=chatSeq,proto3" json:"chat_seq,omitempty"`
+	HasMore       bool       `protobuf:"varint,4,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChangesResponse) Reset() {
+	*x = GetChangesResponse{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChangesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChangesResponse) ProtoMessage() {}
+
+func (x *GetChangesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChangesResponse.ProtoReflect.Descriptor instead.
+func (*GetChangesResponse) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetChangesResponse) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *GetChangesResponse) GetNextSinceSeq() int64 {
+	if x != nil {
+		return x.NextSinceSeq
+	}
+	return 0
+}
+
+func (x *GetChangesResponse) GetChatSeq() int64 {
+	if x != nil {
+		return x.ChatSeq
+	}
+	return 0
+}
+
+func (x *GetChangesResponse) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+type SearchMessagesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// 0 - по всем чатам пользователя
+	ChatId        int64  `protobuf:"varint,2,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	Cursor        string `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit         int32  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchMessagesRequest) Reset() {
+	*x = SearchMessagesRequest{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchMessagesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMessagesRequest) ProtoMessage() {}
+
+func (x *SearchMessagesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMessagesRequest.ProtoReflect.Descriptor instead.
+func (*SearchMessagesRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchMessagesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchMessagesRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *SearchMessagesRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *SearchMessagesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type SearchMessagesResponse struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Hits          []*SearchMessagesResponse_Hit `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`
+	NextCursor    string                        `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchMessagesResponse) Reset() {
+	*x = SearchMessagesResponse{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchMessagesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMessagesResponse) ProtoMessage() {}
+
+func (x *SearchMessagesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMessagesResponse.ProtoReflect.Descriptor instead.
+func (*SearchMessagesResponse) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchMessagesResponse) GetHits() []*SearchMessagesResponse_Hit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+func (x *SearchMessagesResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type ReactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ChatId        int64                  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	MessageId     int64                  `protobuf:"varint,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Emoji         string                 `protobuf:"bytes,3,opt,name=emoji,proto3" json:"emoji,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactionRequest) Reset() {
+	*x = ReactionRequest{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactionRequest) ProtoMessage() {}
+
+func (x *ReactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactionRequest.ProtoReflect.Descriptor instead.
+func (*ReactionRequest) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReactionRequest) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *ReactionRequest) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *ReactionRequest) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+type ReactionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     int64                  `protobuf:"varint,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Reactions     []*ReactionCount       `protobuf:"bytes,2,rep,name=reactions,proto3" json:"reactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactionsResponse) Reset() {
+	*x = ReactionsResponse{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactionsResponse) ProtoMessage() {}
+
+func (x *ReactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactionsResponse.ProtoReflect.Descriptor instead.
+func (*ReactionsResponse) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReactionsResponse) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *ReactionsResponse) GetReactions() []*ReactionCount {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+type SearchMessagesResponse_Hit struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message *Message               `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Rank    float64                `protobuf:"fixed64,2,opt,name=rank,proto3" json:"rank,omitempty"`
+	// фрагмент текста с подсветкой найденного
+	Snippet       string `protobuf:"bytes,3,opt,name=snippet,proto3" json:"snippet,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchMessagesResponse_Hit) Reset() {
+	*x = SearchMessagesResponse_Hit{}
+	mi := &file_messenger_v1_message_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchMessagesResponse_Hit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMessagesResponse_Hit) ProtoMessage() {}
+
+func (x *SearchMessagesResponse_Hit) ProtoReflect() protoreflect.Message {
+	mi := &file_messenger_v1_message_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMessagesResponse_Hit.ProtoReflect.Descriptor instead.
+func (*SearchMessagesResponse_Hit) Descriptor() ([]byte, []int) {
+	return file_messenger_v1_message_service_proto_rawDescGZIP(), []int{7, 0}
+}
+
+func (x *SearchMessagesResponse_Hit) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *SearchMessagesResponse_Hit) GetRank() float64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *SearchMessagesResponse_Hit) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+var File_messenger_v1_message_service_proto protoreflect.FileDescriptor
+
+const file_messenger_v1_message_service_proto_rawDesc = "" +
+	"\n" +
+	"\"messenger/v1/message_service.proto\x12\fmessenger.v1\x1a\x18messenger/v1/types.proto\"D\n" +
+	"\n" +
+	"MessageRef\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\x03R\tmessageId\"`\n" +
+	"\x12EditMessageRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\x03R\tmessageId\x12\x12\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\"|\n" +
+	"\x10GetThreadRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\x03R\tmessageId\x120\n" +
+	"\x06cursor\x18\x03 \x01(\v2\x18.messenger.v1.PageCursorR\x06cursor\"\xdb\x01\n" +
+	"\x11GetThreadResponse\x12)\n" +
+	"\x04root\x18\x01 \x01(\v2\x15.messenger.v1.MessageR\x04root\x12/\n" +
+	"\areplies\x18\x02 \x03(\v2\x15.messenger.v1.MessageR\areplies\x12$\n" +
+	"\vnext_cursor\x18\x03 \x01(\x03H\x00R\n" +
+	"nextCursor\x88\x01\x01\x12$\n" +
+	"\vprev_cursor\x18\x04 \x01(\x03H\x01R\n" +
+	"prevCursor\x88\x01\x01B\x0e\n" +
+	"\f_next_cursorB\x0e\n" +
+	"\f_prev_cursor\"_\n" +
+	"\x11GetChangesRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x1b\n" +
+	"\tsince_seq\x18\x02 \x01(\x03R\bsinceSeq\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"\xa3\x01\n" +
+	"\x12GetChangesResponse\x121\n" +
+	"\bmessages\x18\x01 \x03(\v2\x15.messenger.v1.MessageR\bmessages\x12$\n" +
+	"\x0enext_since_seq\x18\x02 \x01(\x03R\fnextSinceSeq\x12\x19\n" +
+	"\bchat_seq\x18\x03 \x01(\x03R\achatSeq\x12\x19\n" +
+	"\bhas_more\x18\x04 \x01(\bR\ahasMore\"t\n" +
+	"\x15SearchMessagesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x17\n" +
+	"\achat_id\x18\x02 \x01(\x03R\x06chatId\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\xdd\x01\n" +
+	"\x16SearchMessagesResponse\x12<\n" +
+	"\x04hits\x18\x01 \x03(\v2(.messenger.v1.SearchMessagesResponse.HitR\x04hits\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\x1ad\n" +
+	"\x03Hit\x12/\n" +
+	"\amessage\x18\x01 \x01(\v2\x15.messenger.v1.MessageR\amessage\x12\x12\n" +
+	"\x04rank\x18\x02 \x01(\x01R\x04rank\x12\x18\n" +
+	"\asnippet\x18\x03 \x01(\tR\asnippet\"_\n" +
+	"\x0fReactionRequest\x12\x17\n" +
+	"\achat_id\x18\x01 \x01(\x03R\x06chatId\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\x03R\tmessageId\x12\x14\n" +
+	"\x05emoji\x18\x03 \x01(\tR\x05emoji\"m\n" +
+	"\x11ReactionsResponse\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\x03R\tmessageId\x129\n" +
+	"\treactions\x18\x02 \x03(\v2\x1b.messenger.v1.ReactionCountR\treactions2\xfa\x04\n" +
+	"\x0eMessageService\x12F\n" +
+	"\vEditMessage\x12 .messenger.v1.EditMessageRequest\x1a\x15.messenger.v1.Message\x12@\n" +
+	"\rDeleteMessage\x12\x18.messenger.v1.MessageRef\x1a\x15.messenger.v1.Message\x12A\n" +
+	"\x0eRestoreMessage\x12\x18.messenger.v1.MessageRef\x1a\x15.messenger.v1.Message\x12L\n" +
+	"\tGetThread\x12\x1e.messenger.v1.GetThreadRequest\x1a\x1f.messenger.v1.GetThreadResponse\x12O\n" +
+	"\n" +
+	"GetChanges\x12\x1f.messenger.v1.GetChangesRequest\x1a .messenger.v1.GetChangesResponse\x12[\n" +
+	"\x0eSearchMessages\x12#.messenger.v1.SearchMessagesRequest\x1a$.messenger.v1.SearchMessagesResponse\x12M\n" +
+	"\vAddReaction\x12\x1d.messenger.v1.ReactionRequest\x1a\x1f.messenger.v1.ReactionsResponse\x12P\n" +
+	"\x0eRemoveReaction\x12\x1d.messenger.v1.ReactionRequest\x1a\x1f.messenger.v1.ReactionsResponseB?Z=testtask5/internal/interfaces/grpcAPI/messengerpb;messengerpbb\x06proto3"
+
+var (
+	file_messenger_v1_message_service_proto_rawDescOnce sync.Once
+	file_messenger_v1_message_service_proto_rawDescData []byte
+)
+
+func file_messenger_v1_message_service_proto_rawDescGZIP() []byte {
+	file_messenger_v1_message_service_proto_rawDescOnce.Do(func() {
+		file_messenger_v1_message_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_messenger_v1_message_service_proto_rawDesc), len(file_messenger_v1_message_service_proto_rawDesc)))
+	})
+	return file_messenger_v1_message_service_proto_rawDescData
+}
+
+var file_messenger_v1_message_service_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_messenger_v1_message_service_proto_goTypes = []any{
+	(*MessageRef)(nil),                 // 0: messenger.v1.MessageRef
+	(*EditMessageRequest)(nil),         // 1: messenger.v1.EditMessageRequest
+	(*GetThreadRequest)(nil),           // 2: messenger.v1.GetThreadRequest
+	(*GetThreadResponse)(nil),          // 3: messenger.v1.GetThreadResponse
+	(*GetChangesRequest)(nil),          // 4: messenger.v1.GetChangesRequest
+	(*GetChangesResponse)(nil),         // 5: messenger.v1.GetChangesResponse
+	(*SearchMessagesRequest)(nil),      // 6: messenger.v1.SearchMessagesRequest
+	(*SearchMessagesResponse)(nil),     // 7: messenger.v1.SearchMessagesResponse
+	(*ReactionRequest)(nil),            // 8: messenger.v1.ReactionRequest
+	(*ReactionsResponse)(nil),          // 9: messenger.v1.ReactionsResponse
+	(*SearchMessagesResponse_Hit)(nil), // 10: messenger.v1.SearchMessagesResponse.Hit
+	(*PageCursor)(nil),                 // 11: messenger.v1.PageCursor
+	(*Message)(nil),                    // 12: messenger.v1.Message
+	(*ReactionCount)(nil),              // 13: messenger.v1.ReactionCount
+}
+var file_messenger_v1_message_service_proto_depIdxs = []int32{
+	11, // 0: messenger.v1.GetThreadRequest.cursor:type_name -> messenger.v1.PageCursor
+	12, // 1: messenger.v1.GetThreadResponse.root:type_name -> messenger.v1.Message
+	12, // 2: messenger.v1.GetThreadResponse.replies:type_name -> messenger.v1.Message
+	12, // 3: messenger.v1.GetChangesResponse.messages:type_name -> messenger.v1.Message
+	10, // 4: messenger.v1.SearchMessagesResponse.hits:type_name -> messenger.v1.SearchMessagesResponse.Hit
+	13, // 5: messenger.v1.ReactionsResponse.reactions:type_name -> messenger.v1.ReactionCount
+	12, // 6: messenger.v1.SearchMessagesResponse.Hit.message:type_name -> messenger.v1.Message
+	1,  // 7: messenger.v1.MessageService.EditMessage:input_type -> messenger.v1.EditMessageRequest
+	0,  // 8: messenger.v1.MessageService.DeleteMessage:input_type -> messenger.v1.MessageRef
+	0,  // 9: messenger.v1.MessageService.RestoreMessage:input_type -> messenger.v1.MessageRef
+	2,  // 10: messenger.v1.MessageService.GetThread:input_type -> messenger.v1.GetThreadRequest
+	4,  // 11: messenger.v1.MessageService.GetChanges:input_type -> messenger.v1.GetChangesRequest
+	6,  // 12: messenger.v1.MessageService.SearchMessages:input_type -> messenger.v1.SearchMessagesRequest
+	8,  // 13: messenger.v1.MessageService.AddReaction:input_type -> messenger.v1.ReactionRequest
+	8,  // 14: messenger.v1.MessageService.RemoveReaction:input_type -> messenger.v1.ReactionRequest
+	12, // 15: messenger.v1.MessageService.EditMessage:output_type -> messenger.v1.Message
+	12, // 16: messenger.v1.MessageService.DeleteMessage:output_type -> messenger.v1.Message
+	12, // 17: messenger.v1.MessageService.RestoreMessage:output_type -> messenger.v1.Message
+	3,  // 18: messenger.v1.MessageService.GetThread:output_type -> messenger.v1.GetThreadResponse
+	5,  // 19: messenger.v1.MessageService.GetChanges:output_type -> messenger.v1.GetChangesResponse
+	7,  // 20: messenger.v1.MessageService.SearchMessages:output_type -> messenger.v1.SearchMessagesResponse
+	9,  // 21: messenger.v1.MessageService.AddReaction:output_type -> messenger.v1.ReactionsResponse
+	9,  // 22: messenger.v1.MessageService.RemoveReaction:output_type -> messenger.v1.ReactionsResponse
+	15, // [15:23] is the sub-list for method output_type
+	7,  // [7:15] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_messenger_v1_message_service_proto_init() }
+func file_messenger_v1_message_service_proto_init() {
+	if File_messenger_v1_message_service_proto != nil {
+		return
+	}
+	file_messenger_v1_types_proto_init()
+	file_messenger_v1_message_service_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_messenger_v1_message_service_proto_rawDesc), len(file_messenger_v1_message_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_messenger_v1_message_service_proto_goTypes,
+		DependencyIndexes: file_messenger_v1_message_service_proto_depIdxs,
+		MessageInfos:      file_messenger_v1_message_service_proto_msgTypes,
+	}.Build()
+	File_messenger_v1_message_service_proto = out.File
+	file_messenger_v1_message_service_proto_goTypes = nil
+	file_messenger_v1_message_service_proto_depIdxs = nil
+}
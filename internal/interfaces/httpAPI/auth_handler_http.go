@@ -0,0 +1,101 @@
+package httpHandlers
+
+import (
+	"net/http"
+	"testtask5/internal/domain"
+	"testtask5/internal/dto"
+	"testtask5/internal/services"
+
+	"go.uber.org/zap"
+)
+
+type AuthAPIHTTP struct {
+	baseAPIHTTP
+	authService *services.AuthService
+}
+
+func NewAuthAPIHTTP(aService *services.AuthService, appLogger *zap.Logger) *AuthAPIHTTP {
+	return &AuthAPIHTTP{
+		baseAPIHTTP: baseAPIHTTP{apiLogger: appLogger.Named("auth_api_http")},
+		authService: aService,
+	}
+}
+
+// Регистрация пользователя
+func (ah *AuthAPIHTTP) Register(w http.ResponseWriter, r *http.Request) {
+	var req dto.RegisterRequest
+	if err := ah.decodeJSON(r, &req); err != nil {
+		ah.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		ah.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	user, err := ah.authService.Register(r.Context(), req.Username, req.Password)
+	if err != nil {
+		ah.handleDomainError(w, r, err)
+		return
+	}
+
+	ah.respondJSON(w, r, http.StatusCreated, &dto.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+	})
+}
+
+// Вход по логину и паролю
+func (ah *AuthAPIHTTP) Login(w http.ResponseWriter, r *http.Request) {
+	var req dto.LoginRequest
+	if err := ah.decodeJSON(r, &req); err != nil {
+		ah.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		ah.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	pair, err := ah.authService.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		ah.handleDomainError(w, r, err)
+		return
+	}
+
+	ah.respondJSON(w, r, http.StatusOK, toTokenResponse(pair))
+}
+
+// Обмен refresh-токена на новую пару токенов
+func (ah *AuthAPIHTTP) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshRequest
+	if err := ah.decodeJSON(r, &req); err != nil {
+		ah.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		ah.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	pair, err := ah.authService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		ah.handleDomainError(w, r, err)
+		return
+	}
+
+	ah.respondJSON(w, r, http.StatusOK, toTokenResponse(pair))
+}
+
+func toTokenResponse(pair *domain.TokenPair) *dto.TokenResponse {
+	return &dto.TokenResponse{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(pair.ExpiresIn.Seconds()),
+	}
+}
@@ -0,0 +1,298 @@
+package httpHandlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/repo"
+
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// baseAPIHTTP содержит общие для всех http-хэндлеров хелперы
+type baseAPIHTTP struct {
+	apiLogger *zap.Logger
+}
+
+// logger возвращает логгер хэндлера с request_id и пользователем запроса
+func (b *baseAPIHTTP) logger(r *http.Request) *zap.Logger {
+	return logging.Logger(r.Context(), b.apiLogger)
+}
+
+// parseID извлекает и валидирует ID из URL
+func (b *baseAPIHTTP) parseID(r *http.Request) (int, error) {
+	idStr := chi.URLParam(r, "id")
+	if idStr == "" {
+		return 0, errors.New("chatID отсутствует")
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 0 {
+		return 0, errors.New("chatID должен быть положительным числом")
+	}
+	return id, nil
+}
+
+// parseMessageID извлекает и валидирует ID сообщения из URL
+func (b *baseAPIHTTP) parseMessageID(r *http.Request) (int, error) {
+	idStr := chi.URLParam(r, "msgID")
+	if idStr == "" {
+		return 0, errors.New("messageID отсутствует")
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		return 0, errors.New("messageID должен быть положительным числом")
+	}
+	return id, nil
+}
+
+// parseLimit парсит параметр limit или возвращает дефолтное значение. Больше maxLimit не отдаётся
+func (b *baseAPIHTTP) parseLimit(r *http.Request) int {
+	defaultLimit, maxLimit := 20, 100
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return defaultLimit
+	}
+	if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+		return min(parsed, maxLimit)
+	}
+	return defaultLimit
+}
+
+// parseCursor парсит параметры keyset-пагинации before/after и limit
+func (b *baseAPIHTTP) parseCursor(r *http.Request) (repo.CursorParam, error) {
+	cursor := repo.CursorParam{Limit: b.parseLimit(r)}
+	query := r.URL.Query()
+
+	beforeStr, afterStr := query.Get("before"), query.Get("after")
+	if beforeStr != "" && afterStr != "" {
+		return cursor, errors.New("нельзя одновременно указывать before и after")
+	}
+
+	if beforeStr != "" {
+		before, err := strconv.Atoi(beforeStr)
+		if err != nil || before <= 0 {
+			return cursor, errors.New("before должен быть положительным числом")
+		}
+		cursor.Before = before
+	}
+
+	if afterStr != "" {
+		after, err := strconv.Atoi(afterStr)
+		if err != nil || after <= 0 {
+			return cursor, errors.New("after должен быть положительным числом")
+		}
+		cursor.After = after
+	}
+
+	return cursor, nil
+}
+
+// parseIfMatch достаёт из If-Match версию чата, которую видел клиент.
+// Без заголовка или с * версия не проверяется и возвращается 0
+func (b *baseAPIHTTP) parseIfMatch(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	version, ok := versionFromETag(value)
+	if !ok {
+		return 0, errors.New("If-Match должен содержать ETag чата")
+	}
+	return version, nil
+}
+
+// decodeJSON декодирует тело запроса
+func (b *baseAPIHTTP) decodeJSON(r *http.Request, v interface{}) error {
+	defer r.Body.Close()
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// respondJSON отправляет стандартизированный JSON ответ
+func (b *baseAPIHTTP) respondJSON(w http.ResponseWriter, r *http.Request, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if payload != nil {
+		if err := json.NewEncoder(w).Encode(payload); err != nil {
+			b.logger(r).Error("ошибка при кодировании ответа", zap.Error(err))
+		}
+	}
+}
+
+// respondWithETag отправляет JSON ответ с ETag чата
+func (b *baseAPIHTTP) respondWithETag(w http.ResponseWriter, r *http.Request, status int, version int, payload interface{}) {
+	body, etag, err := encodeWithETag(version, payload)
+	if err != nil {
+		b.logger(r).Error("ошибка при кодировании ответа", zap.Error(err))
+		b.respondError(w, r, "internal server error", http.StatusInternalServerError, nil)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// respondCacheable отправляет чат на чтение. Если у клиента уже есть такой ответ (If-None-Match), отвечает 304 без тела
+func (b *baseAPIHTTP) respondCacheable(w http.ResponseWriter, r *http.Request, version int, payload interface{}) {
+	body, etag, err := encodeWithETag(version, payload)
+	if err != nil {
+		b.logger(r).Error("ошибка при кодировании ответа", zap.Error(err))
+		b.respondError(w, r, "internal server error", http.StatusInternalServerError, nil)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// respondError отправляет ошибку в формате JSON
+func (b *baseAPIHTTP) respondError(w http.ResponseWriter, r *http.Request, message string, code int, err error) {
+	if err != nil {
+		b.logger(r).Warn(message, zap.Error(err))
+	} else {
+		b.logger(r).Warn(message)
+	}
+	b.respondJSON(w, r, code, map[string]string{"error": message})
+}
+
+// handleDomainError маппит ошибки домена на HTTP коды
+func (b *baseAPIHTTP) handleDomainError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrChatNotFound):
+		b.respondError(w, r, "чат не найден", http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrChatArchived):
+		b.respondError(w, r, "чат в архиве", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrChatNotArchived):
+		b.respondError(w, r, "чат не в архиве", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrVersionMismatch):
+		b.respondError(w, r, "чат изменён другим запросом, загрузите его заново", http.StatusPreconditionFailed, err)
+	case errors.Is(err, domain.ErrMessageNotFound):
+		b.respondError(w, r, "сообщение не найдено", http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrMessageDeleted):
+		b.respondError(w, r, "сообщение удалено", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrMessageNotDeleted):
+		b.respondError(w, r, "сообщение не удалено", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrAttachmentNotFound):
+		b.respondError(w, r, "вложение не найдено", http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrAttachmentTooLarge):
+		b.respondError(w, r, "вложение превышает допустимый размер", http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, domain.ErrAttachmentType):
+		b.respondError(w, r, "недопустимый тип вложения", http.StatusUnsupportedMediaType, err)
+	case errors.Is(err, domain.ErrTooManyAttachments):
+		b.respondError(w, r, "слишком много вложений", http.StatusBadRequest, err)
+	case errors.Is(err, domain.ErrEmptyMessage):
+		b.respondError(w, r, "сообщение должно содержать текст или вложения", http.StatusBadRequest, err)
+	case errors.Is(err, domain.ErrAlreadyPinned):
+		b.respondError(w, r, "сообщение уже закреплено", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrNotPinned):
+		b.respondError(w, r, "сообщение не закреплено", http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrPinLimitReached):
+		b.respondError(w, r, "достигнут лимит закреплённых сообщений", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrResyncRequired):
+		b.respondError(w, r, "изменения уже очищены, загрузите историю чата заново", http.StatusGone, err)
+	case errors.Is(err, domain.ErrRestoreExpired):
+		b.respondError(w, r, "время на восстановление сообщения истекло", http.StatusGone, err)
+	case errors.Is(err, domain.ErrParentNotInChat):
+		b.respondError(w, r, "родительское сообщение не найдено в этом чате", http.StatusBadRequest, err)
+	case errors.Is(err, domain.ErrUserAlreadyExists):
+		b.respondError(w, r, "пользователь с таким именем уже существует", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		b.respondError(w, r, "неверный логин или пароль", http.StatusUnauthorized, err)
+	case errors.Is(err, domain.ErrInvalidToken):
+		b.respondError(w, r, "некорректный или просроченный токен", http.StatusUnauthorized, err)
+	case errors.Is(err, domain.ErrUnauthorized):
+		b.respondError(w, r, "требуется аутентификация", http.StatusUnauthorized, err)
+	case errors.Is(err, domain.ErrNotChatMember):
+		b.respondError(w, r, "вы не состоите в этом чате", http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrForbidden):
+		b.respondError(w, r, "недостаточно прав", http.StatusForbidden, err)
+	case errors.Is(err, domain.ErrMemberNotFound):
+		b.respondError(w, r, "участник не найден", http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrUserNotFound):
+		b.respondError(w, r, "пользователь не найден", http.StatusNotFound, err)
+	case errors.Is(err, domain.ErrMemberExists):
+		b.respondError(w, r, "пользователь уже состоит в чате", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrInvalidRole):
+		b.respondError(w, r, "некорректная роль", http.StatusBadRequest, err)
+	case errors.Is(err, domain.ErrOwnerCannotLeave):
+		b.respondError(w, r, "владелец не может покинуть чат, сначала передайте владение", http.StatusConflict, err)
+	case errors.Is(err, domain.ErrChatAlreadyExists):
+		b.respondError(w, r, "чат с таким названием уже существует", http.StatusBadRequest, err)
+	case errors.Is(err, domain.ErrFieldIsNotAllowed):
+		b.respondError(w, r, "поле не разрешено для фильтрации или сортировки", http.StatusBadRequest, err)
+	case errors.Is(err, domain.ErrInvalidCursor):
+		b.respondError(w, r, "некорректный курсор", http.StatusBadRequest, err)
+	case errors.Is(err, context.Canceled):
+		// Клиент ушел, отвечать некому, просто логируем
+		b.logger(r).Info("запрос отменён клиентом")
+	default:
+		b.logger(r).Error("внутренняя ошибка сервера", zap.Error(err))
+		b.respondError(w, r, "internal server error", http.StatusInternalServerError, nil)
+	}
+}
+
+// encodeWithETag кодирует ответ и строит ETag вида "<версия>.<хэш тела>". По версии проверяется If-Match,
+// а хэш меняется вместе с сообщениями и закреплениями, которые версию чата не меняют
+func encodeWithETag(version int, payload interface{}) ([]byte, string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+	body = append(body, '\n')
+
+	sum := sha256.Sum256(body)
+	return body, fmt.Sprintf(`"%d.%s"`, version, hex.EncodeToString(sum[:8])), nil
+}
+
+// versionFromETag достаёт версию из ETag вида "3.<хэш>" или "3"
+func versionFromETag(tag string) (int, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	versionStr, _, _ := strings.Cut(tag[1:len(tag)-1], ".")
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// etagMatches сравнивает ETag со списком из If-None-Match, слабые теги сравниваются как сильные
+func etagMatches(header string, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
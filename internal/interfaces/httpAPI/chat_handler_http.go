@@ -1,28 +1,32 @@
 package httpHandlers
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
+	"mime"
 	"net/http"
 	"strconv"
 	"testtask5/internal/domain"
 	"testtask5/internal/dto"
+	"testtask5/internal/repo"
 	"testtask5/internal/services"
+	"time"
 
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 )
 
+// multipartMemory - сколько multipart-формы держать в памяти, остальное пишется во временные файлы
+const multipartMemory = 8 << 20
+
 type ChatAPIHTTP struct {
+	baseAPIHTTP
 	chatService *services.ChatService
-	apiLogger   *zap.Logger
 }
 
 func NewChatAPIHTTP(mService *services.ChatService, appLogger *zap.Logger) *ChatAPIHTTP {
 	return &ChatAPIHTTP{
+		baseAPIHTTP: baseAPIHTTP{apiLogger: appLogger.Named("chat_api_http")},
 		chatService: mService,
-		apiLogger:   appLogger.Named("chat_api_http"),
 	}
 }
 
@@ -30,173 +34,592 @@ func NewChatAPIHTTP(mService *services.ChatService, appLogger *zap.Logger) *Chat
 func (ch *ChatAPIHTTP) CreateChat(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateChatRequest
 	if err := ch.decodeJSON(r, &req); err != nil {
-		ch.respondError(w, "некорректный JSON", http.StatusBadRequest, err)
+		ch.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
 		return
 	}
 
 	// валидация title
 	if err := req.Validate(); err != nil {
-		ch.respondError(w, "ошибка валидации title", http.StatusBadRequest, err)
+		ch.respondError(w, r, "ошибка валидации title", http.StatusBadRequest, err)
 		return
 	}
 
 	chat := &domain.ChatDomain{Title: req.Title}
 	result, err := ch.chatService.CreateChat(r.Context(), chat)
 	if err != nil {
-		ch.handleDomainError(w, err)
+		ch.handleDomainError(w, r, err)
 		return
 	}
 
-	ch.respondJSON(w, http.StatusCreated, &dto.CreateChatResponse{
+	ch.respondWithETag(w, r, http.StatusCreated, result.Version, &dto.CreateChatResponse{
 		ID:        result.ID,
 		Title:     result.Title,
+		Version:   result.Version,
 		CreatedAt: result.CreatedAt,
 	})
 }
 
-// Получить чат и limit сообщений
+// Получить чат и limit сообщений, листание истории через ?before= / ?after=.
+// Ответ отдаётся с ETag, при совпадении If-None-Match - 304 без тела
 func (ch *ChatAPIHTTP) GetChat(w http.ResponseWriter, r *http.Request) {
 	id, err := ch.parseID(r)
 	if err != nil {
-		ch.respondError(w, err.Error(), http.StatusBadRequest, nil)
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	limit := ch.parseLimit(r)
+	cursor, err := ch.parseCursor(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
 
-	result, err := ch.chatService.GetChatById(r.Context(), id, limit)
+	result, err := ch.chatService.GetChatById(r.Context(), id, cursor)
 	if err != nil {
-		ch.handleDomainError(w, err)
+		ch.handleDomainError(w, r, err)
 		return
 	}
 
-	ch.respondJSON(w, http.StatusOK, &dto.CreateChatResponse{
-		ID:        result.ID,
-		Title:     result.Title,
-		CreatedAt: result.CreatedAt,
-		Messages:  result.Messages,
+	ch.respondCacheable(w, r, result.Version, &dto.CreateChatResponse{
+		ID:         result.ID,
+		Title:      result.Title,
+		Version:    result.Version,
+		CreatedAt:  result.CreatedAt,
+		Messages:   toMessageResponses(result.Messages),
+		Pinned:     toPinResponses(result.Pinned),
+		NextCursor: result.NextCursor,
+		PrevCursor: result.PrevCursor,
 	})
 }
 
-// Удаление чата
+// Список чатов с фильтрами, сортировкой и keyset-пагинацией
+func (ch *ChatAPIHTTP) ListChats(w http.ResponseWriter, r *http.Request) {
+	params, err := ch.parseListParams(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	result, err := ch.chatService.ListChats(r.Context(), params)
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	resp := &dto.ListChatsResponse{
+		Chats:      make([]*dto.ChatListItem, 0, len(result.Chats)),
+		Total:      result.Total,
+		Limit:      params.Limit,
+		NextCursor: result.NextCursor,
+	}
+	for _, chat := range result.Chats {
+		resp.Chats = append(resp.Chats, toChatListItem(chat))
+	}
+
+	ch.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// Переименование чата, с If-Match - только если чат не менялся с тех пор, как клиент его прочитал
+func (ch *ChatAPIHTTP) RenameChat(w http.ResponseWriter, r *http.Request) {
+	id, err := ch.parseID(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	version, err := ch.parseIfMatch(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	var req dto.UpdateChatRequest
+	if err := ch.decodeJSON(r, &req); err != nil {
+		ch.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		ch.respondError(w, r, "ошибка валидации title", http.StatusBadRequest, err)
+		return
+	}
+
+	chat, err := ch.chatService.RenameChat(r.Context(), id, req.Title, version)
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	ch.respondWithETag(w, r, http.StatusOK, chat.Version, toChatListItem(chat))
+}
+
+// Удаление чата: по умолчанию чат переносится в архив, ?purge=true удаляет его безвозвратно
 func (ch *ChatAPIHTTP) DeleteChat(w http.ResponseWriter, r *http.Request) {
 	id, err := ch.parseID(r)
 	if err != nil {
-		ch.respondError(w, err.Error(), http.StatusBadRequest, nil)
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	if err := ch.chatService.DeleteChatByID(r.Context(), id); err != nil {
-		ch.handleDomainError(w, err)
+	version, err := ch.parseIfMatch(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
 		return
 	}
 
-	ch.respondJSON(w, http.StatusOK, &dto.DeleteChatResponse{
-		Content:    "чат успешно удалён",
+	purge := false
+	if v := r.URL.Query().Get("purge"); v != "" {
+		if purge, err = strconv.ParseBool(v); err != nil {
+			ch.respondError(w, r, "purge должен быть true или false", http.StatusBadRequest, nil)
+			return
+		}
+	}
+
+	content := "чат перенесён в архив"
+	if purge {
+		err = ch.chatService.DeleteChatByID(r.Context(), id, version)
+		content = "чат успешно удалён"
+	} else {
+		_, err = ch.chatService.ArchiveChat(r.Context(), id, version)
+	}
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	ch.respondJSON(w, r, http.StatusOK, &dto.DeleteChatResponse{
+		Content:    content,
 		StatusCode: http.StatusOK, // Обычно No Content (204) не возвращает тело, но оставил как у вас
 	})
 }
 
+// Восстановление чата из архива
+func (ch *ChatAPIHTTP) RestoreChat(w http.ResponseWriter, r *http.Request) {
+	id, err := ch.parseID(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	version, err := ch.parseIfMatch(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	chat, err := ch.chatService.RestoreChat(r.Context(), id, version)
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	ch.respondWithETag(w, r, http.StatusOK, chat.Version, toChatListItem(chat))
+}
+
 // Отправка сообщения
 func (ch *ChatAPIHTTP) SendMessage(w http.ResponseWriter, r *http.Request) {
 	id, err := ch.parseID(r)
 	if err != nil {
-		ch.respondError(w, err.Error(), http.StatusBadRequest, nil)
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	//сообщение с вложениями приходит как multipart/form-data, обычное - как JSON
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		ch.sendMessageWithAttachments(w, r, id)
 		return
 	}
 
 	var req dto.CreateMessageRequest
 	if err := ch.decodeJSON(r, &req); err != nil {
-		ch.respondError(w, "некорректный JSON", http.StatusBadRequest, err)
+		ch.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
 		return
 	}
 
 	// валидация text
 	if err := req.Validate(); err != nil {
-		ch.respondError(w, "ошибка валидации text", http.StatusBadRequest, err)
+		ch.respondError(w, r, "ошибка валидации text", http.StatusBadRequest, err)
 		return
 	}
 
 	msgDomain := &domain.MessageDomain{
-		ChatID: id,
-		Text:   req.Text,
+		ChatID:          id,
+		Text:            req.Text,
+		ParentMessageID: req.ParentMessageID,
 	}
 
 	result, err := ch.chatService.SendMessage(r.Context(), msgDomain)
 	if err != nil {
-		ch.handleDomainError(w, err)
+		ch.handleDomainError(w, r, err)
 		return
 	}
 
-	ch.respondJSON(w, http.StatusOK, result)
+	ch.respondJSON(w, r, http.StatusOK, toMessageResponse(result))
 }
 
-// parseID извлекает и валидирует ID из URL
-func (ch *ChatAPIHTTP) parseID(r *http.Request) (int, error) {
-	idStr := chi.URLParam(r, "id")
-	if idStr == "" {
-		return 0, errors.New("chatID отсутствует")
+// sendMessageWithAttachments разбирает multipart-форму: поля text и parent_message_id, файлы в поле files
+func (ch *ChatAPIHTTP) sendMessageWithAttachments(w http.ResponseWriter, r *http.Request, chatID int) {
+	r.Body = http.MaxBytesReader(w, r.Body, ch.chatService.MaxUploadSize())
+
+	//файлы больше multipartMemory сбрасываются во временные файлы
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ch.respondError(w, r, "запрос превышает допустимый размер", http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		ch.respondError(w, r, "некорректная multipart-форма", http.StatusBadRequest, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	req := dto.CreateMessageRequest{Text: r.FormValue("text")}
+	if parentStr := r.FormValue("parent_message_id"); parentStr != "" {
+		parentID, err := strconv.Atoi(parentStr)
+		if err != nil {
+			ch.respondError(w, r, "parent_message_id должен быть положительным числом", http.StatusBadRequest, err)
+			return
+		}
+		req.ParentMessageID = &parentID
+	}
+
+	if err := req.ValidateWithAttachments(); err != nil {
+		ch.respondError(w, r, "ошибка валидации text", http.StatusBadRequest, err)
+		return
 	}
 
-	id, err := strconv.Atoi(idStr)
-	if err != nil || id < 0 {
-		return 0, errors.New("chatID должен быть положительным числом")
+	files := r.MultipartForm.File["files"]
+	uploads := make([]*domain.AttachmentUpload, 0, len(files))
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			ch.respondError(w, r, "не удалось прочитать файл", http.StatusBadRequest, err)
+			return
+		}
+		defer file.Close()
+
+		uploads = append(uploads, &domain.AttachmentUpload{
+			FileName: header.Filename,
+			Size:     header.Size,
+			Content:  file,
+		})
 	}
-	return id, nil
+
+	result, err := ch.chatService.SendMessageWithAttachments(r.Context(), &domain.MessageDomain{
+		ChatID:          chatID,
+		Text:            req.Text,
+		ParentMessageID: req.ParentMessageID,
+	}, uploads)
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	ch.respondJSON(w, r, http.StatusOK, toMessageResponse(result))
 }
 
-// parseLimit парсит параметр limit или возвращает дефолтное значение
-func (ch *ChatAPIHTTP) parseLimit(r *http.Request) int {
-	defaultLimit := 20
-	limitStr := r.URL.Query().Get("limit")
-	if limitStr == "" {
-		return defaultLimit
+// Список закреплённых сообщений
+func (ch *ChatAPIHTTP) ListPinned(w http.ResponseWriter, r *http.Request) {
+	id, err := ch.parseID(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
 	}
-	if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
-		return parsed
+
+	pins, err := ch.chatService.ListPinned(r.Context(), id)
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
 	}
-	return defaultLimit
+
+	ch.respondJSON(w, r, http.StatusOK, toPinResponses(pins))
 }
 
-// decodeJSON декодирует тело запроса
-func (ch *ChatAPIHTTP) decodeJSON(r *http.Request, v interface{}) error {
-	defer r.Body.Close()
-	return json.NewDecoder(r.Body).Decode(v)
+// Закрепление сообщения
+func (ch *ChatAPIHTTP) PinMessage(w http.ResponseWriter, r *http.Request) {
+	id, messageID, err := ch.parsePinPath(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	pin, err := ch.chatService.PinMessage(r.Context(), id, messageID)
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	ch.respondJSON(w, r, http.StatusCreated, toPinResponse(pin))
 }
 
-// respondJSON отправляет стандартизированный JSON ответ
-func (ch *ChatAPIHTTP) respondJSON(w http.ResponseWriter, status int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if payload != nil {
-		if err := json.NewEncoder(w).Encode(payload); err != nil {
-			ch.apiLogger.Error("ошибка при кодировании ответа", zap.Error(err))
-		}
+// Открепление сообщения
+func (ch *ChatAPIHTTP) UnpinMessage(w http.ResponseWriter, r *http.Request) {
+	id, messageID, err := ch.parsePinPath(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
 	}
+
+	if err := ch.chatService.UnpinMessage(r.Context(), id, messageID); err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
-// respondError отправляет ошибку в формате JSON
-func (ch *ChatAPIHTTP) respondError(w http.ResponseWriter, message string, code int, err error) {
+func (ch *ChatAPIHTTP) parsePinPath(r *http.Request) (int, int, error) {
+	id, err := ch.parseID(r)
 	if err != nil {
-		ch.apiLogger.Warn(message, zap.Error(err))
-	} else {
-		ch.apiLogger.Warn(message)
+		return 0, 0, err
 	}
-	ch.respondJSON(w, code, map[string]string{"error": message})
+
+	messageID, err := ch.parseMessageID(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return id, messageID, nil
 }
 
-// handleDomainError маппит ошибки домена на HTTP коды
-func (ch *ChatAPIHTTP) handleDomainError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, domain.ErrChatNotFound):
-		ch.respondError(w, "чат не найден", http.StatusNotFound, err)
-	case errors.Is(err, domain.ErrChatAlreadyExists):
-		ch.respondError(w, "чат с таким названием уже существует", http.StatusBadRequest, err)
-	case errors.Is(err, context.Canceled):
-		// Клиент ушел, отвечать некому, просто логируем
-		ch.apiLogger.Info("запрос отменён клиентом")
+// Список участников чата
+func (ch *ChatAPIHTTP) ListMembers(w http.ResponseWriter, r *http.Request) {
+	id, err := ch.parseID(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	members, err := ch.chatService.ListMembers(r.Context(), id)
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	resp := make([]*dto.MemberResponse, 0, len(members))
+	for _, m := range members {
+		resp = append(resp, toMemberResponse(m))
+	}
+
+	ch.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// Добавление участника в чат
+func (ch *ChatAPIHTTP) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := ch.parseID(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	var req dto.AddMemberRequest
+	if err := ch.decodeJSON(r, &req); err != nil {
+		ch.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	member, err := ch.chatService.AddMember(r.Context(), &domain.ChatMemberDomain{
+		ChatID: id,
+		UserID: req.UserID,
+		Role:   domain.ChatRole(req.Role),
+	})
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	ch.respondJSON(w, r, http.StatusCreated, toMemberResponse(member))
+}
+
+// Изменение роли участника
+func (ch *ChatAPIHTTP) ChangeMemberRole(w http.ResponseWriter, r *http.Request) {
+	id, userID, err := ch.parseMemberPath(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	var req dto.ChangeMemberRoleRequest
+	if err := ch.decodeJSON(r, &req); err != nil {
+		ch.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	member, err := ch.chatService.ChangeMemberRole(r.Context(), id, userID, domain.ChatRole(req.Role))
+	if err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	ch.respondJSON(w, r, http.StatusOK, toMemberResponse(member))
+}
+
+// Исключение участника или выход из чата
+func (ch *ChatAPIHTTP) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, userID, err := ch.parseMemberPath(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := ch.chatService.RemoveMember(r.Context(), id, userID); err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Передача владения чатом
+func (ch *ChatAPIHTTP) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	id, err := ch.parseID(r)
+	if err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	var req dto.TransferOwnershipRequest
+	if err := ch.decodeJSON(r, &req); err != nil {
+		ch.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		ch.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := ch.chatService.TransferOwnership(r.Context(), id, req.UserID); err != nil {
+		ch.handleDomainError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseMemberPath извлекает ID чата и ID пользователя из URL
+func (ch *ChatAPIHTTP) parseMemberPath(r *http.Request) (int, int, error) {
+	id, err := ch.parseID(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil || userID <= 0 {
+		return 0, 0, errors.New("userID должен быть положительным числом")
+	}
+
+	return id, userID, nil
+}
+
+func toMemberResponse(member *domain.ChatMemberDomain) *dto.MemberResponse {
+	return &dto.MemberResponse{
+		ChatID:    member.ChatID,
+		UserID:    member.UserID,
+		Role:      string(member.Role),
+		CreatedAt: member.CreatedAt,
+	}
+}
+
+// parseListParams парсит фильтры, сортировку и курсор для списка чатов
+func (ch *ChatAPIHTTP) parseListParams(r *http.Request) (repo.ListParam, error) {
+	query := r.URL.Query()
+	params := repo.ListParam{
+		SortField: query.Get("sort"),
+		Cursor:    query.Get("cursor"),
+		Limit:     ch.parseLimit(r),
+	}
+
+	if v := query.Get("archived"); v != "" {
+		archived, err := strconv.ParseBool(v)
+		if err != nil {
+			return params, errors.New("archived должен быть true или false")
+		}
+		params.Archived = archived
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		params.SortDesc = true
 	default:
-		ch.apiLogger.Error("внутренняя ошибка сервера", zap.Error(err))
-		ch.respondError(w, "internal server error", http.StatusInternalServerError, nil)
+		return params, errors.New("order должен быть asc или desc")
+	}
+
+	if v := query.Get("title_prefix"); v != "" {
+		params.Filters = append(params.Filters, repo.FilterParam{Field: "title", Value: v, Operator: repo.FilterPrefix})
+	}
+	if v := query.Get("title_contains"); v != "" {
+		params.Filters = append(params.Filters, repo.FilterParam{Field: "title", Value: v, Operator: repo.FilterContains})
+	}
+
+	if v := query.Get("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			return params, errors.New("id должен быть положительным числом")
+		}
+		params.Filters = append(params.Filters, repo.FilterParam{Field: "id", Value: id, Operator: repo.FilterEq})
+	}
+
+	dateFilters := []struct {
+		name     string
+		operator string
+	}{
+		{"created_from", repo.FilterGte},
+		{"created_to", repo.FilterLte},
+	}
+	for _, df := range dateFilters {
+		v := query.Get(df.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return params, errors.New(df.name + " должен быть в формате RFC3339")
+		}
+		params.Filters = append(params.Filters, repo.FilterParam{Field: "created_at", Value: t, Operator: df.operator})
+	}
+
+	return params, nil
+}
+
+func toChatListItem(chat *domain.ChatDomain) *dto.ChatListItem {
+	return &dto.ChatListItem{
+		ID:         chat.ID,
+		Title:      chat.Title,
+		Version:    chat.Version,
+		CreatedAt:  chat.CreatedAt,
+		ArchivedAt: chat.ArchivedAt,
+	}
+}
+
+func toPinResponse(pin *domain.PinDomain) *dto.PinResponse {
+	resp := &dto.PinResponse{
+		MessageID: pin.MessageID,
+		PinnedBy:  pin.PinnedBy,
+		PinnedAt:  pin.PinnedAt,
+	}
+	if pin.Message != nil {
+		resp.Message = toMessageResponse(pin.Message)
+	}
+	return resp
+}
+
+func toPinResponses(pins []*domain.PinDomain) []*dto.PinResponse {
+	resp := make([]*dto.PinResponse, 0, len(pins))
+	for _, pin := range pins {
+		resp = append(resp, toPinResponse(pin))
 	}
+	return resp
 }
@@ -0,0 +1,71 @@
+package httpHandlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.uber.org/zap"
+)
+
+// Хэши SRI статики Swagger UI: браузер не выполнит файл с CDN, если его содержимое подменили.
+// При смене версии пересчитываются скриптом scripts/swagger-ui-sri.sh
+const (
+	swaggerUICSSIntegrity    = ""
+	swaggerUIBundleIntegrity = ""
+)
+
+// swaggerUIPage - Swagger UI со статикой из CDN, документ берётся из /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+	<meta charset="utf-8">
+	<title>API - Swagger UI</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css" integrity="` + swaggerUICSSIntegrity + `" crossorigin="anonymous">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" integrity="` + swaggerUIBundleIntegrity + `" crossorigin="anonymous"></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+		};
+	</script>
+</body>
+</html>
+`
+
+type DocsAPIHTTP struct {
+	baseAPIHTTP
+	spec []byte
+}
+
+// Документ кодируется в JSON один раз: он вшит в бинарник и не меняется
+func NewDocsAPIHTTP(doc *openapi3.T, appLogger *zap.Logger) (*DocsAPIHTTP, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &DocsAPIHTTP{
+		baseAPIHTTP: baseAPIHTTP{apiLogger: appLogger.Named("docs_api_http")},
+		spec:        spec,
+	}, nil
+}
+
+// Спецификация OpenAPI в JSON
+func (dh *DocsAPIHTTP) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(dh.spec); err != nil {
+		dh.logger(r).Warn("не удалось отдать спецификацию", zap.Error(err))
+	}
+}
+
+// Swagger UI для спецификации
+func (dh *DocsAPIHTTP) UI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(swaggerUIPage)); err != nil {
+		dh.logger(r).Warn("не удалось отдать страницу документации", zap.Error(err))
+	}
+}
@@ -0,0 +1,68 @@
+package httpHandlers
+
+import (
+	"net/http"
+	"testtask5/internal/domain"
+	"testtask5/internal/dto"
+	"testtask5/internal/services"
+
+	"go.uber.org/zap"
+)
+
+type HealthAPIHTTP struct {
+	baseAPIHTTP
+	healthService *services.HealthService
+}
+
+func NewHealthAPIHTTP(hService *services.HealthService, appLogger *zap.Logger) *HealthAPIHTTP {
+	return &HealthAPIHTTP{
+		baseAPIHTTP:   baseAPIHTTP{apiLogger: appLogger.Named("health_api_http")},
+		healthService: hService,
+	}
+}
+
+// Healthz отвечает, пока процесс жив. Зависимости не проверяются: недоступная база
+// не повод перезапускать процесс
+func (hh *HealthAPIHTTP) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	hh.respondJSON(w, r, http.StatusOK, &dto.HealthResponse{Status: "ok"})
+}
+
+// Readyz отвечает 200, если сервис готов принимать запросы, иначе 503. В теле - результат каждой проверки
+func (hh *HealthAPIHTTP) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := hh.healthService.Ready(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	hh.respondJSON(w, r, status, toReadinessResponse(report))
+}
+
+func toReadinessResponse(report *domain.HealthReport) *dto.ReadinessResponse {
+	resp := &dto.ReadinessResponse{
+		Status: healthStatus(report.Ready()),
+		Checks: make([]*dto.HealthCheckResponse, 0, len(report.Checks)),
+	}
+	for _, check := range report.Checks {
+		checkResp := &dto.HealthCheckResponse{
+			Name:       check.Name,
+			Status:     healthStatus(check.Err == nil),
+			DurationMs: float64(check.Duration.Microseconds()) / 1000,
+		}
+		if check.Err != nil {
+			checkResp.Error = check.Err.Error()
+		}
+		resp.Checks = append(resp.Checks, checkResp)
+	}
+	return resp
+}
+
+func healthStatus(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "fail"
+}
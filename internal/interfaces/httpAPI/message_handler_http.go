@@ -1,20 +1,524 @@
 package httpHandlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"testtask5/internal/domain"
+	"testtask5/internal/dto"
+	"testtask5/internal/repo"
 	"testtask5/internal/services"
+	"time"
 
+	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 )
 
+const (
+	changesDefaultLimit = 100
+	changesMaxLimit     = 1000
+)
+
 type MessageAPIHTTP struct {
+	baseAPIHTTP
 	messageService *services.MessageService
-	apiLogger      *zap.Logger
 }
 
 func NewMessageAPIHTTP(mService *services.MessageService, appLogger *zap.Logger) *MessageAPIHTTP {
 	apiLogger := appLogger.Named("message_api_http")
 	return &MessageAPIHTTP{
+		baseAPIHTTP:    baseAPIHTTP{apiLogger: apiLogger},
 		messageService: mService,
-		apiLogger:      apiLogger,
 	}
 }
+
+// Скачивание вложения сообщения
+func (mh *MessageAPIHTTP) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	chatID, messageID, attachmentID, err := mh.parseAttachmentPath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	attachment, content, err := mh.messageService.OpenAttachment(r.Context(), chatID, messageID, attachmentID)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName})
+	mh.streamContent(w, r, content, attachment.ContentType, attachment.Size, disposition)
+}
+
+// Превью картинки, size - длина большей стороны
+func (mh *MessageAPIHTTP) DownloadThumbnail(w http.ResponseWriter, r *http.Request) {
+	chatID, messageID, attachmentID, err := mh.parseAttachmentPath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	maxSide, err := strconv.Atoi(chi.URLParam(r, "size"))
+	if err != nil || maxSide <= 0 {
+		mh.respondError(w, r, "size должен быть положительным числом", http.StatusBadRequest, nil)
+		return
+	}
+
+	thumbnail, content, err := mh.messageService.OpenThumbnail(r.Context(), chatID, messageID, attachmentID, maxSide)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	mh.streamContent(w, r, content, thumbnail.ContentType, thumbnail.Size, "inline")
+}
+
+// streamContent отдаёт содержимое файла из хранилища
+func (mh *MessageAPIHTTP) streamContent(w http.ResponseWriter, r *http.Request, content io.Reader, contentType string, size int64, disposition string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set("Content-Disposition", disposition)
+	//браузер не должен угадывать тип по содержимому, иначе загруженный html исполнится
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	//заголовки уже отправлены, об ошибке остаётся только залогировать
+	if _, err := io.Copy(w, content); err != nil {
+		mh.logger(r).Warn("не удалось отдать содержимое вложения", zap.Error(err))
+	}
+}
+
+// parseAttachmentPath извлекает id чата, сообщения и вложения из URL
+func (mh *MessageAPIHTTP) parseAttachmentPath(r *http.Request) (int, int, int, error) {
+	chatID, messageID, err := mh.parseMessagePath(r)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	attachmentID, err := strconv.Atoi(chi.URLParam(r, "attID"))
+	if err != nil || attachmentID <= 0 {
+		return 0, 0, 0, errors.New("attachmentID должен быть положительным числом")
+	}
+
+	return chatID, messageID, attachmentID, nil
+}
+
+// Редактирование сообщения
+func (mh *MessageAPIHTTP) EditMessage(w http.ResponseWriter, r *http.Request) {
+	chatID, messageID, err := mh.parseMessagePath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	var req dto.UpdateMessageRequest
+	if err := mh.decodeJSON(r, &req); err != nil {
+		mh.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	// валидация text
+	if err := req.Validate(); err != nil {
+		mh.respondError(w, r, "ошибка валидации text", http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := mh.messageService.EditMessage(r.Context(), &domain.MessageDomain{
+		ID:     messageID,
+		ChatID: chatID,
+		Text:   req.Text,
+	})
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, toMessageResponse(result))
+}
+
+// Мягкое удаление сообщения, в ответе tombstone
+func (mh *MessageAPIHTTP) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	chatID, messageID, err := mh.parseMessagePath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	result, err := mh.messageService.DeleteMessage(r.Context(), chatID, messageID)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, toMessageResponse(result))
+}
+
+// Восстановление удалённого сообщения в пределах окна восстановления
+func (mh *MessageAPIHTTP) RestoreMessage(w http.ResponseWriter, r *http.Request) {
+	chatID, messageID, err := mh.parseMessagePath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	result, err := mh.messageService.RestoreMessage(r.Context(), chatID, messageID)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, toMessageResponse(result))
+}
+
+// Тред: корневое сообщение и ответы на него с той же пагинацией, что и история чата
+func (mh *MessageAPIHTTP) GetThread(w http.ResponseWriter, r *http.Request) {
+	chatID, messageID, err := mh.parseMessagePath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	cursor, err := mh.parseCursor(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	thread, err := mh.messageService.GetThread(r.Context(), chatID, messageID, cursor)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	resp := &dto.ThreadResponse{
+		Root:       toMessageResponse(thread.Root),
+		Replies:    make([]*dto.MessageResponse, 0, len(thread.Replies)),
+		NextCursor: thread.NextCursor,
+		PrevCursor: thread.PrevCursor,
+	}
+	for _, reply := range thread.Replies {
+		resp.Replies = append(resp.Replies, toMessageResponse(reply))
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// Дельта-синхронизация: изменения сообщений чата после ?since_seq=
+func (mh *MessageAPIHTTP) GetChanges(w http.ResponseWriter, r *http.Request) {
+	chatID, err := mh.parseID(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	sinceSeq, limit, err := parseChangesParams(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	changes, err := mh.messageService.GetChanges(r.Context(), chatID, sinceSeq, limit)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	resp := &dto.ChangesResponse{
+		ChatID:       chatID,
+		Changes:      make([]*dto.MessageChangeResponse, 0, len(changes.Messages)),
+		NextSinceSeq: sinceSeq,
+		ChatSeq:      changes.ChatSeq,
+		HasMore:      changes.HasMore,
+	}
+	for _, message := range changes.Messages {
+		resp.Changes = append(resp.Changes, &dto.MessageChangeResponse{
+			Seq:     message.Seq,
+			Type:    changeType(message),
+			Message: toMessageResponse(message),
+		})
+		resp.NextSinceSeq = message.Seq
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// Поставить реакцию на сообщение
+func (mh *MessageAPIHTTP) AddReaction(w http.ResponseWriter, r *http.Request) {
+	mh.handleReaction(w, r, mh.messageService.AddReaction)
+}
+
+// Снять реакцию с сообщения
+func (mh *MessageAPIHTTP) RemoveReaction(w http.ResponseWriter, r *http.Request) {
+	mh.handleReaction(w, r, mh.messageService.RemoveReaction)
+}
+
+// handleReaction - общий разбор запроса для постановки и снятия реакции
+func (mh *MessageAPIHTTP) handleReaction(
+	w http.ResponseWriter,
+	r *http.Request,
+	apply func(ctx context.Context, chatID int, reaction *domain.ReactionDomain) ([]domain.ReactionCount, error),
+) {
+	chatID, messageID, err := mh.parseMessagePath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	var req dto.ReactionRequest
+	if err := mh.decodeJSON(r, &req); err != nil {
+		mh.respondError(w, r, "некорректный JSON", http.StatusBadRequest, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		mh.respondError(w, r, "ошибка валидации реакции", http.StatusBadRequest, err)
+		return
+	}
+
+	counts, err := apply(r.Context(), chatID, &domain.ReactionDomain{
+		MessageID: messageID,
+		Emoji:     req.Emoji,
+	})
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	resp := &dto.ReactionsResponse{
+		MessageID: messageID,
+		Reactions: make([]*dto.ReactionCountResponse, 0, len(counts)),
+	}
+	for _, c := range counts {
+		resp.Reactions = append(resp.Reactions, &dto.ReactionCountResponse{Emoji: c.Emoji, Count: c.Count})
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// История правок сообщения
+func (mh *MessageAPIHTTP) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	chatID, messageID, err := mh.parseMessagePath(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	revisions, err := mh.messageService.GetMessageRevisions(r.Context(), chatID, messageID)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	resp := make([]*dto.MessageRevisionResponse, 0, len(revisions))
+	for _, rev := range revisions {
+		resp = append(resp, &dto.MessageRevisionResponse{
+			ID:        rev.ID,
+			MessageID: rev.MessageID,
+			Text:      rev.Text,
+			EditedAt:  rev.CreatedAt,
+		})
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// Полнотекстовый поиск по сообщениям
+func (mh *MessageAPIHTTP) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	params, err := mh.parseSearchParams(r)
+	if err != nil {
+		mh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	result, err := mh.messageService.SearchMessages(r.Context(), params)
+	if err != nil {
+		mh.handleDomainError(w, r, err)
+		return
+	}
+
+	resp := &dto.SearchMessagesResponse{
+		Hits:       make([]*dto.MessageSearchHit, 0, len(result.Hits)),
+		NextCursor: result.NextCursor,
+	}
+	for _, hit := range result.Hits {
+		resp.Hits = append(resp.Hits, &dto.MessageSearchHit{
+			ID:        hit.Message.ID,
+			ChatID:    hit.Message.ChatID,
+			Text:      hit.Message.Text,
+			Snippet:   hit.Snippet,
+			Rank:      hit.Rank,
+			CreatedAt: hit.Message.CreatedAt,
+		})
+	}
+
+	mh.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// parseSearchParams парсит строку поиска, фильтры по чату и датам и курсор
+func (mh *MessageAPIHTTP) parseSearchParams(r *http.Request) (repo.SearchParam, error) {
+	query := r.URL.Query()
+	params := repo.SearchParam{
+		Query:  strings.TrimSpace(query.Get("q")),
+		Cursor: query.Get("cursor"),
+		Limit:  mh.parseLimit(r),
+	}
+
+	if params.Query == "" {
+		return params, errors.New("q не может быть пустым")
+	}
+	if len(params.Query) > 500 {
+		return params, errors.New("q слишком длинный (500 максимум)")
+	}
+
+	if v := query.Get("chat_id"); v != "" {
+		chatID, err := strconv.Atoi(v)
+		if err != nil || chatID <= 0 {
+			return params, errors.New("chat_id должен быть положительным числом")
+		}
+		params.ChatID = chatID
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return params, errors.New("from должен быть в формате RFC3339")
+		}
+		params.From = &from
+	}
+
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return params, errors.New("to должен быть в формате RFC3339")
+		}
+		params.To = &to
+	}
+
+	return params, nil
+}
+
+// parseMessagePath извлекает ID чата и ID сообщения из URL
+func (mh *MessageAPIHTTP) parseMessagePath(r *http.Request) (int, int, error) {
+	chatID, err := mh.parseID(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	messageID, err := mh.parseMessageID(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return chatID, messageID, nil
+}
+
+// parseChangesParams парсит since_seq и limit дельта-синхронизации
+func parseChangesParams(r *http.Request) (int64, int, error) {
+	query := r.URL.Query()
+
+	var sinceSeq int64
+	if value := query.Get("since_seq"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			return 0, 0, errors.New("since_seq должен быть неотрицательным числом")
+		}
+		sinceSeq = parsed
+	}
+
+	limit := changesDefaultLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return 0, 0, errors.New("limit должен быть положительным числом")
+		}
+		limit = min(parsed, changesMaxLimit)
+	}
+
+	return sinceSeq, limit, nil
+}
+
+func changeType(message *domain.MessageDomain) string {
+	switch {
+	case message.DeletedAt != nil:
+		return "deleted"
+	case message.EditedAt != nil:
+		return "edited"
+	default:
+		return "created"
+	}
+}
+
+func toMessageResponse(message *domain.MessageDomain) *dto.MessageResponse {
+	return &dto.MessageResponse{
+		ID:              message.ID,
+		ChatID:          message.ChatID,
+		Seq:             message.Seq,
+		ParentMessageID: message.ParentMessageID,
+		AuthorID:        message.AuthorID,
+		Text:            message.Text,
+		CreatedAt:       message.CreatedAt,
+		EditedAt:        message.EditedAt,
+		DeletedAt:       message.DeletedAt,
+		ReplyCount:      message.ReplyCount,
+		LastReplyAt:     message.LastReplyAt,
+		Reactions:       toReactionCountResponses(message.Reactions),
+		Attachments:     toAttachmentResponses(message),
+	}
+}
+
+func toMessageResponses(messages []*domain.MessageDomain) []*dto.MessageResponse {
+	resp := make([]*dto.MessageResponse, 0, len(messages))
+	for _, message := range messages {
+		resp = append(resp, toMessageResponse(message))
+	}
+	return resp
+}
+
+func toReactionCountResponses(counts []domain.ReactionCount) []*dto.ReactionCountResponse {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	resp := make([]*dto.ReactionCountResponse, 0, len(counts))
+	for _, c := range counts {
+		resp = append(resp, &dto.ReactionCountResponse{Emoji: c.Emoji, Count: c.Count})
+	}
+	return resp
+}
+
+func toAttachmentResponses(message *domain.MessageDomain) []*dto.AttachmentResponse {
+	if len(message.Attachments) == 0 {
+		return nil
+	}
+
+	resp := make([]*dto.AttachmentResponse, 0, len(message.Attachments))
+	for _, attachment := range message.Attachments {
+		url := fmt.Sprintf("/chats/%d/messages/%d/attachments/%d", message.ChatID, message.ID, attachment.ID)
+		item := &dto.AttachmentResponse{
+			ID:          attachment.ID,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			URL:         url,
+			Width:       attachment.Width,
+			Height:      attachment.Height,
+		}
+		for _, thumbnail := range attachment.Thumbnails {
+			item.Thumbnails = append(item.Thumbnails, &dto.ThumbnailResponse{
+				MaxSide: thumbnail.MaxSide,
+				Width:   thumbnail.Width,
+				Height:  thumbnail.Height,
+				URL:     fmt.Sprintf("%s/thumbnails/%d", url, thumbnail.MaxSide),
+			})
+		}
+		resp = append(resp, item)
+	}
+	return resp
+}
@@ -0,0 +1,161 @@
+package httpHandlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testtask5/internal/domain"
+	"testtask5/internal/services"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	sseHeartbeatPeriod = 15 * time.Second
+	sseReplayBatch     = 500
+)
+
+type ChatSSEHTTP struct {
+	baseAPIHTTP
+	chatService *services.ChatService
+}
+
+func NewChatSSEHTTP(cService *services.ChatService, appLogger *zap.Logger) *ChatSSEHTTP {
+	return &ChatSSEHTTP{
+		baseAPIHTTP: baseAPIHTTP{apiLogger: appLogger.Named("chat_sse_http")},
+		chatService: cService,
+	}
+}
+
+// Поток событий чата в формате text/event-stream. После переподключения
+// события, пропущенные после Last-Event-ID, дочитываются из журнала
+func (sh *ChatSSEHTTP) Stream(w http.ResponseWriter, r *http.Request) {
+	id, err := sh.parseID(r)
+	if err != nil {
+		sh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	lastID, err := parseLastEventID(r)
+	if err != nil {
+		sh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		sh.respondError(w, r, "потоковая передача не поддерживается", http.StatusInternalServerError, nil)
+		return
+	}
+
+	ctx := r.Context()
+
+	//подписываемся до чтения журнала, чтобы не потерять события между повтором и живым потоком
+	sub, err := sh.chatService.SubscribeChat(ctx, id)
+	if err != nil {
+		sh.handleDomainError(w, r, err)
+		return
+	}
+	defer sh.chatService.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if lastID > 0 {
+		for {
+			events, err := sh.chatService.ReplayEvents(ctx, id, lastID, sseReplayBatch)
+			if err != nil {
+				sh.logger(r).Error("не удалось повторить события", zap.Int("chat_id", id), zap.Error(err))
+				return
+			}
+			for _, event := range events {
+				if err := writeSSEEvent(w, event); err != nil {
+					return
+				}
+				lastID = event.ID
+				if event.Type.EndsStream() {
+					flusher.Flush()
+					return
+				}
+			}
+			flusher.Flush()
+			if len(events) < sseReplayBatch {
+				break
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			//комментарий держит соединение живым через прокси
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-sub.Events:
+			if !ok {
+				//сервер останавливается или клиент не успевал читать - он переподключится с Last-Event-ID
+				return
+			}
+			//события, уже отданные из журнала
+			if event.ID != 0 && event.ID <= lastID {
+				continue
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+			if event.ID > lastID {
+				lastID = event.ID
+			}
+			if event.Type.EndsStream() {
+				return
+			}
+		}
+	}
+}
+
+// parseLastEventID читает заголовок Last-Event-ID, EventSource-полифиллы передают его в ?last_event_id=
+func parseLastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	lastID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || lastID < 0 {
+		return 0, errors.New("Last-Event-ID должен быть неотрицательным числом")
+	}
+	return lastID, nil
+}
+
+func writeSSEEvent(w http.ResponseWriter, event *domain.ChatEvent) error {
+	data, err := json.Marshal(toChatEventResponse(event))
+	if err != nil {
+		return err
+	}
+
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
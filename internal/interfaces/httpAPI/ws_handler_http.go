@@ -0,0 +1,157 @@
+package httpHandlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testtask5/internal/domain"
+	"testtask5/internal/dto"
+	"testtask5/internal/realtime"
+	"testtask5/internal/services"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	//клиент ничего не присылает, кроме pong и close, большие сообщения не нужны
+	wsMaxReadSize = 512
+)
+
+type ChatWSHTTP struct {
+	baseAPIHTTP
+	chatService *services.ChatService
+	upgrader    websocket.Upgrader
+	conns       sync.WaitGroup
+}
+
+func NewChatWSHTTP(cService *services.ChatService, appLogger *zap.Logger) *ChatWSHTTP {
+	return &ChatWSHTTP{
+		baseAPIHTTP: baseAPIHTTP{apiLogger: appLogger.Named("chat_ws_http")},
+		chatService: cService,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+		},
+	}
+}
+
+// Подписка на новые, изменённые и удалённые сообщения чата по WebSocket
+func (wh *ChatWSHTTP) Subscribe(w http.ResponseWriter, r *http.Request) {
+	id, err := wh.parseID(r)
+	if err != nil {
+		wh.respondError(w, r, err.Error(), http.StatusBadRequest, nil)
+		return
+	}
+
+	//права проверяем до апгрейда, чтобы вернуть клиенту обычный http-статус
+	sub, err := wh.chatService.SubscribeChat(r.Context(), id)
+	if err != nil {
+		wh.handleDomainError(w, r, err)
+		return
+	}
+
+	conn, err := wh.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		//Upgrade уже ответил клиенту ошибкой
+		wh.chatService.Unsubscribe(sub)
+		wh.logger(r).Warn("не удалось установить websocket-соединение", zap.Error(err))
+		return
+	}
+
+	wh.conns.Add(1)
+	go wh.writePump(conn, sub)
+	go wh.readPump(conn, sub)
+}
+
+// Shutdown ждёт, пока закроются все websocket-соединения.
+// Сами соединения закрываются остановкой брокера событий
+func (wh *ChatWSHTTP) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		wh.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readPump читает управляющие кадры клиента и отписывает его, когда соединение оборвалось
+func (wh *ChatWSHTTP) readPump(conn *websocket.Conn, sub *realtime.Subscription) {
+	defer wh.chatService.Unsubscribe(sub)
+
+	conn.SetReadLimit(wsMaxReadSize)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump - единственный писатель в соединение: события, ping и закрывающий кадр
+func (wh *ChatWSHTTP) writePump(conn *websocket.Conn, sub *realtime.Subscription) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+		wh.conns.Done()
+	}()
+
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				//подписка закрыта: сервер останавливается или клиент не успевал читать
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := conn.WriteJSON(toChatEventResponse(event)); err != nil {
+				return
+			}
+			if event.Type.EndsStream() {
+				conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "чат удалён или перенесён в архив"))
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func toChatEventResponse(event *domain.ChatEvent) *dto.ChatEventResponse {
+	resp := &dto.ChatEventResponse{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		ChatID:     event.ChatID,
+		ActorID:    event.ActorID,
+		MemberID:   event.MemberID,
+		OccurredAt: event.OccurredAt,
+	}
+	if event.Message != nil {
+		resp.Message = toMessageResponse(event.Message)
+	}
+	if event.Chat != nil {
+		resp.Title = event.Chat.Title
+	}
+	return resp
+}
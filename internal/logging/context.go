@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type fieldsCtxKey struct{}
+type requestIDCtxKey struct{}
+
+// WithFields добавляет поля, которые попадут во все логи этого запроса
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	existing, _ := ctx.Value(fieldsCtxKey{}).([]zap.Field)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsCtxKey{}, merged)
+}
+
+// WithRequestID кладёт ID запроса в контекст и в поля логов
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDCtxKey{}, requestID)
+	return WithFields(ctx, zap.String("request_id", requestID))
+}
+
+// RequestIDFromContext достаёт ID запроса, положенный middleware
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDCtxKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// Logger возвращает логгер слоя с полями запроса из контекста. Имя логгера остаётся от слоя,
+// поэтому строки одного запроса из хэндлера, сервиса и репозитория находятся по request_id.
+// Если в контексте есть спан, добавляются trace_id и span_id, чтобы перейти от лога к трейсу
+func Logger(ctx context.Context, base *zap.Logger) *zap.Logger {
+	fields, _ := ctx.Value(fieldsCtxKey{}).([]zap.Field)
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		fields = append(fields[:len(fields):len(fields)],
+			zap.String("trace_id", spanCtx.TraceID().String()),
+			zap.String("span_id", spanCtx.SpanID().String()),
+		)
+	}
+	if len(fields) == 0 {
+		return base
+	}
+	return base.With(fields...)
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := zap.New(core).Named("chat_service")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithFields(ctx, zap.Int("user_id", 7))
+
+	Logger(ctx, base).Info("сообщение")
+	Logger(context.Background(), base).Info("без запроса")
+
+	entries := logs.All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "chat_service", entries[0].LoggerName)
+	assert.Equal(t, map[string]any{"request_id": "req-1", "user_id": int64(7)}, entries[0].ContextMap())
+	assert.Empty(t, entries[1].Context)
+
+	requestID, ok := RequestIDFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "req-1", requestID)
+}
+
+func TestLogger_TraceIDs(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	base := zap.New(core)
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{2},
+	})
+	ctx := trace.ContextWithSpanContext(WithRequestID(context.Background(), "req-1"), spanCtx)
+
+	Logger(ctx, base).Info("сообщение")
+
+	assert.Equal(t, map[string]any{
+		"request_id": "req-1",
+		"trace_id":   "01000000000000000000000000000000",
+		"span_id":    "0200000000000000",
+	}, logs.All()[0].ContextMap())
+}
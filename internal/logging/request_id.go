@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// maxRequestIDLength - входящий ID длиннее этого заменяется своим, чтобы не раздувать логи
+const maxRequestIDLength = 128
+
+// RequestIDOrNew возвращает ID запроса от клиента, если он годится для логов, иначе генерирует новый
+func RequestIDOrNew(requestID string) string {
+	if !validRequestID(requestID) {
+		return newRequestID()
+	}
+	return requestID
+}
+
+// validRequestID пропускает только печатные ASCII-символы: ID попадает в логи и заголовки ответа
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	raw := make([]byte, 16)
+	//crypto/rand не возвращает ошибок
+	rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "messanger"
+
+// Metrics собирает метрики сервиса в собственный реестр и отдаёт их в формате Prometheus
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests   *prometheus.CounterVec
+	httpDuration   *prometheus.HistogramVec
+	workerDuration *prometheus.HistogramVec
+	workerErrors   *prometheus.CounterVec
+	workerLastRun  *prometheus.GaugeVec
+	workerInterval *prometheus.GaugeVec
+	chatsTotal     prometheus.Gauge
+	messagesTotal  prometheus.Gauge
+}
+
+// db - пул соединений GORM, его статистика отдаётся вместе с остальными метриками
+func NewMetrics(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Количество HTTP-запросов по маршруту и статусу ответа.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Время обработки HTTP-запросов по маршруту и статусу ответа.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		workerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "worker_run_duration_seconds",
+			Help:      "Время одного прогона фонового воркера.",
+			Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60},
+		}, []string{"worker"}),
+		workerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "worker_run_errors_total",
+			Help:      "Количество прогонов фонового воркера, завершившихся ошибкой.",
+		}, []string{"worker"}),
+		workerLastRun: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "worker_last_run_timestamp_seconds",
+			Help:      "Время последнего запуска фонового воркера, unix-секунды.",
+		}, []string{"worker"}),
+		workerInterval: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "worker_interval_seconds",
+			Help:      "Интервал запуска фонового воркера.",
+		}, []string{"worker"}),
+		chatsTotal: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "chats_total",
+			Help:      "Количество чатов.",
+		}),
+		messagesTotal: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "messages_total",
+			Help:      "Количество сообщений.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.workerDuration,
+		m.workerErrors,
+		m.workerLastRun,
+		m.workerInterval,
+		m.chatsTotal,
+		m.messagesTotal,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+	}
+
+	return m
+}
+
+// Handler отдаёт метрики для /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest учитывает HTTP-запрос. route - шаблон маршрута, а не путь, иначе каждый id чата
+// станет отдельной серией
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	statusStr := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, statusStr).Inc()
+	m.httpDuration.WithLabelValues(method, route, statusStr).Observe(duration.Seconds())
+}
+
+// ObserveWorkerRun учитывает прогон фонового воркера
+func (m *Metrics) ObserveWorkerRun(worker string, duration time.Duration, err error) {
+	m.workerDuration.WithLabelValues(worker).Observe(duration.Seconds())
+	if err != nil {
+		m.workerErrors.WithLabelValues(worker).Inc()
+	}
+}
+
+// SetWorkerInterval запоминает, как часто должен запускаться воркер
+func (m *Metrics) SetWorkerInterval(worker string, interval time.Duration) {
+	m.workerInterval.WithLabelValues(worker).Set(interval.Seconds())
+}
+
+// ObserveWorkerStart отмечает запуск воркера. Зависший воркер перестаёт запускаться, и время
+// последнего запуска отстаёт от текущего больше чем на несколько интервалов
+func (m *Metrics) ObserveWorkerStart(worker string, at time.Time) {
+	m.workerLastRun.WithLabelValues(worker).Set(float64(at.Unix()))
+}
+
+// SetTotals обновляет количество чатов и сообщений
+func (m *Metrics) SetTotals(chats int64, messages int64) {
+	m.chatsTotal.Set(float64(chats))
+	m.messagesTotal.Set(float64(messages))
+}
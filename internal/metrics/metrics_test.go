@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := NewMetrics(nil)
+
+	m.ObserveRequest("GET", "/chats/{id}", 200, 30*time.Millisecond)
+	m.ObserveRequest("GET", "/chats/{id}", 200, 10*time.Millisecond)
+	m.SetWorkerInterval("thumbnails", 30*time.Second)
+	m.ObserveWorkerStart("thumbnails", time.Unix(1700000000, 0))
+	m.ObserveWorkerRun("thumbnails", time.Second, errors.New("blob store недоступен"))
+	m.SetTotals(3, 42)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, string(body), `messanger_http_requests_total{method="GET",route="/chats/{id}",status="200"} 2`)
+	assert.Contains(t, string(body), `messanger_http_request_duration_seconds_count{method="GET",route="/chats/{id}",status="200"} 2`)
+	assert.Contains(t, string(body), `messanger_worker_run_duration_seconds_count{worker="thumbnails"} 1`)
+	assert.Contains(t, string(body), `messanger_worker_run_errors_total{worker="thumbnails"} 1`)
+	assert.Contains(t, string(body), `messanger_worker_interval_seconds{worker="thumbnails"} 30`)
+	assert.Contains(t, string(body), `messanger_worker_last_run_timestamp_seconds{worker="thumbnails"} 1.7e+09`)
+	assert.Contains(t, string(body), "messanger_chats_total 3")
+	assert.Contains(t, string(body), "messanger_messages_total 42")
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+type TokenParser interface {
+	ParseAccessToken(token string) (*domain.UserDomain, error)
+}
+
+// AuthMiddleware проверяет Bearer access-токен из заголовка Authorization и кладёт пользователя в контекст запроса
+func AuthMiddleware(tokens TokenParser) func(http.Handler) http.Handler {
+	return authMiddleware(tokens, false)
+}
+
+// StreamAuthMiddleware - AuthMiddleware для WebSocket и SSE: браузер не умеет ставить заголовки
+// при их открытии, поэтому токен принимается и из ?access_token=. Ставится только на маршруты потоков,
+// чтобы токены обычных запросов не попадали в логи доступа через URL
+func StreamAuthMiddleware(tokens TokenParser) func(http.Handler) http.Handler {
+	return authMiddleware(tokens, true)
+}
+
+func authMiddleware(tokens TokenParser, allowQueryToken bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, found := bearerToken(r, allowQueryToken)
+			if !found || strings.TrimSpace(token) == "" {
+				unauthorized(w, "требуется аутентификация")
+				return
+			}
+
+			user, err := tokens.ParseAccessToken(strings.TrimSpace(token))
+			if err != nil {
+				unauthorized(w, "некорректный или просроченный токен")
+				return
+			}
+
+			ctx := auth.WithUser(r.Context(), user)
+			ctx = logging.WithFields(ctx, zap.Int("user_id", user.ID))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken достаёт токен из заголовка Authorization, а если разрешено - из ?access_token=
+func bearerToken(r *http.Request, allowQueryToken bool) (string, bool) {
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		return token, true
+	}
+
+	if allowQueryToken {
+		token := r.URL.Query().Get("access_token")
+		return token, token != ""
+	}
+
+	return "", false
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
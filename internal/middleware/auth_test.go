@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testtask5/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTokens struct{}
+
+func (fakeTokens) ParseAccessToken(token string) (*domain.UserDomain, error) {
+	if token != "valid" {
+		return nil, domain.ErrInvalidToken
+	}
+	return &domain.UserDomain{ID: 1}, nil
+}
+
+func TestAuthMiddleware_QueryToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+		header     string
+		want       int
+	}{
+		{"заголовок", AuthMiddleware(fakeTokens{}), "Bearer valid", http.StatusOK},
+		{"токен в URL на обычном маршруте", AuthMiddleware(fakeTokens{}), "", http.StatusUnauthorized},
+		{"токен в URL на маршруте потока", StreamAuthMiddleware(fakeTokens{}), "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/chats/1/events?access_token=valid", nil)
+			//заголовок, по которому раньше угадывался поток, на решение влиять не должен
+			req.Header.Set("Accept", "text/event-stream")
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			tt.middleware(ok).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 )
 
+// TimeoutMiddleware ограничивает время обработки запроса. Ставится на маршруты, а не на весь роутер:
+// потоки событий и загрузка вложений живут дольше таймаута и регистрируются без него
 func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
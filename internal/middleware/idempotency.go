@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxIdempotencyKeyLength - ограничение колонки idempotency_keys.key
+	maxIdempotencyKeyLength = 255
+	// idempotencyMemoryBody - тело больше этого размера на время запроса уходит во временный файл
+	idempotencyMemoryBody = 1 << 20
+)
+
+type IdempotencyStore interface {
+	Begin(ctx context.Context, key string, scope string, requestHash string) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyMiddleware выполняет запрос с заголовком Idempotency-Key один раз, а на повторы
+// отдаёт сохранённый ответ. Запрос сравнивается по методу, пути и хэшу тела, поэтому тело
+// повтора должно совпадать байт в байт. Ответы 5xx не сохраняются, такой запрос можно повторить.
+// maxBodySize ограничивает тело, которое приходится прочитать целиком ради хэша
+func IdempotencyMiddleware(store IdempotencyStore, maxBodySize int64, appLogger *zap.Logger) func(http.Handler) http.Handler {
+	logger := appLogger.Named("idempotency")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, http.StatusBadRequest, "слишком длинный Idempotency-Key")
+				return
+			}
+
+			body, requestHash, err := spoolBody(w, r, maxBodySize)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeError(w, http.StatusRequestEntityTooLarge, "слишком большой запрос")
+					return
+				}
+				writeError(w, http.StatusBadRequest, "не удалось прочитать тело запроса")
+				return
+			}
+			defer body.Close()
+			r.Body = body
+
+			record, err := store.Begin(r.Context(), key, r.Method+" "+r.URL.Path, requestHash)
+			switch {
+			case errors.Is(err, domain.ErrIdempotencyReused):
+				writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key уже использован с другим запросом")
+				return
+			case errors.Is(err, domain.ErrIdempotencyBusy):
+				w.Header().Set("Retry-After", "1")
+				writeError(w, http.StatusConflict, "запрос с этим Idempotency-Key ещё выполняется")
+				return
+			case errors.Is(err, domain.ErrUnauthorized):
+				unauthorized(w, "требуется аутентификация")
+				return
+			case err != nil:
+				writeError(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+
+			if record != nil {
+				if record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.Body)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(rec, r)
+
+			//запрос мог упереться в таймаут, а ответ сохранить нужно
+			storeCtx := context.WithoutCancel(r.Context())
+			if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+				//ответа нет (клиент ушёл) или ошибка сервера - повтор должен выполниться заново
+				releaseKey(storeCtx, store, key, logger)
+				return
+			}
+			if err := store.Complete(storeCtx, key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+				//ответ не сохранился, и без освобождения ключа повторы получали бы 409 до истечения срока
+				logging.Logger(storeCtx, logger).Error("не удалось сохранить ответ по Idempotency-Key, ключ освобождается", zap.Error(err))
+				releaseKey(storeCtx, store, key, logger)
+			}
+		})
+	}
+}
+
+// releaseKey освобождает ключ. Если и это не удалось, ключ занят до истечения срока хранения
+func releaseKey(ctx context.Context, store IdempotencyStore, key string, logger *zap.Logger) {
+	if err := store.Release(ctx, key); err != nil {
+		logging.Logger(ctx, logger).Error("не удалось освободить Idempotency-Key", zap.Error(err))
+	}
+}
+
+// spoolBody читает тело целиком, считает его sha256 и возвращает копию тела для хэндлера
+func spoolBody(w http.ResponseWriter, r *http.Request, maxSize int64) (io.ReadCloser, string, error) {
+	hash := sha256.New()
+	body := io.TeeReader(http.MaxBytesReader(w, r.Body, maxSize), hash)
+
+	head, err := io.ReadAll(io.LimitReader(body, idempotencyMemoryBody+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(head) <= idempotencyMemoryBody {
+		return io.NopCloser(bytes.NewReader(head)), hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	file, err := os.CreateTemp("", "idempotency-body-*")
+	if err != nil {
+		return nil, "", err
+	}
+	spooled := &tempFileBody{File: file}
+
+	if _, err := file.Write(head); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, "", err
+	}
+
+	return spooled, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// tempFileBody удаляет временный файл при закрытии тела
+type tempFileBody struct {
+	*os.File
+}
+
+func (b *tempFileBody) Close() error {
+	b.File.Close()
+	return os.Remove(b.Name())
+}
+
+// responseRecorder пишет ответ клиенту и запоминает его для сохранения
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(p)
+	return rr.ResponseWriter.Write(p)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testtask5/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// brokenIdempotencyStore занимает ключ, но не может сохранить ответ
+type brokenIdempotencyStore struct {
+	released []string
+}
+
+func (s *brokenIdempotencyStore) Begin(ctx context.Context, key string, scope string, requestHash string) (*domain.IdempotencyRecord, error) {
+	return nil, nil
+}
+
+func (s *brokenIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	return errors.New("соединение с базой потеряно")
+}
+
+func (s *brokenIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.released = append(s.released, key)
+	return nil
+}
+
+func TestIdempotencyMiddleware_ReleasesKeyWhenResponseNotSaved(t *testing.T) {
+	store := &brokenIdempotencyStore{}
+	created := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/chats/", strings.NewReader(`{"title":"чат"}`))
+	req.Header.Set("Idempotency-Key", "k1")
+	rec := httptest.NewRecorder()
+	IdempotencyMiddleware(store, 1<<20, zap.NewNop())(created).ServeHTTP(rec, req)
+
+	//клиент получает ответ, а ключ освобождается, чтобы повтор не получал 409
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, []string{"k1"}, store.released)
+}
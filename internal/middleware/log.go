@@ -1,18 +1,29 @@
 package middleware
 
 import (
+	"bufio"
+	"errors"
+	"net"
 	"net/http"
+	"testtask5/internal/logging"
 	"time"
 
+	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 )
 
+type RequestObserver interface {
+	ObserveRequest(method string, route string, status int, duration time.Duration)
+}
+
 type ResponseWriterWrapper struct {
 	http.ResponseWriter
-	status int
+	status      int
+	wroteHeader bool
 }
 
-func LoggingMiddleWare(logger *zap.Logger) func(http.Handler) http.Handler {
+// LoggingMiddleWare логирует запрос и передаёт его длительность и статус в метрики
+func LoggingMiddleWare(logger *zap.Logger, observer RequestObserver) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -22,14 +33,55 @@ func LoggingMiddleWare(logger *zap.Logger) func(http.Handler) http.Handler {
 				status:         http.StatusOK,
 			}
 
-			logger.Info("request started", zap.String("method", r.Method), zap.String("url", r.URL.Path))
+			//ставится после RequestIDMiddleware, поэтому обе строки несут request_id
+			requestLogger := logging.Logger(r.Context(), logger).
+				With(zap.String("method", r.Method), zap.String("url", r.URL.Path))
+			requestLogger.Info("request started")
 
 			defer func() {
 				duration := time.Since(start)
-				logger.Info("request finished", zap.Int("status", rw.status), zap.Duration("duration", duration))
+				requestLogger.Info("request finished", zap.Int("status", rw.status), zap.Duration("duration", duration))
+				observer.ObserveRequest(r.Method, routePattern(r), rw.status, duration)
 			}()
 
 			next.ServeHTTP(rw, r)
 		})
 	}
 }
+
+func (rw *ResponseWriterWrapper) WriteHeader(status int) {
+	//повторные вызовы net/http игнорирует, статус ответа - первый
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// routePattern возвращает шаблон маршрута chi, который заполняется по ходу роутинга.
+// Запросы мимо всех маршрутов собираются в одну серию, чтобы сканеры не плодили метрики
+func routePattern(r *http.Request) string {
+	if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+		if pattern := routeCtx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return "unmatched"
+}
+
+// Hijack нужен для апгрейда соединения до WebSocket
+func (rw *ResponseWriterWrapper) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("ResponseWriter не поддерживает Hijack")
+	}
+	rw.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Flush нужен для потоковой отдачи text/event-stream
+func (rw *ResponseWriterWrapper) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
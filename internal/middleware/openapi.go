@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"testtask5/internal/logging"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"go.uber.org/zap"
+)
+
+// OpenAPIValidator проверяет запросы по спецификации OpenAPI до того, как они дойдут до хэндлеров.
+// В тестовом режиме проверяются и ответы: ответ, расходящийся со спецификацией, заменяется на 500,
+// чтобы тесты падали, как только код и документ разойдутся
+type OpenAPIValidator struct {
+	router            routers.Router
+	validateResponses bool
+	logger            *zap.Logger
+}
+
+func NewOpenAPIValidator(doc *openapi3.T, validateResponses bool, appLogger *zap.Logger) (*OpenAPIValidator, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось построить маршруты по спецификации: %w", err)
+	}
+
+	logger := appLogger.Named("openapi_validator")
+	return &OpenAPIValidator{
+		router:            router,
+		validateResponses: validateResponses,
+		logger:            logger,
+	}, nil
+}
+
+func (v *OpenAPIValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			//маршрута нет в спецификации - 404 или 405 ответит сам роутер
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				//токен проверяет AuthMiddleware, здесь только формат запроса
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				//файлы не читаются в память целиком, форму разбирает хэндлер
+				ExcludeRequestBody: isUploadRequest(r),
+			},
+		}
+		if err := v.validateRequest(input); err != nil {
+			logging.Logger(r.Context(), v.logger).Info("запрос не соответствует спецификации", zap.Error(err))
+			writeError(w, http.StatusBadRequest, "запрос не соответствует спецификации API: "+describeRequestError(err))
+			return
+		}
+		r.Body = input.Request.Body
+
+		//потоки событий отдаются частями, их ответ не буферизуется
+		if !v.validateResponses || isStreamingRoute(route) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buffered := &bufferedResponse{header: make(http.Header)}
+		next.ServeHTTP(buffered, r)
+		v.writeValidatedResponse(w, r, input, buffered)
+	})
+}
+
+// validateRequest проверяет запрос. Хэндлеры читают тело как JSON при любом Content-Type,
+// кроме multipart, поэтому так же проверяется и тело
+func (v *OpenAPIValidator) validateRequest(input *openapi3filter.RequestValidationInput) error {
+	r := input.Request
+	if body := input.Route.Operation.RequestBody; body != nil && body.Value != nil {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if body.Value.Content.Get(mediaType) == nil && body.Value.Content.Get("application/json") != nil {
+			input.Request = r.Clone(r.Context())
+			input.Request.Header.Set("Content-Type", "application/json")
+		}
+	}
+	return openapi3filter.ValidateRequest(r.Context(), input)
+}
+
+func (v *OpenAPIValidator) writeValidatedResponse(w http.ResponseWriter, r *http.Request, input *openapi3filter.RequestValidationInput, buffered *bufferedResponse) {
+	if buffered.status == 0 {
+		//хэндлер ничего не записал, net/http в этом случае отвечает 200
+		buffered.status = http.StatusOK
+	}
+
+	responseInput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 buffered.status,
+		Header:                 buffered.header,
+		Options: &openapi3filter.Options{
+			//бинарные файлы сверяются только по коду ответа и заголовкам
+			ExcludeResponseBody: !isJSONResponse(buffered.header),
+			//код ответа, которого нет в спецификации, - тоже расхождение
+			IncludeResponseStatus: true,
+		},
+	}
+	responseInput.SetBodyBytes(buffered.body.Bytes())
+	if err := openapi3filter.ValidateResponse(r.Context(), responseInput); err != nil {
+		logging.Logger(r.Context(), v.logger).Error("ответ не соответствует спецификации", zap.Int("status", buffered.status), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "ответ не соответствует спецификации API: "+err.Error())
+		return
+	}
+
+	for key, values := range buffered.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(buffered.status)
+	w.Write(buffered.body.Bytes())
+}
+
+// describeRequestError сокращает ошибку валидации до параметра или поля тела и причины,
+// без дампа схемы, который kin-openapi добавляет в Error()
+func describeRequestError(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error()
+	}
+
+	reason := requestErr.Reason
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		reason = schemaErr.Reason
+		if path := schemaErr.JSONPointer(); len(path) > 0 {
+			reason = strings.Join(path, ".") + ": " + reason
+		}
+	} else if requestErr.Err != nil {
+		reason = requestErr.Err.Error()
+	}
+
+	switch {
+	case requestErr.Parameter != nil:
+		return fmt.Sprintf("параметр %s: %s", requestErr.Parameter.Name, reason)
+	case requestErr.RequestBody != nil:
+		return "тело запроса: " + reason
+	default:
+		return reason
+	}
+}
+
+// isStreamingRoute - маршрут WebSocket (ответ 101) или потока text/event-stream
+func isStreamingRoute(route *routers.Route) bool {
+	for code, response := range route.Operation.Responses.Map() {
+		if code == "101" {
+			return true
+		}
+		if response.Value != nil && response.Value.Content.Get("text/event-stream") != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isUploadRequest - запрос с файлами в multipart/form-data
+func isUploadRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+func isJSONResponse(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// bufferedResponse придерживает ответ хэндлера, пока он не будет сверен со спецификацией
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(status int) {
+	if br.status == 0 {
+		br.status = status
+	}
+}
+
+func (br *bufferedResponse) Write(p []byte) (int, error) {
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+	return br.body.Write(p)
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testtask5/internal/openapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestValidator(t *testing.T, validateResponses bool) *OpenAPIValidator {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	validator, err := NewOpenAPIValidator(doc, validateResponses, zap.NewNop())
+	require.NoError(t, err)
+	return validator
+}
+
+func TestOpenAPIValidator_RejectsInvalidRequests(t *testing.T) {
+	validator := newTestValidator(t, false)
+	handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("невалидный запрос дошёл до хэндлера")
+	}))
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"пустое название чата", httptest.NewRequest(http.MethodPost, "/chats/", strings.NewReader(`{"title":""}`))},
+		{"нет обязательного поля", httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"alice"}`))},
+		{"ID чата не число", httptest.NewRequest(http.MethodGet, "/chats/abc", nil)},
+		{"неизвестный order", httptest.NewRequest(http.MethodGet, "/chats/?order=up", nil)},
+		{"поиск без q", httptest.NewRequest(http.MethodGet, "/search/messages", nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), "запрос не соответствует спецификации API")
+		})
+	}
+}
+
+func TestOpenAPIValidator_PassesValidRequestWithBody(t *testing.T) {
+	validator := newTestValidator(t, false)
+	body := `{"username":"alice","password":"password123"}`
+
+	var received string
+	handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	//хэндлеры читают JSON и без Content-Type, валидатор тоже
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, body, received, "тело должно дойти до хэндлера целиком")
+}
+
+func TestOpenAPIValidator_SkipsUndocumentedRoutes(t *testing.T) {
+	validator := newTestValidator(t, true)
+	handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestOpenAPIValidator_ValidatesResponses(t *testing.T) {
+	validator := newTestValidator(t, true)
+
+	t.Run("ответ по спецификации отдаётся как есть", func(t *testing.T) {
+		handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"ok"}`))
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+	})
+
+	t.Run("расхождение со спецификацией превращается в 500", func(t *testing.T) {
+		handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"state":"ok"}`))
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), "ответ не соответствует спецификации API")
+	})
+
+	t.Run("недокументированный код ответа - тоже расхождение", func(t *testing.T) {
+		handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error)
+}
+
+// RateLimiter ограничивает частоту запросов одного клиента. Клиент - это пользователь из access-токена,
+// а для запросов без аутентификации - IP-адрес
+type RateLimiter struct {
+	store      RateLimitStore
+	trustProxy bool
+	logger     *zap.Logger
+}
+
+// trustProxy - брать IP клиента из X-Forwarded-For. Включать только за своим прокси,
+// иначе клиент подставит в заголовок любой адрес и обойдёт лимит
+func NewRateLimiter(store RateLimitStore, trustProxy bool, appLogger *zap.Logger) *RateLimiter {
+	logger := appLogger.Named("rate_limiter")
+	return &RateLimiter{
+		store:      store,
+		trustProxy: trustProxy,
+		logger:     logger,
+	}
+}
+
+// Limit ограничивает запросы группы маршрутов. У каждой группы свои ведра, поэтому лимиты групп
+// не расходуют друг друга. Лимит с нулевым Burst выключен
+func (rl *RateLimiter) Limit(group string, limit domain.RateLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit.Burst <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := rl.store.Take(r.Context(), group+":"+rl.clientKey(r), limit, time.Now())
+			if err != nil {
+				//без хранилища лимитов сервис должен продолжать работать
+				logging.Logger(r.Context(), rl.logger).Error("не удалось проверить лимит запросов", zap.String("group", group), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", ceilSeconds(result.ResetAfter))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+				writeError(w, http.StatusTooManyRequests, "слишком много запросов")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AllowUser забирает токен из ведра пользователя в группе. Ведра те же, что у Limit, поэтому
+// вызовы по gRPC и запросы по HTTP расходуют один лимит. Выключенный лимит пропускает всё
+func (rl *RateLimiter) AllowUser(ctx context.Context, group string, limit domain.RateLimit, userID int) (domain.RateLimitResult, error) {
+	if limit.Burst <= 0 {
+		return domain.RateLimitResult{Allowed: true}, nil
+	}
+	return rl.store.Take(ctx, group+":"+userKey(userID), limit, time.Now())
+}
+
+func (rl *RateLimiter) clientKey(r *http.Request) string {
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		return userKey(user.ID)
+	}
+	return "ip:" + rl.clientIP(r)
+}
+
+func userKey(userID int) string {
+	return "user:" + strconv.Itoa(userID)
+}
+
+// clientIP берёт последний адрес из X-Forwarded-For: его дописал наш прокси, а остальные мог подставить клиент
+func (rl *RateLimiter) clientIP(r *http.Request) string {
+	if rl.trustProxy {
+		forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+		if ip := strings.TrimSpace(forwarded[len(forwarded)-1]); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds округляет вверх: клиент, подождавший Retry-After секунд, должен пройти
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
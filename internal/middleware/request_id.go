@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+	"testtask5/internal/logging"
+)
+
+// RequestIDMiddleware берёт ID запроса из X-Request-ID или генерирует новый, кладёт его в контекст
+// для логов всех слоёв и возвращает клиенту в том же заголовке
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := logging.RequestIDOrNew(r.Header.Get("X-Request-ID"))
+
+		w.Header().Set("X-Request-ID", requestID)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
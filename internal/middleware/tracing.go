@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"testtask5/internal/logging"
+	"testtask5/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware открывает серверный спан на запрос. Если клиент прислал traceparent,
+// спан продолжает его трейс. Ставится после RequestIDMiddleware и до LoggingMiddleWare,
+// чтобы логи запроса несли trace_id
+func TracingMiddleware(next http.Handler) http.Handler {
+	tracer := tracing.Tracer("http")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if requestID, ok := logging.RequestIDFromContext(ctx); ok {
+			span.SetAttributes(attribute.String("request_id", requestID))
+		}
+
+		rw := &ResponseWriterWrapper{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		//шаблон маршрута известен только после роутинга
+		route := routePattern(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", rw.status),
+		)
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type Attachment struct {
+	ID                 int `gorm:"primaryKey;autoIncrement"`
+	MessageID          *int
+	StorageKey         string `gorm:"size:255;not null;unique"`
+	FileName           string `gorm:"size:255;not null"`
+	ContentType        string `gorm:"size:127;not null"`
+	SizeBytes          int64  `gorm:"not null"`
+	CreatedAt          time.Time
+	Width              *int
+	Height             *int
+	ThumbnailStatus    string `gorm:"size:16;not null;default:none"`
+	ThumbnailAttempts  int    `gorm:"not null;default:0"`
+	ThumbnailClaimedAt *time.Time
+	Thumbnails         []*AttachmentThumbnail `gorm:"foreignKey:AttachmentID"`
+}
+
+type AttachmentThumbnail struct {
+	ID           int    `gorm:"primaryKey;autoIncrement"`
+	AttachmentID int    `gorm:"not null"`
+	MaxSide      int    `gorm:"not null"`
+	Width        int    `gorm:"not null"`
+	Height       int    `gorm:"not null"`
+	ContentType  string `gorm:"size:127;not null"`
+	SizeBytes    int64  `gorm:"not null"`
+	StorageKey   string `gorm:"size:255;not null;unique"`
+	CreatedAt    time.Time
+}
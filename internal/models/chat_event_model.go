@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type ChatEvent struct {
+	ID        int64 `gorm:"primaryKey;autoIncrement"`
+	ChatID    int
+	Type      string `gorm:"size:32;not null"`
+	Payload   []byte `gorm:"type:jsonb;not null"`
+	CreatedAt time.Time
+}
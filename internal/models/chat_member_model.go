@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type ChatMember struct {
+	ChatID    int    `gorm:"primaryKey"`
+	UserID    int    `gorm:"primaryKey"`
+	Role      string `gorm:"size:16;not null"`
+	CreatedAt time.Time
+}
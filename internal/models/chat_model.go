@@ -3,7 +3,9 @@ package models
 import "time"
 
 type Chat struct {
-	ID        int    `gorm:"primaryKey;autoIncrement"`
-	Title     string `gorm:"size:200;not null"`
-	CreatedAt time.Time
+	ID         int    `gorm:"primaryKey;autoIncrement"`
+	Title      string `gorm:"size:200;not null"`
+	Version    int    `gorm:"not null;default:1"`
+	CreatedAt  time.Time
+	ArchivedAt *time.Time
 }
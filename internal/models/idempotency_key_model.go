@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type IdempotencyKey struct {
+	UserID       int    `gorm:"primaryKey;autoIncrement:false"`
+	Key          string `gorm:"primaryKey;size:255"`
+	Scope        string `gorm:"size:255;not null"`
+	RequestHash  string `gorm:"size:64;not null"`
+	StatusCode   *int
+	ContentType  string `gorm:"size:127;not null"`
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
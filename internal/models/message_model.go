@@ -3,8 +3,13 @@ package models
 import "time"
 
 type Message struct {
-	ID        int `gorm:"primaryKey;autoIncrement"`
-	ChatID    int
-	Text      string `gorm:"size:5000;not null"`
-	CreatedAt time.Time
+	ID              int `gorm:"primaryKey;autoIncrement"`
+	ChatID          int
+	Seq             int64 `gorm:"not null"`
+	ParentMessageID *int
+	AuthorID        *int
+	Text            string `gorm:"size:5000;not null"`
+	CreatedAt       time.Time
+	EditedAt        *time.Time
+	DeletedAt       *time.Time
 }
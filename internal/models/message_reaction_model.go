@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type MessageReaction struct {
+	ID        int `gorm:"primaryKey;autoIncrement"`
+	MessageID int
+	Reactor   string `gorm:"size:100;not null"`
+	Emoji     string `gorm:"size:32;not null"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type MessageRevision struct {
+	ID        int `gorm:"primaryKey;autoIncrement"`
+	MessageID int
+	Text      string `gorm:"size:5000;not null"`
+	CreatedAt time.Time
+}
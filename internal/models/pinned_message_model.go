@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type PinnedMessage struct {
+	ChatID    int `gorm:"primaryKey"`
+	MessageID int `gorm:"primaryKey"`
+	PinnedBy  *int
+	PinnedAt  time.Time
+	Message   *Message `gorm:"foreignKey:MessageID"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type RateLimitBucket struct {
+	Key        string `gorm:"primaryKey;size:255"`
+	Tokens     float64
+	RefilledAt time.Time
+}
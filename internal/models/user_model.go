@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type User struct {
+	ID           int    `gorm:"primaryKey;autoIncrement"`
+	Username     string `gorm:"size:50;not null;unique"`
+	PasswordHash string `gorm:"size:100;not null"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,28 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// spec - описание HTTP API. Документ вшит в бинарник, чтобы отдаваемая клиентам спецификация
+// и спецификация, по которой проверяются запросы, всегда совпадали с кодом этой сборки
+//
+//go:embed openapi.yaml
+var spec []byte
+
+// Load разбирает и проверяет вшитую спецификацию
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать спецификацию OpenAPI: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("спецификация OpenAPI некорректна: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,107 @@
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testtask5/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	require.NoError(t, err)
+	assert.NotEmpty(t, doc.Paths.Map())
+}
+
+// Схемы в спецификации должны совпадать с DTO по набору полей. У ответов, кроме того,
+// обязательны ровно те поля, которые сериализуются без omitempty
+func TestSchemas_MatchDTOs(t *testing.T) {
+	doc, err := Load()
+	require.NoError(t, err)
+
+	cases := []struct {
+		schema   string
+		dto      any
+		response bool
+	}{
+		{"RegisterRequest", dto.RegisterRequest{}, false},
+		{"LoginRequest", dto.LoginRequest{}, false},
+		{"RefreshRequest", dto.RefreshRequest{}, false},
+		{"UserResponse", dto.UserResponse{}, true},
+		{"TokenResponse", dto.TokenResponse{}, true},
+		{"CreateChatRequest", dto.CreateChatRequest{}, false},
+		{"UpdateChatRequest", dto.UpdateChatRequest{}, false},
+		{"CreateChatResponse", dto.CreateChatResponse{}, true},
+		{"ChatListItem", dto.ChatListItem{}, true},
+		{"ListChatsResponse", dto.ListChatsResponse{}, true},
+		{"DeleteChatResponse", dto.DeleteChatResponse{}, true},
+		{"CreateMessageRequest", dto.CreateMessageRequest{}, false},
+		{"UpdateMessageRequest", dto.UpdateMessageRequest{}, false},
+		{"MessageResponse", dto.MessageResponse{}, true},
+		{"AttachmentResponse", dto.AttachmentResponse{}, true},
+		{"ThumbnailResponse", dto.ThumbnailResponse{}, true},
+		{"MessageRevisionResponse", dto.MessageRevisionResponse{}, true},
+		{"ThreadResponse", dto.ThreadResponse{}, true},
+		{"MessageChangeResponse", dto.MessageChangeResponse{}, true},
+		{"ChangesResponse", dto.ChangesResponse{}, true},
+		{"ReactionRequest", dto.ReactionRequest{}, false},
+		{"ReactionCountResponse", dto.ReactionCountResponse{}, true},
+		{"ReactionsResponse", dto.ReactionsResponse{}, true},
+		{"PinResponse", dto.PinResponse{}, true},
+		{"AddMemberRequest", dto.AddMemberRequest{}, false},
+		{"ChangeMemberRoleRequest", dto.ChangeMemberRoleRequest{}, false},
+		{"TransferOwnershipRequest", dto.TransferOwnershipRequest{}, false},
+		{"MemberResponse", dto.MemberResponse{}, true},
+		{"MessageSearchHit", dto.MessageSearchHit{}, true},
+		{"SearchMessagesResponse", dto.SearchMessagesResponse{}, true},
+		{"ChatEventResponse", dto.ChatEventResponse{}, true},
+		{"HealthResponse", dto.HealthResponse{}, true},
+		{"ReadinessResponse", dto.ReadinessResponse{}, true},
+		{"HealthCheckResponse", dto.HealthCheckResponse{}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.schema, func(t *testing.T) {
+			ref, ok := doc.Components.Schemas[tc.schema]
+			require.True(t, ok, "схема отсутствует в спецификации")
+			schema := ref.Value
+
+			fields, always := jsonFields(reflect.TypeOf(tc.dto))
+
+			properties := make([]string, 0, len(schema.Properties))
+			for name := range schema.Properties {
+				properties = append(properties, name)
+			}
+			sort.Strings(properties)
+			assert.Equal(t, fields, properties)
+
+			if tc.response {
+				required := append([]string(nil), schema.Required...)
+				sort.Strings(required)
+				assert.Equal(t, always, required)
+			}
+		})
+	}
+}
+
+// jsonFields возвращает имена полей в JSON и те из них, что сериализуются всегда
+func jsonFields(typ reflect.Type) ([]string, []string) {
+	var fields, always []string
+	for i := 0; i < typ.NumField(); i++ {
+		name, opts, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+		if !strings.Contains(opts, "omitempty") {
+			always = append(always, name)
+		}
+	}
+	sort.Strings(fields)
+	sort.Strings(always)
+	return fields, always
+}
@@ -0,0 +1,134 @@
+package realtime
+
+import (
+	"sync"
+	"testtask5/internal/domain"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Subscription - подписка пользователя на события одного чата. Канал Events закрывается,
+// когда подписчик отписан, отстал от потока, исключён из чата, у него истёк токен или брокер остановлен
+type Subscription struct {
+	ChatID int
+	UserID int
+	Events <-chan *domain.ChatEvent
+
+	events chan *domain.ChatEvent
+	expiry *time.Timer
+	once   sync.Once
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		if s.expiry != nil {
+			s.expiry.Stop()
+		}
+		close(s.events)
+	})
+}
+
+// Broadcaster - in-process брокер событий чатов. Публикация никогда не блокируется:
+// подписчик, чей буфер переполнен, отключается, и клиенту нужно перезапросить историю
+type Broadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[int]map[*Subscription]struct{}
+	bufferSize  int
+	closed      bool
+
+	logger *zap.Logger
+}
+
+func NewBroadcaster(bufferSize int, appLogger *zap.Logger) *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[int]map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+		logger:      appLogger.Named("broadcaster"),
+	}
+}
+
+// Subscribe подписывает пользователя на события чата. Подписка живёт не дольше expiresAt - срока
+// токена, с которым она открыта, нулевое время - без срока. После остановки брокера возвращается уже закрытая подписка
+func (b *Broadcaster) Subscribe(chatID int, userID int, expiresAt time.Time) *Subscription {
+	events := make(chan *domain.ChatEvent, b.bufferSize)
+	sub := &Subscription{ChatID: chatID, UserID: userID, Events: events, events: events}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		sub.close()
+		return sub
+	}
+
+	if b.subscribers[chatID] == nil {
+		b.subscribers[chatID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[chatID][sub] = struct{}{}
+
+	if !expiresAt.IsZero() {
+		sub.expiry = time.AfterFunc(time.Until(expiresAt), func() { b.Unsubscribe(sub) })
+	}
+
+	return sub
+}
+
+// Unsubscribe отписывает и закрывает канал подписки, повторный вызов безопасен
+func (b *Broadcaster) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	b.remove(sub)
+	b.mu.Unlock()
+
+	sub.close()
+}
+
+// Publish рассылает событие всем подписчикам чата. Подписки исключённого участника
+// вместо member.removed закрываются: событий чата он больше видеть не должен
+func (b *Broadcaster) Publish(event *domain.ChatEvent) {
+	var slow, removed []*Subscription
+
+	b.mu.RLock()
+	for sub := range b.subscribers[event.ChatID] {
+		if event.Type == domain.EventMemberRemoved && event.MemberID != nil && *event.MemberID == sub.UserID {
+			removed = append(removed, sub)
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range slow {
+		b.logger.Warn("подписчик не успевает читать события, отключаем", zap.Int("chat_id", sub.ChatID))
+		b.Unsubscribe(sub)
+	}
+	for _, sub := range removed {
+		b.Unsubscribe(sub)
+	}
+}
+
+// Close закрывает все подписки, новые подписки сразу закрываются
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for chatID, subs := range b.subscribers {
+		for sub := range subs {
+			sub.close()
+		}
+		delete(b.subscribers, chatID)
+	}
+}
+
+func (b *Broadcaster) remove(sub *Subscription) {
+	subs := b.subscribers[sub.ChatID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.ChatID)
+	}
+}
@@ -0,0 +1,90 @@
+package realtime
+
+import (
+	"testing"
+	"testtask5/internal/domain"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestBroadcaster_Publish(t *testing.T) {
+	t.Run("событие получают только подписчики чата", func(t *testing.T) {
+		b := NewBroadcaster(4, zap.NewNop())
+		sub := b.Subscribe(1, 1, time.Time{})
+		other := b.Subscribe(2, 1, time.Time{})
+		event := &domain.ChatEvent{Type: domain.EventMessageCreated, ChatID: 1}
+
+		b.Publish(event)
+
+		assert.Equal(t, event, <-sub.Events)
+		assert.Empty(t, other.Events)
+	})
+
+	t.Run("отстающий подписчик отключается, публикация не блокируется", func(t *testing.T) {
+		b := NewBroadcaster(1, zap.NewNop())
+		sub := b.Subscribe(1, 1, time.Time{})
+
+		b.Publish(&domain.ChatEvent{ChatID: 1})
+		b.Publish(&domain.ChatEvent{ChatID: 1})
+
+		<-sub.Events
+		_, ok := <-sub.Events
+		assert.False(t, ok)
+	})
+
+	t.Run("повторная отписка безопасна", func(t *testing.T) {
+		b := NewBroadcaster(1, zap.NewNop())
+		sub := b.Subscribe(1, 1, time.Time{})
+
+		b.Unsubscribe(sub)
+		b.Unsubscribe(sub)
+		b.Publish(&domain.ChatEvent{ChatID: 1})
+
+		_, ok := <-sub.Events
+		assert.False(t, ok)
+	})
+}
+
+func TestBroadcaster_RevokesSubscriptions(t *testing.T) {
+	t.Run("member.removed закрывает подписки исключённого, остальные получают событие", func(t *testing.T) {
+		b := NewBroadcaster(4, zap.NewNop())
+		removedSub := b.Subscribe(1, 7, time.Time{})
+		other := b.Subscribe(1, 8, time.Time{})
+		memberID := 7
+		event := &domain.ChatEvent{Type: domain.EventMemberRemoved, ChatID: 1, MemberID: &memberID}
+
+		b.Publish(event)
+
+		_, ok := <-removedSub.Events
+		assert.False(t, ok)
+		assert.Equal(t, event, <-other.Events)
+	})
+
+	t.Run("подписка закрывается, когда истекает токен", func(t *testing.T) {
+		b := NewBroadcaster(1, zap.NewNop())
+		sub := b.Subscribe(1, 7, time.Now().Add(10*time.Millisecond))
+
+		select {
+		case _, ok := <-sub.Events:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("подписка с истёкшим токеном не закрылась")
+		}
+	})
+}
+
+func TestBroadcaster_Close(t *testing.T) {
+	b := NewBroadcaster(1, zap.NewNop())
+	sub := b.Subscribe(1, 1, time.Time{})
+
+	b.Close()
+
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+
+	late := b.Subscribe(1, 1, time.Time{})
+	_, ok = <-late.Events
+	assert.False(t, ok)
+}
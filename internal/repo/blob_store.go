@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore - хранилище содержимого вложений. Если объекта нет, Get возвращает domain.ErrAttachmentNotFound,
+// а Delete не считает это ошибкой
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,93 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testtask5/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// LocalBlobStore хранит объекты файлами в каталоге на диске
+type LocalBlobStore struct {
+	root   string
+	logger *zap.Logger
+}
+
+func NewLocalBlobStore(root string, appLogger *zap.Logger) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("не удалось создать каталог хранилища: %w", err)
+	}
+
+	return &LocalBlobStore{
+		root:   root,
+		logger: appLogger.Named("local_blob_store"),
+	}, nil
+}
+
+// Put пишет объект во временный файл и переименовывает его, чтобы читатели не увидели недописанный файл
+func (ls *LocalBlobStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (ls *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	return file, err
+}
+
+// Delete удаляет объект, отсутствие объекта ошибкой не считается
+func (ls *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path переводит ключ в путь внутри корня хранилища и не даёт выйти за его пределы
+func (ls *LocalBlobStore) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("некорректный ключ объекта: %q", key)
+	}
+	return filepath.Join(ls.root, filepath.FromSlash(key)), nil
+}
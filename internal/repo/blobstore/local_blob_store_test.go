@@ -0,0 +1,47 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testtask5/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("запись, чтение и удаление", func(t *testing.T) {
+		store, err := NewLocalBlobStore(t.TempDir(), zap.NewNop())
+		require.NoError(t, err)
+
+		require.NoError(t, store.Put(ctx, "ab/cd/file", strings.NewReader("hello"), 5, "text/plain"))
+
+		reader, err := store.Get(ctx, "ab/cd/file")
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(content))
+
+		require.NoError(t, store.Delete(ctx, "ab/cd/file"))
+		_, err = store.Get(ctx, "ab/cd/file")
+		assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+
+		//повторное удаление не ошибка
+		assert.NoError(t, store.Delete(ctx, "ab/cd/file"))
+	})
+
+	t.Run("ключ не может выйти за пределы каталога", func(t *testing.T) {
+		store, err := NewLocalBlobStore(t.TempDir(), zap.NewNop())
+		require.NoError(t, err)
+
+		assert.Error(t, store.Put(ctx, "../escape", strings.NewReader("x"), 1, "text/plain"))
+		_, err = store.Get(ctx, "/etc/passwd")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,80 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testtask5/internal/domain"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+)
+
+type S3Config struct {
+	Endpoint  string // host:port без схемы
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Region    string
+	UseSSL    bool
+}
+
+// S3BlobStore хранит объекты в S3-совместимом хранилище (AWS S3, MinIO и т.п.)
+type S3BlobStore struct {
+	client *minio.Client
+	bucket string
+	logger *zap.Logger
+}
+
+func NewS3BlobStore(cfg S3Config, appLogger *zap.Logger) (*S3BlobStore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("не задан бакет S3")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		//регион задан явно, чтобы клиент не ходил за ним отдельным запросом
+		Region:       cfg.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать клиент S3: %w", err)
+	}
+
+	return &S3BlobStore{
+		client: client,
+		bucket: cfg.Bucket,
+		logger: appLogger.Named("s3_blob_store"),
+	}, nil
+}
+
+func (ss *S3BlobStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	_, err := ss.client.PutObject(ctx, ss.bucket, key, content, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+// Get проверяет наличие объекта сразу: minio-клиент откладывает запрос до первого чтения
+func (ss *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := ss.client.GetObject(ctx, ss.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+			return nil, domain.ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (ss *S3BlobStore) Delete(ctx context.Context, key string) error {
+	return ss.client.RemoveObject(ctx, ss.bucket, key, minio.RemoveObjectOptions{})
+}
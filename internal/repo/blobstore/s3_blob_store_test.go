@@ -0,0 +1,132 @@
+package blobstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testtask5/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeS3 - минимальная замена MinIO: path-style PUT/GET/HEAD/DELETE объектов в памяти
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readS3Body(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// readS3Body снимает aws-chunked кодирование, которым клиент подписывает тело без TLS
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var body bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body.Bytes(), nil
+		}
+		if _, err := io.CopyN(&body, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func TestS3BlobStore(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := NewS3BlobStore(S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Bucket:    "attachments",
+		AccessKey: "minio",
+		SecretKey: "minio123",
+		Region:    "us-east-1",
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "ab/file.txt", strings.NewReader("hello s3"), 8, "text/plain"))
+	assert.Equal(t, "hello s3", string(fake.objects["attachments/ab/file.txt"]))
+	assert.Equal(t, "text/plain", fake.types["attachments/ab/file.txt"])
+
+	reader, err := store.Get(ctx, "ab/file.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello s3", string(content))
+
+	require.NoError(t, store.Delete(ctx, "ab/file.txt"))
+	_, err = store.Get(ctx, "ab/file.txt")
+	assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+}
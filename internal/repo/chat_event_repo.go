@@ -0,0 +1,11 @@
+package repo
+
+import (
+	"context"
+	"testtask5/internal/domain"
+)
+
+type ChatEventRepository interface {
+	SaveEvent(ctx context.Context, event *domain.ChatEvent) error
+	ListEventsAfter(ctx context.Context, chatID int, afterID int64, limit int) ([]*domain.ChatEvent, error)
+}
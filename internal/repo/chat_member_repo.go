@@ -0,0 +1,15 @@
+package repo
+
+import (
+	"context"
+	"testtask5/internal/domain"
+)
+
+type ChatMemberRepository interface {
+	AddMember(ctx context.Context, member *domain.ChatMemberDomain) (*domain.ChatMemberDomain, error)
+	FindMember(ctx context.Context, chatID int, userID int) (*domain.ChatMemberDomain, error)
+	ListMembers(ctx context.Context, chatID int) ([]*domain.ChatMemberDomain, error)
+	UpdateMemberRole(ctx context.Context, chatID int, userID int, role domain.ChatRole) (*domain.ChatMemberDomain, error)
+	RemoveMember(ctx context.Context, chatID int, userID int) error
+	TransferOwnership(ctx context.Context, chatID int, fromUserID int, toUserID int) error
+}
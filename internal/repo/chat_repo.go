@@ -3,12 +3,20 @@ package repo
 import (
 	"context"
 	"testtask5/internal/domain"
+	"time"
 )
 
+// Изменяющие методы принимают ожидаемую версию чата: если она задана и не совпадает
+// с текущей, возвращается ErrVersionMismatch. version = 0 - без проверки
 type ChatRepostiory interface {
 	CreateChat(ctx context.Context, data *domain.ChatDomain) (*domain.ChatDomain, error)
 	FindChatById(ctx context.Context, data *domain.ChatDomain) (*domain.ChatDomain, error)
 	ChatExists(ctx context.Context, param FilterParam) (bool, error)
-	DeleteChat(ctx context.Context, chatID int) error
+	ListChats(ctx context.Context, params ListParam) (*domain.ChatList, error)
+	UpdateChatTitle(ctx context.Context, chatID int, title string, version int) (*domain.ChatDomain, error)
+	ArchiveChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error)
+	RestoreChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error)
+	DeleteChat(ctx context.Context, chatID int, version int) error
+	PurgeArchivedChats(ctx context.Context, archivedBefore time.Time, limit int) (int64, error)
 	Count(ctx context.Context) int64
 }
@@ -1,6 +1,50 @@
 package repo
 
+import "time"
+
+// Операторы фильтрации, пустой оператор равносилен FilterEq
+const (
+	FilterEq       = "eq"
+	FilterPrefix   = "prefix"
+	FilterContains = "contains"
+	FilterGte      = "gte"
+	FilterLte      = "lte"
+)
+
 type FilterParam struct {
-	Field string
-	Value any
+	Field    string
+	Value    any
+	Operator string
+}
+
+// CursorParam описывает keyset-пагинацию по id сообщения.
+// Before - сообщения старше указанного id, After - новее. Одновременно задаётся не больше одного.
+type CursorParam struct {
+	Before int
+	After  int
+	Limit  int
+}
+
+// ListParam описывает выборку списка с фильтрами, сортировкой и keyset-пагинацией.
+// Cursor - непрозрачная строка из предыдущей страницы.
+type ListParam struct {
+	MemberID  int  // если задан - только чаты, где пользователь состоит
+	Archived  bool // true - только архивные чаты, иначе только активные
+	Filters   []FilterParam
+	SortField string
+	SortDesc  bool
+	Cursor    string
+	Limit     int
+}
+
+// SearchParam описывает полнотекстовый поиск по сообщениям.
+// ChatID = 0 - поиск по всем чатам, From/To - необязательный диапазон created_at.
+type SearchParam struct {
+	Query    string
+	ChatID   int
+	MemberID int // если задан - только по чатам, где пользователь состоит
+	From     *time.Time
+	To       *time.Time
+	Cursor   string
+	Limit    int
 }
@@ -0,0 +1,15 @@
+package repo
+
+import (
+	"context"
+	"testtask5/internal/domain"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Reserve сохраняет ключ за запросом. Если живой ключ уже есть, возвращает его запись и false
+	Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, record *domain.IdempotencyRecord) error
+	Release(ctx context.Context, userID int, key string) error
+	PurgeExpired(ctx context.Context, now time.Time) (int64, error)
+}
@@ -3,11 +3,27 @@ package repo
 import (
 	"context"
 	"testtask5/internal/domain"
+	"time"
 )
 
 type MessageRepostiory interface {
 	CreateMessage(ctx context.Context, data *domain.MessageDomain) (*domain.MessageDomain, error)
-	GetMessagesByChaWithLimit(ctx context.Context, chatID int, limit int) []*domain.MessageDomain
+	FindMessageById(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error)
+	GetMessagesByChatWithCursor(ctx context.Context, chatID int, cursor CursorParam) (*domain.MessagePage, error)
+	GetThreadWithCursor(ctx context.Context, chatID int, rootID int, cursor CursorParam) (*domain.MessagePage, error)
+	UpdateMessageText(ctx context.Context, data *domain.MessageDomain) (*domain.MessageDomain, error)
+	GetMessageRevisions(ctx context.Context, messageID int) ([]*domain.MessageRevisionDomain, error)
+	SoftDeleteMessage(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error)
+	RestoreMessage(ctx context.Context, chatID int, messageID int, deletedAfter time.Time) (*domain.MessageDomain, error)
+	FindAttachment(ctx context.Context, chatID int, messageID int, attachmentID int) (*domain.AttachmentDomain, error)
+	ListOrphanAttachments(ctx context.Context, limit int) ([]*domain.AttachmentDomain, error)
+	DeleteAttachments(ctx context.Context, attachmentIDs []int) error
+	GetChangesSince(ctx context.Context, chatID int, sinceSeq int64, limit int) (*domain.MessageChanges, error)
+	PurgeDeletedMessages(ctx context.Context, deletedBefore time.Time) (int64, error)
+	AddReaction(ctx context.Context, reaction *domain.ReactionDomain) error
+	RemoveReaction(ctx context.Context, reaction *domain.ReactionDomain) error
+	GetReactionCounts(ctx context.Context, messageIDs []int) (map[int][]domain.ReactionCount, error)
+	SearchMessages(ctx context.Context, params SearchParam) (*domain.MessageSearchResult, error)
 	DeleteMessages(ctx context.Context, chatID int) error
 	Count(ctx context.Context) int64
 }
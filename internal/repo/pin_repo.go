@@ -0,0 +1,13 @@
+package repo
+
+import (
+	"context"
+	"testtask5/internal/domain"
+)
+
+type PinRepository interface {
+	// PinMessage закрепляет сообщение, если в чате закреплено меньше limit сообщений
+	PinMessage(ctx context.Context, pin *domain.PinDomain, limit int) (*domain.PinDomain, error)
+	UnpinMessage(ctx context.Context, chatID int, messageID int) error
+	ListPinned(ctx context.Context, chatID int) ([]*domain.PinDomain, error)
+}
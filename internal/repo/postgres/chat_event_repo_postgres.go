@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ChatEventRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewChatEventRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *ChatEventRepoPostgres {
+	dbLogger := appLogger.Named("chat_event_db")
+	return &ChatEventRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+// chatEventPayload - снимок изменённого сообщения или чата на момент события
+type chatEventPayload struct {
+	Message  *eventMessagePayload `json:"message,omitempty"`
+	Title    string               `json:"title,omitempty"`
+	ActorID  *int                 `json:"actor_id,omitempty"`
+	MemberID *int                 `json:"member_id,omitempty"`
+}
+
+type eventMessagePayload struct {
+	ID              int        `json:"id"`
+	Seq             int64      `json:"seq"`
+	ParentMessageID *int       `json:"parent_message_id,omitempty"`
+	AuthorID        *int       `json:"author_id,omitempty"`
+	Text            string     `json:"text"`
+	CreatedAt       time.Time  `json:"created_at"`
+	EditedAt        *time.Time `json:"edited_at,omitempty"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+// SaveEvent записывает событие в журнал и проставляет ему ID
+func (er *ChatEventRepoPostgres) SaveEvent(ctx context.Context, event *domain.ChatEvent) error {
+	payload, err := json.Marshal(eventToPayload(event))
+	if err != nil {
+		return err
+	}
+
+	eventModel := &models.ChatEvent{
+		ChatID:    event.ChatID,
+		Type:      string(event.Type),
+		Payload:   payload,
+		CreatedAt: event.OccurredAt,
+	}
+
+	//блокировка на чат держится до коммита, поэтому события одного чата
+	//становятся видны строго в порядке id и при повторе ничего не пропускается
+	err = er.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?), ?)", "chat_events", event.ChatID).Error; err != nil {
+			return err
+		}
+		return tx.Create(eventModel).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	event.ID = eventModel.ID
+	return nil
+}
+
+// ListEventsAfter возвращает события чата с id больше afterID в порядке возрастания
+func (er *ChatEventRepoPostgres) ListEventsAfter(ctx context.Context, chatID int, afterID int64, limit int) ([]*domain.ChatEvent, error) {
+	var eventModels []*models.ChatEvent
+
+	err := er.db.WithContext(ctx).
+		Where("chat_id = ? AND id > ?", chatID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&eventModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*domain.ChatEvent, 0, len(eventModels))
+	for _, el := range eventModels {
+		event, err := eventModelToDomain(el)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func eventToPayload(event *domain.ChatEvent) *chatEventPayload {
+	payload := &chatEventPayload{ActorID: event.ActorID, MemberID: event.MemberID}
+
+	if m := event.Message; m != nil {
+		payload.Message = &eventMessagePayload{
+			ID:              m.ID,
+			Seq:             m.Seq,
+			ParentMessageID: m.ParentMessageID,
+			AuthorID:        m.AuthorID,
+			Text:            m.Text,
+			CreatedAt:       m.CreatedAt,
+			EditedAt:        m.EditedAt,
+			DeletedAt:       m.DeletedAt,
+		}
+	}
+	if event.Chat != nil {
+		payload.Title = event.Chat.Title
+	}
+
+	return payload
+}
+
+func eventModelToDomain(el *models.ChatEvent) (*domain.ChatEvent, error) {
+	var payload chatEventPayload
+	if err := json.Unmarshal(el.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	event := &domain.ChatEvent{
+		ID:         el.ID,
+		Type:       domain.ChatEventType(el.Type),
+		ChatID:     el.ChatID,
+		ActorID:    payload.ActorID,
+		MemberID:   payload.MemberID,
+		OccurredAt: el.CreatedAt,
+	}
+
+	if m := payload.Message; m != nil {
+		event.Message = &domain.MessageDomain{
+			ID:              m.ID,
+			ChatID:          el.ChatID,
+			Seq:             m.Seq,
+			ParentMessageID: m.ParentMessageID,
+			AuthorID:        m.AuthorID,
+			Text:            m.Text,
+			CreatedAt:       m.CreatedAt,
+			EditedAt:        m.EditedAt,
+			DeletedAt:       m.DeletedAt,
+		}
+	}
+	if strings.HasPrefix(el.Type, "chat.") {
+		event.Chat = &domain.ChatDomain{ID: el.ChatID, Title: payload.Title}
+	}
+
+	return event, nil
+}
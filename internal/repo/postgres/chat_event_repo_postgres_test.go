@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"encoding/json"
+	"testing"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// событие из журнала должно выглядеть так же, как то, что ушло подписчикам вживую
+func TestChatEventPayload_RoundTrip(t *testing.T) {
+	actorID, memberID := 1, 2
+	event := &domain.ChatEvent{
+		Type:     domain.EventMemberRemoved,
+		ChatID:   10,
+		ActorID:  &actorID,
+		MemberID: &memberID,
+	}
+
+	payload, err := json.Marshal(eventToPayload(event))
+	require.NoError(t, err)
+
+	occurredAt := time.Now()
+	restored, err := eventModelToDomain(&models.ChatEvent{
+		ID:        5,
+		ChatID:    event.ChatID,
+		Type:      string(event.Type),
+		Payload:   payload,
+		CreatedAt: occurredAt,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &domain.ChatEvent{
+		ID:         5,
+		Type:       domain.EventMemberRemoved,
+		ChatID:     10,
+		ActorID:    &actorID,
+		MemberID:   &memberID,
+		OccurredAt: occurredAt,
+	}, restored)
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ChatMemberRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewChatMemberRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *ChatMemberRepoPostgres {
+	dbLogger := appLogger.Named("chat_member_db")
+	return &ChatMemberRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+func (mr *ChatMemberRepoPostgres) AddMember(ctx context.Context, member *domain.ChatMemberDomain) (*domain.ChatMemberDomain, error) {
+	memberModel := &models.ChatMember{
+		ChatID:    member.ChatID,
+		UserID:    member.UserID,
+		Role:      string(member.Role),
+		CreatedAt: time.Now(),
+	}
+
+	if err := mr.db.WithContext(ctx).Create(memberModel).Error; err != nil {
+		switch {
+		case isPgError(err, uniqueViolationCode):
+			return nil, domain.ErrMemberExists
+		case isPgError(err, foreignKeyViolationCode):
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return memberModelToDomain(memberModel), nil
+}
+
+func (mr *ChatMemberRepoPostgres) FindMember(ctx context.Context, chatID int, userID int) (*domain.ChatMemberDomain, error) {
+	memberModel := &models.ChatMember{}
+
+	err := mr.db.WithContext(ctx).Where("chat_id = ? AND user_id = ?", chatID, userID).First(memberModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return memberModelToDomain(memberModel), nil
+}
+
+func (mr *ChatMemberRepoPostgres) ListMembers(ctx context.Context, chatID int) ([]*domain.ChatMemberDomain, error) {
+	var memberModels []*models.ChatMember
+
+	if err := mr.db.WithContext(ctx).Where("chat_id = ?", chatID).Order("created_at, user_id").Find(&memberModels).Error; err != nil {
+		return nil, err
+	}
+
+	members := make([]*domain.ChatMemberDomain, 0, len(memberModels))
+	for _, el := range memberModels {
+		members = append(members, memberModelToDomain(el))
+	}
+
+	return members, nil
+}
+
+func (mr *ChatMemberRepoPostgres) UpdateMemberRole(ctx context.Context, chatID int, userID int, role domain.ChatRole) (*domain.ChatMemberDomain, error) {
+	result := mr.db.WithContext(ctx).Model(&models.ChatMember{}).
+		Where("chat_id = ? AND user_id = ?", chatID, userID).
+		Update("role", string(role))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, domain.ErrMemberNotFound
+	}
+
+	return mr.FindMember(ctx, chatID, userID)
+}
+
+func (mr *ChatMemberRepoPostgres) RemoveMember(ctx context.Context, chatID int, userID int) error {
+	result := mr.db.WithContext(ctx).Where("chat_id = ? AND user_id = ?", chatID, userID).Delete(&models.ChatMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrMemberNotFound
+	}
+	return nil
+}
+
+// TransferOwnership в одной транзакции понижает текущего владельца до админа и назначает нового
+func (mr *ChatMemberRepoPostgres) TransferOwnership(ctx context.Context, chatID int, fromUserID int, toUserID int) error {
+	return mr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		//сначала понижаем, иначе сработает уникальный индекс "один владелец на чат"
+		demoted := tx.Model(&models.ChatMember{}).
+			Where("chat_id = ? AND user_id = ? AND role = ?", chatID, fromUserID, string(domain.RoleOwner)).
+			Update("role", string(domain.RoleAdmin))
+		if demoted.Error != nil {
+			return demoted.Error
+		}
+		if demoted.RowsAffected == 0 {
+			return domain.ErrForbidden
+		}
+
+		promoted := tx.Model(&models.ChatMember{}).
+			Where("chat_id = ? AND user_id = ?", chatID, toUserID).
+			Update("role", string(domain.RoleOwner))
+		if promoted.Error != nil {
+			return promoted.Error
+		}
+		if promoted.RowsAffected == 0 {
+			return domain.ErrMemberNotFound
+		}
+
+		return nil
+	})
+}
+
+// claimOrphanChatsSQL назначает пользователя владельцем всех чатов без владельца
+const claimOrphanChatsSQL = `
+INSERT INTO chat_members (chat_id, user_id, role, created_at)
+SELECT c.id, u.id, 'owner', NOW()
+FROM chats c
+JOIN users u ON u.username = ?
+WHERE NOT EXISTS (SELECT 1 FROM chat_members cm WHERE cm.chat_id = c.id AND cm.role = 'owner')
+ON CONFLICT (chat_id, user_id) DO UPDATE SET role = 'owner'`
+
+// ClaimOrphanChats отдаёт пользователю чаты, у которых нет владельца, - например, созданные
+// до появления участников, где миграции не по кому было восстановить владельца
+func (mr *ChatMemberRepoPostgres) ClaimOrphanChats(ctx context.Context, username string) (int64, error) {
+	result := mr.db.WithContext(ctx).Exec(claimOrphanChatsSQL, username)
+	return result.RowsAffected, result.Error
+}
+
+func memberModelToDomain(el *models.ChatMember) *domain.ChatMemberDomain {
+	return &domain.ChatMemberDomain{
+		ChatID:    el.ChatID,
+		UserID:    el.UserID,
+		Role:      domain.ChatRole(el.Role),
+		CreatedAt: el.CreatedAt,
+	}
+}
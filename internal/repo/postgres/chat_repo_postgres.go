@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"errors"
+	"strconv"
+	"strings"
 	"testtask5/internal/domain"
 	"testtask5/internal/models"
 	"testtask5/internal/repo"
@@ -10,6 +12,7 @@ import (
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChatRepoPostgres struct {
@@ -20,7 +23,7 @@ type ChatRepoPostgres struct {
 func NewChatRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *ChatRepoPostgres {
 	dbLogger := appLogger.Named("chat_db")
 	return &ChatRepoPostgres{
-		db:       db,
+		db:       withLogger(db, dbLogger),
 		dbLogger: dbLogger,
 	}
 }
@@ -34,14 +37,34 @@ func (cr *ChatRepoPostgres) Count(ctx context.Context) int64 {
 func (cr *ChatRepoPostgres) CreateChat(ctx context.Context, data *domain.ChatDomain) (*domain.ChatDomain, error) {
 	chatModel := &models.Chat{
 		Title:     data.Title,
+		Version:   1,
 		CreatedAt: time.Now(),
 	}
 
-	if err := cr.db.WithContext(ctx).Create(chatModel).Error; err != nil {
+	//чат и его владелец создаются атомарно, чтобы не осталось чатов без владельца
+	err := cr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(chatModel).Error; err != nil {
+			return err
+		}
+
+		if data.OwnerID == 0 {
+			return nil
+		}
+
+		return tx.Create(&models.ChatMember{
+			ChatID:    chatModel.ID,
+			UserID:    data.OwnerID,
+			Role:      string(domain.RoleOwner),
+			CreatedAt: chatModel.CreatedAt,
+		}).Error
+	})
+	if err != nil {
 		return data, err
 	}
 
 	data.ID = chatModel.ID
+	data.Version = chatModel.Version
+	data.CreatedAt = chatModel.CreatedAt
 
 	return data, nil
 }
@@ -50,11 +73,18 @@ func (cr *ChatRepoPostgres) FindChatById(ctx context.Context, data *domain.ChatD
 	chatModel := &models.Chat{}
 
 	err := cr.db.WithContext(ctx).First(&chatModel, data.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrChatNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	data.ID = chatModel.ID
+	data.Title = chatModel.Title
+	data.Version = chatModel.Version
+	data.CreatedAt = chatModel.CreatedAt
+	data.ArchivedAt = chatModel.ArchivedAt
 
 	return data, nil
 }
@@ -73,8 +103,173 @@ func (cr *ChatRepoPostgres) ChatExists(ctx context.Context, param repo.FilterPar
 	return count > 0, nil
 }
 
-func (cr *ChatRepoPostgres) DeleteChat(ctx context.Context, chatID int) error {
-	return cr.db.WithContext(ctx).Where("id = ?", chatID).Delete(&models.Chat{}).Error
+func (cr *ChatRepoPostgres) ListChats(ctx context.Context, params repo.ListParam) (*domain.ChatList, error) {
+	sortField := params.SortField
+	if sortField == "" {
+		sortField = "id"
+	}
+	if isFieldAllowed(sortField) != nil {
+		return nil, domain.ErrFieldIsNotAllowed
+	}
+
+	query := cr.db.WithContext(ctx).Model(&models.Chat{})
+	if params.Archived {
+		query = query.Where("archived_at IS NOT NULL")
+	} else {
+		query = query.Where("archived_at IS NULL")
+	}
+	if params.MemberID > 0 {
+		query = query.Where("id IN (SELECT chat_id FROM chat_members WHERE user_id = ?)", params.MemberID)
+	}
+	for _, filter := range params.Filters {
+		var err error
+		if query, err = applyFilter(query, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	//общее количество считаем без учёта курсора
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	direction, cmp := "ASC", ">"
+	if params.SortDesc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if params.Cursor != "" {
+		value, id, err := decodeListCursor(params.Cursor, sortField)
+		if err != nil {
+			return nil, err
+		}
+		// сравнение кортежей держит порядок стабильным при одинаковых значениях поля
+		query = query.Where("("+sortField+", id) "+cmp+" (?, ?)", value, id)
+	}
+
+	var chatModels []*models.Chat
+	err := query.
+		Order(sortField + " " + direction).
+		Order("id " + direction).
+		Limit(params.Limit + 1).
+		Find(&chatModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.ChatList{
+		Chats: make([]*domain.ChatDomain, 0, len(chatModels)),
+		Total: total,
+	}
+
+	if len(chatModels) > params.Limit {
+		chatModels = chatModels[:params.Limit]
+		last := chatModels[len(chatModels)-1]
+		result.NextCursor = encodeListCursor(last, sortField)
+	}
+
+	for _, el := range chatModels {
+		result.Chats = append(result.Chats, chatModelToDomain(el))
+	}
+
+	return result, nil
+}
+
+func (cr *ChatRepoPostgres) UpdateChatTitle(ctx context.Context, chatID int, title string, version int) (*domain.ChatDomain, error) {
+	return cr.updateChat(ctx, chatID, version, "", map[string]any{"title": title}, nil)
+}
+
+// ArchiveChat переносит чат в архив. Уже архивный чат не трогается, чтобы не сдвигать срок хранения
+func (cr *ChatRepoPostgres) ArchiveChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error) {
+	return cr.updateChat(ctx, chatID, version, "archived_at IS NULL", map[string]any{"archived_at": time.Now()}, domain.ErrChatArchived)
+}
+
+// RestoreChat возвращает чат из архива
+func (cr *ChatRepoPostgres) RestoreChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error) {
+	return cr.updateChat(ctx, chatID, version, "archived_at IS NOT NULL", map[string]any{"archived_at": nil}, domain.ErrChatNotArchived)
+}
+
+// updateChat меняет чат, если он в ожидаемом состоянии и версии, и увеличивает версию.
+// Проверка и изменение делаются одним UPDATE, поэтому из двух одновременных запросов пройдёт один
+func (cr *ChatRepoPostgres) updateChat(ctx context.Context, chatID int, version int, expected string, updates map[string]any, stateErr error) (*domain.ChatDomain, error) {
+	chatModel := &models.Chat{}
+
+	query := cr.db.WithContext(ctx).Model(chatModel).
+		Clauses(clause.Returning{}).
+		Where("id = ?", chatID)
+	if expected != "" {
+		query = query.Where(expected)
+	}
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	updates["version"] = gorm.Expr("version + 1")
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, cr.notUpdatedReason(ctx, chatID, version, stateErr)
+	}
+
+	return chatModelToDomain(chatModel), nil
+}
+
+// notUpdatedReason выясняет, почему изменение не затронуло чат
+func (cr *ChatRepoPostgres) notUpdatedReason(ctx context.Context, chatID int, version int, stateErr error) error {
+	current, err := cr.FindChatById(ctx, &domain.ChatDomain{ID: chatID})
+	if err != nil {
+		return err
+	}
+	if version > 0 && current.Version != version {
+		return domain.ErrVersionMismatch
+	}
+	if stateErr != nil {
+		return stateErr
+	}
+	return domain.ErrChatNotFound
+}
+
+// DeleteChat удаляет чат безвозвратно вместе с сообщениями
+func (cr *ChatRepoPostgres) DeleteChat(ctx context.Context, chatID int, version int) error {
+	query := cr.db.WithContext(ctx).Where("id = ?", chatID)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.Delete(&models.Chat{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return cr.notUpdatedReason(ctx, chatID, version, nil)
+	}
+	return nil
+}
+
+// PurgeArchivedChats безвозвратно удаляет не больше limit чатов, перенесённых в архив раньше archivedBefore.
+// Сообщения удаляются каскадно, их вложения подбирает воркер очистки
+func (cr *ChatRepoPostgres) PurgeArchivedChats(ctx context.Context, archivedBefore time.Time, limit int) (int64, error) {
+	result := cr.db.WithContext(ctx).
+		Where("id IN (?)", cr.db.Model(&models.Chat{}).
+			Select("id").
+			Where("archived_at < ?", archivedBefore).
+			Order("archived_at").
+			Limit(limit)).
+		Delete(&models.Chat{})
+	return result.RowsAffected, result.Error
+}
+
+func chatModelToDomain(el *models.Chat) *domain.ChatDomain {
+	return &domain.ChatDomain{
+		ID:         el.ID,
+		Title:      el.Title,
+		Version:    el.Version,
+		CreatedAt:  el.CreatedAt,
+		ArchivedAt: el.ArchivedAt,
+	}
 }
 
 func isFieldAllowed(field string) error {
@@ -90,3 +285,71 @@ func isFieldAllowed(field string) error {
 
 	return nil
 }
+
+// applyFilter добавляет условие фильтра в запрос, поле проверяется по белому списку
+func applyFilter(query *gorm.DB, param repo.FilterParam) (*gorm.DB, error) {
+	if isFieldAllowed(param.Field) != nil {
+		return nil, domain.ErrFieldIsNotAllowed
+	}
+
+	switch param.Operator {
+	case "", repo.FilterEq:
+		return query.Where(param.Field+" = ?", param.Value), nil
+	case repo.FilterGte:
+		return query.Where(param.Field+" >= ?", param.Value), nil
+	case repo.FilterLte:
+		return query.Where(param.Field+" <= ?", param.Value), nil
+	case repo.FilterPrefix, repo.FilterContains:
+		value, ok := param.Value.(string)
+		if !ok {
+			return nil, domain.ErrFieldIsNotAllowed
+		}
+		pattern := escapeLike(value) + "%"
+		if param.Operator == repo.FilterContains {
+			pattern = "%" + pattern
+		}
+		return query.Where(param.Field+" ILIKE ?", pattern), nil
+	default:
+		return nil, domain.ErrFieldIsNotAllowed
+	}
+}
+
+// escapeLike экранирует спецсимволы LIKE в пользовательском вводе
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func encodeListCursor(chat *models.Chat, sortField string) string {
+	switch sortField {
+	case "title":
+		return encodeCursor(chat.Title, chat.ID)
+	case "created_at":
+		return encodeCursor(chat.CreatedAt.Format(time.RFC3339Nano), chat.ID)
+	default:
+		return encodeCursor(strconv.Itoa(chat.ID), chat.ID)
+	}
+}
+
+func decodeListCursor(encoded string, sortField string) (any, int, error) {
+	cursor, err := decodeCursor(encoded)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch sortField {
+	case "title":
+		return cursor.Value, cursor.ID, nil
+	case "created_at":
+		createdAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+		if err != nil {
+			return nil, 0, domain.ErrInvalidCursor
+		}
+		return createdAt, cursor.ID, nil
+	default:
+		id, err := strconv.Atoi(cursor.Value)
+		if err != nil {
+			return nil, 0, domain.ErrInvalidCursor
+		}
+		return id, cursor.ID, nil
+	}
+}
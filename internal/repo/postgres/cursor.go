@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testtask5/internal/domain"
+)
+
+// keysetCursor - содержимое непрозрачного курсора: значение поля сортировки и id последней записи
+type keysetCursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+func encodeCursor(value string, id int) string {
+	raw, _ := json.Marshal(keysetCursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(encoded string) (*keysetCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	cursor := &keysetCursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testtask5/internal/logging"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold - запросы дольше этого логируются как медленные
+const slowQueryThreshold = 200 * time.Millisecond
+
+// withLogger подключает к базе логгер репозитория. Запросы логируются через контекст,
+// поэтому строки из chat_db и прочих репозиториев несут request_id запроса
+func withLogger(db *gorm.DB, dbLogger *zap.Logger) *gorm.DB {
+	return db.Session(&gorm.Session{Logger: &zapGormLogger{logger: dbLogger}})
+}
+
+// zapGormLogger пишет ошибки и медленные запросы GORM в zap. Остальные запросы уходят на уровень debug
+type zapGormLogger struct {
+	logger *zap.Logger
+}
+
+func (l *zapGormLogger) LogMode(gormLogger.LogLevel) gormLogger.Interface {
+	//уровень задаётся конфигом zap
+	return l
+}
+
+func (l *zapGormLogger) Info(ctx context.Context, msg string, args ...any) {
+	logging.Logger(ctx, l.logger).Info(fmt.Sprintf(msg, args...))
+}
+
+func (l *zapGormLogger) Warn(ctx context.Context, msg string, args ...any) {
+	logging.Logger(ctx, l.logger).Warn(fmt.Sprintf(msg, args...))
+}
+
+func (l *zapGormLogger) Error(ctx context.Context, msg string, args ...any) {
+	logging.Logger(ctx, l.logger).Error(fmt.Sprintf(msg, args...))
+}
+
+func (l *zapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	//не найденная запись - обычный ответ репозитория, а не ошибка
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, context.Canceled)
+	slow := elapsed >= slowQueryThreshold
+	if !failed && !slow && !l.logger.Core().Enabled(zap.DebugLevel) {
+		return
+	}
+
+	sql, rows := fc()
+	logger := logging.Logger(ctx, l.logger).With(
+		zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("duration", elapsed),
+	)
+	switch {
+	case failed:
+		logger.Error("ошибка запроса к базе", zap.Error(err))
+	case slow:
+		logger.Warn("медленный запрос к базе")
+	default:
+		logger.Debug("запрос к базе")
+	}
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// HealthRepoPostgres проверяет, что база доступна и схема в ней не старее кода
+type HealthRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewHealthRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *HealthRepoPostgres {
+	dbLogger := appLogger.Named("health_db")
+	return &HealthRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+func (hr *HealthRepoPostgres) Ping(ctx context.Context) error {
+	sqlDB, err := hr.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckSchemaVersion сверяет версию миграций в базе с последней миграцией, известной коду.
+// Более новая схема допустима: при выкатке её накатывает новая версия, пока старая ещё обслуживает запросы
+func (hr *HealthRepoPostgres) CheckSchemaVersion(ctx context.Context, expected int64) error {
+	sqlDB, err := hr.db.DB()
+	if err != nil {
+		return err
+	}
+
+	current, err := goose.GetDBVersionContext(ctx, sqlDB)
+	if err != nil {
+		return err
+	}
+	if current < expected {
+		return fmt.Errorf("версия схемы %d, ожидается %d", current, expected)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewIdempotencyRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *IdempotencyRepoPostgres {
+	dbLogger := appLogger.Named("idempotency_db")
+	return &IdempotencyRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+// Reserve вставляет ключ одним запросом, поэтому из двух одновременных запросов ключ достаётся одному.
+// Просроченный ключ, который ещё не убрал воркер, перезаписывается. У невыполненного запроса срок
+// короткий, так что ключ, брошенный упавшим процессом, скоро освобождается
+func (ir *IdempotencyRepoPostgres) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	keyModel := &models.IdempotencyKey{
+		UserID:      record.UserID,
+		Key:         record.Key,
+		Scope:       record.Scope,
+		RequestHash: record.RequestHash,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   record.ExpiresAt,
+	}
+
+	result := ir.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"scope":         keyModel.Scope,
+				"request_hash":  keyModel.RequestHash,
+				"status_code":   nil,
+				"content_type":  "",
+				"response_body": nil,
+				"created_at":    keyModel.CreatedAt,
+				"expires_at":    keyModel.ExpiresAt,
+			}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "idempotency_keys.expires_at <= ?", Vars: []any{keyModel.CreatedAt}},
+			}},
+		}).
+		Create(keyModel)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return record, true, nil
+	}
+
+	existing := &models.IdempotencyKey{}
+	err := ir.db.WithContext(ctx).
+		Where("user_id = ? AND key = ?", record.UserID, record.Key).
+		First(existing).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	return idempotencyModelToDomain(existing), false, nil
+}
+
+// Complete сохраняет ответ на запрос и новый срок хранения ключа
+func (ir *IdempotencyRepoPostgres) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	return ir.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", record.UserID, record.Key).
+		Updates(map[string]any{
+			"status_code":   record.StatusCode,
+			"content_type":  record.ContentType,
+			"response_body": record.Body,
+			"expires_at":    record.ExpiresAt,
+		}).Error
+}
+
+// Release освобождает ключ, ответ на который сохранять нельзя, чтобы запрос можно было повторить
+func (ir *IdempotencyRepoPostgres) Release(ctx context.Context, userID int, key string) error {
+	return ir.db.WithContext(ctx).
+		Where("user_id = ? AND key = ? AND status_code IS NULL", userID, key).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+func (ir *IdempotencyRepoPostgres) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := ir.db.WithContext(ctx).
+		Where("expires_at <= ?", now).
+		Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+func idempotencyModelToDomain(el *models.IdempotencyKey) *domain.IdempotencyRecord {
+	record := &domain.IdempotencyRecord{
+		UserID:      el.UserID,
+		Key:         el.Key,
+		Scope:       el.Scope,
+		RequestHash: el.RequestHash,
+		ContentType: el.ContentType,
+		Body:        el.ResponseBody,
+		ExpiresAt:   el.ExpiresAt,
+	}
+	if el.StatusCode != nil {
+		record.StatusCode = *el.StatusCode
+	}
+	return record
+}
@@ -2,12 +2,17 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"slices"
+	"strconv"
 	"testtask5/internal/domain"
 	"testtask5/internal/models"
+	"testtask5/internal/repo"
 	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MessageRepoPostgres struct {
@@ -18,14 +23,14 @@ type MessageRepoPostgres struct {
 func NewMessageRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *MessageRepoPostgres {
 	dbLogger := appLogger.Named("message_db")
 	return &MessageRepoPostgres{
-		db:       db,
+		db:       withLogger(db, dbLogger),
 		dbLogger: dbLogger,
 	}
 }
 
 func (mr *MessageRepoPostgres) Count(ctx context.Context) int64 {
 	var count int64
-	mr.db.WithContext(ctx).Model(&models.Message{}).Where("1=1").Count(&count)
+	mr.db.WithContext(ctx).Model(&models.Message{}).Where("deleted_at IS NULL").Count(&count)
 	return count
 }
 
@@ -33,39 +38,725 @@ func (mr *MessageRepoPostgres) CreateMessage(ctx context.Context, data *domain.M
 	messageModel := &models.Message{}
 
 	messageModel.ChatID = data.ChatID
+	messageModel.ParentMessageID = data.ParentMessageID
+	messageModel.AuthorID = data.AuthorID
 	messageModel.Text = data.Text
 	messageModel.CreatedAt = time.Now()
 
-	if err := mr.db.WithContext(ctx).Create(messageModel).Error; err != nil {
+	err := mr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seq, err := nextChatSeq(tx, data.ChatID)
+		if err != nil {
+			return err
+		}
+		messageModel.Seq = seq
+
+		if err := tx.Create(messageModel).Error; err != nil {
+			return err
+		}
+
+		return createAttachments(tx, messageModel.ID, data.Attachments)
+	})
+	if err != nil {
 		return data, err
 	}
 
-	data.ID = messageModel.ChatID
+	data.ID = messageModel.ID
+	data.Seq = messageModel.Seq
+	data.CreatedAt = messageModel.CreatedAt
 
 	return data, nil
 }
 
-func (mr *MessageRepoPostgres) GetMessagesByChaWithLimit(ctx context.Context, chatID int, limit int) []*domain.MessageDomain {
-	var messageModels []*models.Message
+func (mr *MessageRepoPostgres) FindMessageById(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error) {
+	messageModel := &models.Message{}
 
-	var messageDomains []*domain.MessageDomain
+	err := mr.db.WithContext(ctx).Where("chat_id = ? AND id = ?", chatID, messageID).First(messageModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	if err := mr.db.WithContext(ctx).Order("created_at DESC").Limit(limit).Find(&messageModels).Error; err != nil {
-		return nil
+	return messageModelToDomain(messageModel), nil
+}
+
+// UpdateMessageText меняет текст сообщения и сохраняет предыдущую версию в message_revisions
+func (mr *MessageRepoPostgres) UpdateMessageText(ctx context.Context, data *domain.MessageDomain) (*domain.MessageDomain, error) {
+	messageModel := &models.Message{}
+
+	err := mr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seq, err := nextChatSeq(tx, data.ChatID)
+		if err != nil {
+			return err
+		}
+
+		//блокируем строку, чтобы параллельные правки не потеряли ревизию
+		if err := lockMessage(tx, data.ChatID, data.ID, messageModel); err != nil {
+			return err
+		}
+		if messageModel.DeletedAt != nil {
+			return domain.ErrMessageDeleted
+		}
+
+		now := time.Now()
+
+		revision := &models.MessageRevision{
+			MessageID: messageModel.ID,
+			Text:      messageModel.Text,
+			CreatedAt: now,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		messageModel.Text = data.Text
+		messageModel.EditedAt = &now
+		messageModel.Seq = seq
+
+		return tx.Model(messageModel).Updates(map[string]any{
+			"text":      messageModel.Text,
+			"edited_at": messageModel.EditedAt,
+			"seq":       messageModel.Seq,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	for _, el := range messageModels {
-		messageDomains = append(messageDomains, &domain.MessageDomain{
+	return messageModelToDomain(messageModel), nil
+}
+
+func (mr *MessageRepoPostgres) GetMessageRevisions(ctx context.Context, messageID int) ([]*domain.MessageRevisionDomain, error) {
+	var revisionModels []*models.MessageRevision
+
+	if err := mr.db.WithContext(ctx).Where("message_id = ?", messageID).Order("id DESC").Find(&revisionModels).Error; err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*domain.MessageRevisionDomain, 0, len(revisionModels))
+	for _, el := range revisionModels {
+		revisions = append(revisions, &domain.MessageRevisionDomain{
 			ID:        el.ID,
-			ChatID:    el.ChatID,
+			MessageID: el.MessageID,
 			Text:      el.Text,
 			CreatedAt: el.CreatedAt,
 		})
 	}
 
-	return messageDomains
+	return revisions, nil
+}
+
+// GetMessagesByChatWithCursor возвращает корневые сообщения чата, ответы в тредах не попадают в основную ленту
+func (mr *MessageRepoPostgres) GetMessagesByChatWithCursor(ctx context.Context, chatID int, cursor repo.CursorParam) (*domain.MessagePage, error) {
+	query := mr.db.WithContext(ctx).Where("chat_id = ? AND parent_message_id IS NULL", chatID)
+
+	page, err := mr.pageMessages(query, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mr.fillReplyStats(ctx, page.Messages); err != nil {
+		return nil, err
+	}
+	if err := mr.fillReactions(ctx, page.Messages); err != nil {
+		return nil, err
+	}
+	if err := mr.fillAttachments(ctx, page.Messages); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (mr *MessageRepoPostgres) GetThreadWithCursor(ctx context.Context, chatID int, rootID int, cursor repo.CursorParam) (*domain.MessagePage, error) {
+	query := mr.db.WithContext(ctx).Where("chat_id = ? AND parent_message_id = ?", chatID, rootID)
+
+	page, err := mr.pageMessages(query, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mr.fillReactions(ctx, page.Messages); err != nil {
+		return nil, err
+	}
+	if err := mr.fillAttachments(ctx, page.Messages); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// pageMessages применяет keyset-пагинацию по id к подготовленному запросу
+func (mr *MessageRepoPostgres) pageMessages(query *gorm.DB, cursor repo.CursorParam) (*domain.MessagePage, error) {
+	var messageModels []*models.Message
+
+	// берём на одну запись больше, чтобы понять, есть ли следующая страница
+	query = query.Limit(cursor.Limit + 1)
+
+	switch {
+	case cursor.After > 0:
+		query = query.Where("id > ?", cursor.After).Order("id ASC")
+	case cursor.Before > 0:
+		query = query.Where("id < ?", cursor.Before).Order("id DESC")
+	default:
+		query = query.Order("id DESC")
+	}
+
+	if err := query.Find(&messageModels).Error; err != nil {
+		return nil, err
+	}
+
+	hasMore := len(messageModels) > cursor.Limit
+	if hasMore {
+		messageModels = messageModels[:cursor.Limit]
+	}
+
+	page := &domain.MessagePage{
+		Messages: make([]*domain.MessageDomain, 0, len(messageModels)),
+	}
+
+	if cursor.After > 0 {
+		// выборка шла по возрастанию, разворачиваем к общему порядку "новые сверху"
+		slices.Reverse(messageModels)
+		page.HasNewer = hasMore
+		page.HasOlder = true
+	} else {
+		page.HasOlder = hasMore
+		page.HasNewer = cursor.Before > 0
+	}
+
+	for _, el := range messageModels {
+		page.Messages = append(page.Messages, messageModelToDomain(el))
+	}
+
+	return page, nil
+}
+
+// replyStatsRow - агрегат ответов по одному корневому сообщению
+type replyStatsRow struct {
+	ParentMessageID int
+	ReplyCount      int
+	LastReplyAt     time.Time
+}
+
+// fillReplyStats одним запросом подтягивает количество ответов и время последнего ответа для страницы
+func (mr *MessageRepoPostgres) fillReplyStats(ctx context.Context, messages []*domain.MessageDomain) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+
+	var rows []*replyStatsRow
+	err := mr.db.WithContext(ctx).Model(&models.Message{}).
+		Select("parent_message_id, COUNT(*) AS reply_count, MAX(created_at) AS last_reply_at").
+		Where("parent_message_id IN ? AND deleted_at IS NULL", ids).
+		Group("parent_message_id").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	stats := make(map[int]*replyStatsRow, len(rows))
+	for _, row := range rows {
+		stats[row.ParentMessageID] = row
+	}
+
+	for _, m := range messages {
+		if row, ok := stats[m.ID]; ok {
+			m.ReplyCount = row.ReplyCount
+			m.LastReplyAt = &row.LastReplyAt
+		}
+	}
+
+	return nil
+}
+
+// SoftDeleteMessage помечает сообщение удалённым, строка остаётся в истории как tombstone
+func (mr *MessageRepoPostgres) SoftDeleteMessage(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error) {
+	messageModel := &models.Message{}
+
+	err := mr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seq, err := nextChatSeq(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		if err := lockMessage(tx, chatID, messageID, messageModel); err != nil {
+			return err
+		}
+		if messageModel.DeletedAt != nil {
+			return domain.ErrMessageDeleted
+		}
+
+		now := time.Now()
+		messageModel.DeletedAt = &now
+		messageModel.Seq = seq
+
+		return tx.Model(messageModel).Updates(map[string]any{
+			"deleted_at": messageModel.DeletedAt,
+			"seq":        messageModel.Seq,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messageModelToDomain(messageModel), nil
+}
+
+// RestoreMessage снимает пометку удаления, если сообщение удалено не раньше deletedAfter
+func (mr *MessageRepoPostgres) RestoreMessage(ctx context.Context, chatID int, messageID int, deletedAfter time.Time) (*domain.MessageDomain, error) {
+	messageModel := &models.Message{}
+
+	err := mr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seq, err := nextChatSeq(tx, chatID)
+		if err != nil {
+			return err
+		}
+
+		if err := lockMessage(tx, chatID, messageID, messageModel); err != nil {
+			return err
+		}
+		if messageModel.DeletedAt == nil {
+			return domain.ErrMessageNotDeleted
+		}
+		if messageModel.DeletedAt.Before(deletedAfter) {
+			return domain.ErrRestoreExpired
+		}
+
+		messageModel.DeletedAt = nil
+		messageModel.Seq = seq
+
+		return tx.Model(messageModel).Updates(map[string]any{
+			"deleted_at": nil,
+			"seq":        messageModel.Seq,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messageModelToDomain(messageModel), nil
+}
+
+// purgeDeletedMessagesSQL удаляет tombstone-ы и запоминает в чатах наибольший удалённый seq:
+// клиенту, который синхронизировался раньше, уже не узнать об этих удалениях из дельты.
+// Корень треда остаётся, пока у него есть ответы, иначе тред потеряет начало
+const purgeDeletedMessagesSQL = `
+WITH purged AS (
+	DELETE FROM messages
+	WHERE deleted_at IS NOT NULL AND deleted_at < ?
+		AND NOT EXISTS (SELECT 1 FROM messages c WHERE c.parent_message_id = messages.id)
+	RETURNING chat_id, seq
+), bumped AS (
+	UPDATE chats SET purged_seq = GREATEST(chats.purged_seq, p.max_seq)
+	FROM (SELECT chat_id, MAX(seq) AS max_seq FROM purged GROUP BY chat_id) p
+	WHERE chats.id = p.chat_id
+)
+SELECT COUNT(*) FROM purged`
+
+// PurgeDeletedMessages окончательно удаляет tombstone-ы, удалённые раньше deletedBefore
+func (mr *MessageRepoPostgres) PurgeDeletedMessages(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := mr.db.WithContext(ctx).Raw(purgeDeletedMessagesSQL, deletedBefore).Scan(&purged).Error
+
+	return purged, err
+}
+
+// chatSeqRow - счётчики изменений чата
+type chatSeqRow struct {
+	LastSeq   int64
+	PurgedSeq int64
+}
+
+// GetChangesSince возвращает сообщения чата, изменённые после sinceSeq, в порядке seq
+func (mr *MessageRepoPostgres) GetChangesSince(ctx context.Context, chatID int, sinceSeq int64, limit int) (*domain.MessageChanges, error) {
+	var counters chatSeqRow
+	result := mr.db.WithContext(ctx).Model(&models.Chat{}).
+		Select("last_seq, purged_seq").
+		Where("id = ?", chatID).
+		Scan(&counters)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, domain.ErrChatNotFound
+	}
+
+	if sinceSeq < counters.PurgedSeq {
+		return nil, domain.ErrResyncRequired
+	}
+
+	var messageModels []*models.Message
+	err := mr.db.WithContext(ctx).
+		Where("chat_id = ? AND seq > ?", chatID, sinceSeq).
+		Order("seq ASC").
+		Limit(limit + 1).
+		Find(&messageModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &domain.MessageChanges{
+		ChatSeq: counters.LastSeq,
+		HasMore: len(messageModels) > limit,
+	}
+	if changes.HasMore {
+		messageModels = messageModels[:limit]
+	}
+
+	changes.Messages = make([]*domain.MessageDomain, 0, len(messageModels))
+	for _, el := range messageModels {
+		changes.Messages = append(changes.Messages, messageModelToDomain(el))
+	}
+
+	return changes, nil
+}
+
+// AddReaction ставит реакцию, повторная такая же реакция от того же участника игнорируется
+func (mr *MessageRepoPostgres) AddReaction(ctx context.Context, reaction *domain.ReactionDomain) error {
+	reactionModel := &models.MessageReaction{
+		MessageID: reaction.MessageID,
+		Reactor:   reaction.Reactor,
+		Emoji:     reaction.Emoji,
+		CreatedAt: time.Now(),
+	}
+
+	return mr.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(reactionModel).Error
+}
+
+func (mr *MessageRepoPostgres) RemoveReaction(ctx context.Context, reaction *domain.ReactionDomain) error {
+	return mr.db.WithContext(ctx).
+		Where("message_id = ? AND reactor = ? AND emoji = ?", reaction.MessageID, reaction.Reactor, reaction.Emoji).
+		Delete(&models.MessageReaction{}).Error
+}
+
+// reactionCountRow - агрегат реакций одного эмодзи на одно сообщение
+type reactionCountRow struct {
+	MessageID int
+	Emoji     string
+	Count     int
+}
+
+// GetReactionCounts одним запросом считает реакции по эмодзи для набора сообщений
+func (mr *MessageRepoPostgres) GetReactionCounts(ctx context.Context, messageIDs []int) (map[int][]domain.ReactionCount, error) {
+	counts := make(map[int][]domain.ReactionCount, len(messageIDs))
+	if len(messageIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []*reactionCountRow
+	err := mr.db.WithContext(ctx).Model(&models.MessageReaction{}).
+		Select("message_id, emoji, COUNT(*) AS count").
+		Where("message_id IN ?", messageIDs).
+		Group("message_id, emoji").
+		Order("message_id, count DESC, MIN(created_at)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.MessageID] = append(counts[row.MessageID], domain.ReactionCount{Emoji: row.Emoji, Count: row.Count})
+	}
+
+	return counts, nil
+}
+
+// fillReactions проставляет агрегированные реакции сообщениям страницы без запроса на каждое сообщение
+func (mr *MessageRepoPostgres) fillReactions(ctx context.Context, messages []*domain.MessageDomain) error {
+	ids := make([]int, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+
+	counts, err := mr.GetReactionCounts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		//у tombstone-ов реакции не показываем
+		if m.DeletedAt == nil {
+			m.Reactions = counts[m.ID]
+		}
+	}
+
+	return nil
+}
+
+// createAttachments сохраняет записи о вложениях нового сообщения в той же транзакции
+func createAttachments(tx *gorm.DB, messageID int, attachments []*domain.AttachmentDomain) error {
+	for _, attachment := range attachments {
+		attachmentModel := &models.Attachment{
+			MessageID:   &messageID,
+			StorageKey:  attachment.StorageKey,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			SizeBytes:   attachment.Size,
+			CreatedAt:   time.Now(),
+		}
+		attachmentModel.ThumbnailStatus = thumbnailStatusNone
+		if attachment.NeedsThumbnails() {
+			attachmentModel.ThumbnailStatus = thumbnailStatusPending
+		}
+		if err := tx.Create(attachmentModel).Error; err != nil {
+			return err
+		}
+
+		attachment.ID = attachmentModel.ID
+		attachment.MessageID = messageID
+		attachment.CreatedAt = attachmentModel.CreatedAt
+	}
+
+	return nil
+}
+
+// fillAttachments одним запросом подтягивает вложения для страницы сообщений
+func (mr *MessageRepoPostgres) fillAttachments(ctx context.Context, messages []*domain.MessageDomain) error {
+	ids := make([]int, 0, len(messages))
+	for _, m := range messages {
+		//у tombstone-ов вложения не показываем
+		if m.DeletedAt == nil {
+			ids = append(ids, m.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var attachmentModels []*models.Attachment
+	err := mr.db.WithContext(ctx).
+		Preload("Thumbnails", preloadThumbnails).
+		Where("message_id IN ?", ids).
+		Order("id").
+		Find(&attachmentModels).Error
+	if err != nil {
+		return err
+	}
+
+	byMessage := make(map[int][]*domain.AttachmentDomain, len(ids))
+	for _, el := range attachmentModels {
+		byMessage[*el.MessageID] = append(byMessage[*el.MessageID], attachmentModelToDomain(el))
+	}
+
+	for _, m := range messages {
+		m.Attachments = byMessage[m.ID]
+	}
+
+	return nil
+}
+
+// FindAttachment ищет вложение сообщения с проверкой, что сообщение из указанного чата
+func (mr *MessageRepoPostgres) FindAttachment(ctx context.Context, chatID int, messageID int, attachmentID int) (*domain.AttachmentDomain, error) {
+	attachmentModel := &models.Attachment{}
+
+	err := mr.db.WithContext(ctx).
+		Preload("Thumbnails", preloadThumbnails).
+		Joins("JOIN messages ON messages.id = attachments.message_id").
+		Where("attachments.id = ? AND attachments.message_id = ? AND messages.chat_id = ?", attachmentID, messageID, chatID).
+		First(attachmentModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return attachmentModelToDomain(attachmentModel), nil
+}
+
+// ListOrphanAttachments возвращает вложения, чьи сообщения окончательно удалены
+func (mr *MessageRepoPostgres) ListOrphanAttachments(ctx context.Context, limit int) ([]*domain.AttachmentDomain, error) {
+	var attachmentModels []*models.Attachment
+
+	err := mr.db.WithContext(ctx).
+		Preload("Thumbnails", preloadThumbnails).
+		Where("message_id IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&attachmentModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*domain.AttachmentDomain, 0, len(attachmentModels))
+	for _, el := range attachmentModels {
+		attachments = append(attachments, attachmentModelToDomain(el))
+	}
+
+	return attachments, nil
+}
+
+func (mr *MessageRepoPostgres) DeleteAttachments(ctx context.Context, attachmentIDs []int) error {
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	return mr.db.WithContext(ctx).Where("id IN ?", attachmentIDs).Delete(&models.Attachment{}).Error
+}
+
+func attachmentModelToDomain(el *models.Attachment) *domain.AttachmentDomain {
+	attachment := &domain.AttachmentDomain{
+		ID:          el.ID,
+		FileName:    el.FileName,
+		ContentType: el.ContentType,
+		Size:        el.SizeBytes,
+		StorageKey:  el.StorageKey,
+		CreatedAt:   el.CreatedAt,
+	}
+	if el.MessageID != nil {
+		attachment.MessageID = *el.MessageID
+	}
+	if el.Width != nil && el.Height != nil {
+		attachment.Width, attachment.Height = *el.Width, *el.Height
+	}
+	for _, thumbnail := range el.Thumbnails {
+		attachment.Thumbnails = append(attachment.Thumbnails, &domain.ThumbnailDomain{
+			MaxSide:     thumbnail.MaxSide,
+			Width:       thumbnail.Width,
+			Height:      thumbnail.Height,
+			ContentType: thumbnail.ContentType,
+			Size:        thumbnail.SizeBytes,
+			StorageKey:  thumbnail.StorageKey,
+		})
+	}
+	return attachment
+}
+
+// preloadThumbnails - превью отдаются от меньшего к большему
+func preloadThumbnails(db *gorm.DB) *gorm.DB {
+	return db.Order("max_side")
+}
+
+// messageSearchRow - строка результата полнотекстового поиска
+type messageSearchRow struct {
+	models.Message
+	Rank    float64
+	Snippet string
+}
+
+// параметры ts_headline для подсветки найденных слов
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+
+// searchHeadlineText - текст сообщения, экранированный как HTML. ts_headline оставляет разметку
+// документа как есть, и без экранирования <script> из сообщения попал бы в фрагмент рядом с <mark>.
+// Сущности &lt; и т.п. парсер считает отдельными токенами, поэтому подсветка их не разрывает
+const searchHeadlineText = "replace(replace(replace(messages.text, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')"
+
+// SearchMessages ищет по tsvector-колонке, сортирует по ts_rank и листает курсором (rank, id)
+func (mr *MessageRepoPostgres) SearchMessages(ctx context.Context, params repo.SearchParam) (*domain.MessageSearchResult, error) {
+	query := mr.db.WithContext(ctx).
+		Table("messages, websearch_to_tsquery('russian', ?) AS q", params.Query).
+		Select("messages.*, ts_rank(messages.search_vector, q)::float8 AS rank, "+
+			"ts_headline('russian', "+searchHeadlineText+", q, ?) AS snippet", searchHeadlineOptions).
+		Where("messages.search_vector @@ q AND messages.deleted_at IS NULL").
+		//архивные чаты скрыты и из поиска
+		Where("messages.chat_id NOT IN (SELECT id FROM chats WHERE archived_at IS NOT NULL)")
+
+	if params.ChatID > 0 {
+		query = query.Where("messages.chat_id = ?", params.ChatID)
+	}
+	if params.MemberID > 0 {
+		query = query.Where("messages.chat_id IN (SELECT chat_id FROM chat_members WHERE user_id = ?)", params.MemberID)
+	}
+	if params.From != nil {
+		query = query.Where("messages.created_at >= ?", *params.From)
+	}
+	if params.To != nil {
+		query = query.Where("messages.created_at <= ?", *params.To)
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		rank, err := strconv.ParseFloat(cursor.Value, 64)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		query = query.Where("(ts_rank(messages.search_vector, q)::float8, messages.id) < (?, ?)", rank, cursor.ID)
+	}
+
+	var rows []*messageSearchRow
+	if err := query.Order("rank DESC, messages.id DESC").Limit(params.Limit + 1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := &domain.MessageSearchResult{
+		Hits: make([]*domain.MessageSearchHit, 0, len(rows)),
+	}
+
+	if len(rows) > params.Limit {
+		rows = rows[:params.Limit]
+		last := rows[len(rows)-1]
+		result.NextCursor = encodeCursor(strconv.FormatFloat(last.Rank, 'g', -1, 64), last.ID)
+	}
+
+	for _, row := range rows {
+		result.Hits = append(result.Hits, &domain.MessageSearchHit{
+			Message: messageModelToDomain(&row.Message),
+			Rank:    row.Rank,
+			Snippet: row.Snippet,
+		})
+	}
+
+	return result, nil
 }
 
 func (mr *MessageRepoPostgres) DeleteMessages(ctx context.Context, chatID int) error {
 	return mr.db.WithContext(ctx).Where("chat_id = ?", chatID).Delete(&models.Message{}).Error
 }
+
+// nextChatSeq выдаёт следующий номер изменения чата. Строка чата остаётся заблокированной
+// до конца транзакции, поэтому изменения одного чата фиксируются строго в порядке seq
+func nextChatSeq(tx *gorm.DB, chatID int) (int64, error) {
+	var seqs []int64
+	err := tx.Raw("UPDATE chats SET last_seq = last_seq + 1 WHERE id = ? RETURNING last_seq", chatID).
+		Scan(&seqs).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(seqs) == 0 {
+		return 0, domain.ErrChatNotFound
+	}
+
+	return seqs[0], nil
+}
+
+// lockMessage читает сообщение чата с блокировкой строки до конца транзакции
+func lockMessage(tx *gorm.DB, chatID int, messageID int, messageModel *models.Message) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("chat_id = ? AND id = ?", chatID, messageID).
+		First(messageModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrMessageNotFound
+	}
+	return err
+}
+
+func messageModelToDomain(el *models.Message) *domain.MessageDomain {
+	message := &domain.MessageDomain{
+		ID:              el.ID,
+		ChatID:          el.ChatID,
+		Seq:             el.Seq,
+		ParentMessageID: el.ParentMessageID,
+		AuthorID:        el.AuthorID,
+		Text:            el.Text,
+		CreatedAt:       el.CreatedAt,
+		EditedAt:        el.EditedAt,
+		DeletedAt:       el.DeletedAt,
+	}
+
+	//tombstone: текст удалённого сообщения наружу не отдаём
+	if el.DeletedAt != nil {
+		message.Text = ""
+	}
+
+	return message
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type PinRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewPinRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *PinRepoPostgres {
+	dbLogger := appLogger.Named("pin_db")
+	return &PinRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+// PinMessage проверяет лимит и закрепляет сообщение под блокировкой на чат,
+// чтобы два одновременных запроса не превысили лимит. Закрепления удалённых сообщений в лимит не входят
+func (pr *PinRepoPostgres) PinMessage(ctx context.Context, pin *domain.PinDomain, limit int) (*domain.PinDomain, error) {
+	pinModel := &models.PinnedMessage{
+		ChatID:    pin.ChatID,
+		MessageID: pin.MessageID,
+		PinnedBy:  pin.PinnedBy,
+		PinnedAt:  time.Now(),
+	}
+
+	err := pr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?), ?)", "pinned_messages", pin.ChatID).Error; err != nil {
+			return err
+		}
+
+		var pinned int64
+		err := tx.Model(&models.PinnedMessage{}).
+			Joins("JOIN messages ON messages.id = pinned_messages.message_id").
+			Where("pinned_messages.chat_id = ? AND messages.deleted_at IS NULL", pin.ChatID).
+			Count(&pinned).Error
+		if err != nil {
+			return err
+		}
+		if pinned >= int64(limit) {
+			return domain.ErrPinLimitReached
+		}
+
+		return tx.Create(pinModel).Error
+	})
+	if err != nil {
+		switch {
+		case isPgError(err, uniqueViolationCode):
+			return nil, domain.ErrAlreadyPinned
+		case isPgError(err, foreignKeyViolationCode):
+			return nil, domain.ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	pin.PinnedAt = pinModel.PinnedAt
+	return pin, nil
+}
+
+func (pr *PinRepoPostgres) UnpinMessage(ctx context.Context, chatID int, messageID int) error {
+	result := pr.db.WithContext(ctx).
+		Where("chat_id = ? AND message_id = ?", chatID, messageID).
+		Delete(&models.PinnedMessage{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrNotPinned
+	}
+	return nil
+}
+
+// ListPinned возвращает закреплённые сообщения чата, последние закреплённые первыми.
+// Удалённые сообщения скрываются, но закрепление возвращается вместе с восстановлением
+func (pr *PinRepoPostgres) ListPinned(ctx context.Context, chatID int) ([]*domain.PinDomain, error) {
+	var pinModels []*models.PinnedMessage
+
+	err := pr.db.WithContext(ctx).
+		Joins("Message").
+		Where("pinned_messages.chat_id = ? AND \"Message\".deleted_at IS NULL", chatID).
+		Order("pinned_messages.pinned_at DESC").
+		Find(&pinModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	pins := make([]*domain.PinDomain, 0, len(pinModels))
+	for _, el := range pinModels {
+		pin := &domain.PinDomain{
+			ChatID:    el.ChatID,
+			MessageID: el.MessageID,
+			PinnedBy:  el.PinnedBy,
+			PinnedAt:  el.PinnedAt,
+		}
+		if el.Message != nil {
+			pin.Message = messageModelToDomain(el.Message)
+		}
+		pins = append(pins, pin)
+	}
+
+	return pins, nil
+}
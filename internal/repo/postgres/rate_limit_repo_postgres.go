@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitRepoPostgres хранит ведра лимитов в базе, чтобы лимит был общим для всех реплик
+type RateLimitRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewRateLimitRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *RateLimitRepoPostgres {
+	dbLogger := appLogger.Named("rate_limit_db")
+	return &RateLimitRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+// Take забирает токен под блокировкой строки, чтобы одновременные запросы с разных реплик
+// не потратили один и тот же токен
+func (rr *RateLimitRepoPostgres) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error) {
+	var result domain.RateLimitResult
+
+	err := rr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		bucketModel := &models.RateLimitBucket{
+			Key:        key,
+			Tokens:     float64(limit.Burst),
+			RefilledAt: now,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(bucketModel).Error; err != nil {
+			return err
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(bucketModel).Error
+		if err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = limit.Take(bucketModel.Tokens, bucketModel.RefilledAt, now)
+
+		return tx.Model(&models.RateLimitBucket{}).
+			Where("key = ?", key).
+			Updates(map[string]any{"tokens": tokens, "refilled_at": now}).Error
+	})
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	return result, nil
+}
+
+// PurgeIdle удаляет ведра, к которым не обращались с idleBefore. К этому времени они уже наполнились,
+// и новое ведро ничем от них не отличается
+func (rr *RateLimitRepoPostgres) PurgeIdle(ctx context.Context, idleBefore time.Time) (int64, error) {
+	result := rr.db.WithContext(ctx).
+		Where("refilled_at < ?", idleBefore).
+		Delete(&models.RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// статусы построения превью в attachments.thumbnail_status
+const (
+	thumbnailStatusNone       = "none"
+	thumbnailStatusPending    = "pending"
+	thumbnailStatusProcessing = "processing"
+	thumbnailStatusDone       = "done"
+	thumbnailStatusFailed     = "failed"
+)
+
+type ThumbnailRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewThumbnailRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *ThumbnailRepoPostgres {
+	dbLogger := appLogger.Named("thumbnail_db")
+	return &ThumbnailRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+// ClaimPendingImages помечает картинки как взятые в обработку. SKIP LOCKED не даёт
+// нескольким экземплярам сервиса взять одну и ту же картинку
+func (tr *ThumbnailRepoPostgres) ClaimPendingImages(ctx context.Context, limit int, staleBefore time.Time, maxAttempts int) ([]*domain.AttachmentDomain, error) {
+	//картинки, на которых воркер падал maxAttempts раз, больше не берём
+	err := tr.db.WithContext(ctx).Model(&models.Attachment{}).
+		Where("thumbnail_status = ? AND thumbnail_claimed_at < ? AND thumbnail_attempts >= ?",
+			thumbnailStatusProcessing, staleBefore, maxAttempts).
+		Update("thumbnail_status", thumbnailStatusFailed).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var attachmentModels []*models.Attachment
+
+	err = tr.db.WithContext(ctx).Raw(`
+		UPDATE attachments
+		SET thumbnail_status = ?, thumbnail_claimed_at = NOW(), thumbnail_attempts = thumbnail_attempts + 1
+		WHERE id IN (
+			SELECT id FROM attachments
+			WHERE message_id IS NOT NULL
+				AND thumbnail_attempts < ?
+				AND (thumbnail_status = ? OR (thumbnail_status = ? AND thumbnail_claimed_at < ?))
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		thumbnailStatusProcessing, maxAttempts, thumbnailStatusPending, thumbnailStatusProcessing, staleBefore, limit,
+	).Scan(&attachmentModels).Error
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := make([]*domain.AttachmentDomain, 0, len(attachmentModels))
+	for _, el := range attachmentModels {
+		attachments = append(attachments, attachmentModelToDomain(el))
+	}
+
+	return attachments, nil
+}
+
+// SaveThumbnails сохраняет превью и размеры оригинала. Если вложение успели удалить,
+// вставка упадёт на внешнем ключе, и вызывающий удалит уже загруженные превью
+func (tr *ThumbnailRepoPostgres) SaveThumbnails(ctx context.Context, attachmentID int, width int, height int, thumbnails []*domain.ThumbnailDomain) error {
+	return tr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, thumbnail := range thumbnails {
+			err := tx.Create(&models.AttachmentThumbnail{
+				AttachmentID: attachmentID,
+				MaxSide:      thumbnail.MaxSide,
+				Width:        thumbnail.Width,
+				Height:       thumbnail.Height,
+				ContentType:  thumbnail.ContentType,
+				SizeBytes:    thumbnail.Size,
+				StorageKey:   thumbnail.StorageKey,
+				CreatedAt:    time.Now(),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Attachment{}).
+			Where("id = ?", attachmentID).
+			Updates(map[string]any{
+				"width":            width,
+				"height":           height,
+				"thumbnail_status": thumbnailStatusDone,
+			}).Error
+	})
+}
+
+func (tr *ThumbnailRepoPostgres) MarkThumbnailsFailed(ctx context.Context, attachmentID int) error {
+	return tr.db.WithContext(ctx).Model(&models.Attachment{}).
+		Where("id = ?", attachmentID).
+		Update("thumbnail_status", thumbnailStatusFailed).Error
+}
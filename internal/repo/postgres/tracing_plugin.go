@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testtask5/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const tracingSpanKey = "tracing:span"
+
+// TracingPlugin открывает спан на каждый запрос GORM. Спан становится дочерним к спану из контекста
+// запроса (WithContext), поэтому в трейсе видно, сколько занял каждый запрос к базе.
+// В спан пишется SQL с плейсхолдерами, значения параметров туда не попадают
+type TracingPlugin struct {
+	tracer trace.Tracer
+}
+
+func NewTracingPlugin() *TracingPlugin {
+	return &TracingPlugin{tracer: tracing.Tracer("postgres")}
+}
+
+func (tp *TracingPlugin) Name() string {
+	return "tracing"
+}
+
+func (tp *TracingPlugin) Initialize(db *gorm.DB) error {
+	type register func(name string, fn func(*gorm.DB)) error
+
+	callbacks := db.Callback()
+	operations := []struct {
+		name          string
+		before, after register
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, op := range operations {
+		if err := op.before("tracing:before_"+op.name, tp.before(op.name)); err != nil {
+			return err
+		}
+		if err := op.after("tracing:after_"+op.name, tp.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tp *TracingPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		ctx, span := tp.tracer.Start(ctx, "db."+operation, trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.String("db.system", "postgresql")),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func (tp *TracingPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.String("db.sql.table", db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	//не найденная запись - обычный ответ репозитория, а не ошибка
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		tracing.RecordError(span, db.Error)
+	}
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testtask5/internal/domain"
+	"testtask5/internal/models"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type UserRepoPostgres struct {
+	db       *gorm.DB
+	dbLogger *zap.Logger
+}
+
+func NewUserRepoPostgres(db *gorm.DB, appLogger *zap.Logger) *UserRepoPostgres {
+	dbLogger := appLogger.Named("user_db")
+	return &UserRepoPostgres{
+		db:       withLogger(db, dbLogger),
+		dbLogger: dbLogger,
+	}
+}
+
+func (ur *UserRepoPostgres) CreateUser(ctx context.Context, data *domain.UserDomain) (*domain.UserDomain, error) {
+	userModel := &models.User{
+		Username:     data.Username,
+		PasswordHash: data.PasswordHash,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := ur.db.WithContext(ctx).Create(userModel).Error; err != nil {
+		if isPgError(err, uniqueViolationCode) {
+			return nil, domain.ErrUserAlreadyExists
+		}
+		return nil, err
+	}
+
+	return userModelToDomain(userModel), nil
+}
+
+func (ur *UserRepoPostgres) FindUserByUsername(ctx context.Context, username string) (*domain.UserDomain, error) {
+	return ur.findUser(ctx, "username = ?", username)
+}
+
+func (ur *UserRepoPostgres) FindUserById(ctx context.Context, userID int) (*domain.UserDomain, error) {
+	return ur.findUser(ctx, "id = ?", userID)
+}
+
+func (ur *UserRepoPostgres) findUser(ctx context.Context, cond string, value any) (*domain.UserDomain, error) {
+	userModel := &models.User{}
+
+	err := ur.db.WithContext(ctx).Where(cond, value).First(userModel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return userModelToDomain(userModel), nil
+}
+
+func userModelToDomain(el *models.User) *domain.UserDomain {
+	return &domain.UserDomain{
+		ID:           el.ID,
+		Username:     el.Username,
+		PasswordHash: el.PasswordHash,
+		CreatedAt:    el.CreatedAt,
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testtask5/internal/domain"
+	"time"
+)
+
+// sweepInterval - как часто из памяти убираются полные ведра
+const sweepInterval = time.Minute
+
+// MemoryRateLimitStore хранит ведра в памяти процесса. Каждая реплика считает лимиты сама по себе
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens     float64
+	refilledAt time.Time
+	limit      domain.RateLimit
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (ms *MemoryRateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit, now time.Time) (domain.RateLimitResult, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if now.Sub(ms.lastSweep) >= sweepInterval {
+		ms.sweep(now)
+	}
+
+	b, ok := ms.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), refilledAt: now}
+		ms.buckets[key] = b
+	}
+	b.limit = limit
+
+	var result domain.RateLimitResult
+	b.tokens, result = limit.Take(b.tokens, b.refilledAt, now)
+	b.refilledAt = now
+
+	return result, nil
+}
+
+// sweep удаляет ведра, которые успели наполниться: новое ведро ничем от них не отличается
+func (ms *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, b := range ms.buckets {
+		if now.Sub(b.refilledAt) >= b.limit.Period {
+			delete(ms.buckets, key)
+		}
+	}
+	ms.lastSweep = now
+}
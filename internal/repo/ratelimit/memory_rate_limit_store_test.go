@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"testtask5/internal/domain"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	limit := domain.RateLimit{Burst: 3, Period: 3 * time.Second}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("пропускает burst запросов подряд, потом отказывает", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+
+		for i := 2; i >= 0; i-- {
+			result, err := store.Take(ctx, "user:1", limit, start)
+			assert.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, i, result.Remaining)
+		}
+
+		result, err := store.Take(ctx, "user:1", limit, start)
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, time.Second, result.RetryAfter)
+		assert.Equal(t, 3*time.Second, result.ResetAfter)
+	})
+
+	t.Run("ведро пополняется со временем", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "user:1", limit, start)
+		}
+
+		result, _ := store.Take(ctx, "user:1", limit, start.Add(time.Second))
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 0, result.Remaining)
+
+		result, _ = store.Take(ctx, "user:1", limit, start.Add(time.Second))
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("у разных клиентов свои ведра", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		for i := 0; i < 3; i++ {
+			store.Take(ctx, "user:1", limit, start)
+		}
+
+		result, _ := store.Take(ctx, "user:2", limit, start)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("наполнившиеся ведра убираются из памяти", func(t *testing.T) {
+		store := NewMemoryRateLimitStore()
+		store.Take(ctx, "user:1", limit, start)
+		store.Take(ctx, "user:2", limit, start.Add(sweepInterval))
+
+		assert.Len(t, store.buckets, 1)
+		assert.Contains(t, store.buckets, "user:2")
+	})
+}
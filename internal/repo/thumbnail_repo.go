@@ -0,0 +1,16 @@
+package repo
+
+import (
+	"context"
+	"testtask5/internal/domain"
+	"time"
+)
+
+// ThumbnailRepository - очередь картинок, для которых воркер строит превью
+type ThumbnailRepository interface {
+	// ClaimPendingImages забирает картинки в обработку. Картинки, взятые раньше staleBefore,
+	// считаются брошенными и выдаются снова, но не больше maxAttempts раз
+	ClaimPendingImages(ctx context.Context, limit int, staleBefore time.Time, maxAttempts int) ([]*domain.AttachmentDomain, error)
+	SaveThumbnails(ctx context.Context, attachmentID int, width int, height int, thumbnails []*domain.ThumbnailDomain) error
+	MarkThumbnailsFailed(ctx context.Context, attachmentID int) error
+}
@@ -0,0 +1,12 @@
+package repo
+
+import (
+	"context"
+	"testtask5/internal/domain"
+)
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, data *domain.UserDomain) (*domain.UserDomain, error)
+	FindUserByUsername(ctx context.Context, username string) (*domain.UserDomain, error)
+	FindUserById(ctx context.Context, userID int) (*domain.UserDomain, error)
+}
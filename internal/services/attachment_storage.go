@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/repo"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AttachmentLimits - ограничения на вложения одного сообщения.
+// В AllowedTypes допускаются маски вида image/*
+type AttachmentLimits struct {
+	MaxSize      int64
+	MaxCount     int
+	AllowedTypes []string
+}
+
+// AttachmentStorage проверяет вложения по лимитам и кладёт их содержимое в BlobStore
+type AttachmentStorage struct {
+	blobs   repo.BlobStore
+	limits  AttachmentLimits
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+// timeout ограничивает операции с хранилищем. Запрос к API ограничен более коротким таймаутом,
+// а загрузка и скачивание больших файлов должны успеть завершиться
+func NewAttachmentStorage(blobs repo.BlobStore, limits AttachmentLimits, timeout time.Duration, appLogger *zap.Logger) *AttachmentStorage {
+	return &AttachmentStorage{
+		blobs:   blobs,
+		limits:  limits,
+		timeout: timeout,
+		logger:  appLogger.Named("attachment_storage"),
+	}
+}
+
+// multipartOverhead - запас на текстовые поля и заголовки частей multipart-запроса
+const multipartOverhead = 1 << 20
+
+// maxRequestSize - предельный размер запроса с максимальным числом вложений максимального размера
+func (as *AttachmentStorage) maxRequestSize() int64 {
+	return as.limits.MaxSize*int64(as.limits.MaxCount) + multipartOverhead
+}
+
+// validate проверяет количество, размер и тип вложений. Тип определяется по первым байтам содержимого
+func (as *AttachmentStorage) validate(uploads []*domain.AttachmentUpload) error {
+	if len(uploads) > as.limits.MaxCount {
+		return domain.ErrTooManyAttachments
+	}
+
+	for _, upload := range uploads {
+		if upload.Size > as.limits.MaxSize {
+			return domain.ErrAttachmentTooLarge
+		}
+
+		contentType, err := sniffContentType(upload)
+		if err != nil {
+			return err
+		}
+		if !as.typeAllowed(contentType) {
+			return domain.ErrAttachmentType
+		}
+		upload.ContentType = contentType
+	}
+
+	return nil
+}
+
+// store сохраняет содержимое вложений. Если один файл не сохранился, уже сохранённые удаляются
+func (as *AttachmentStorage) store(ctx context.Context, uploads []*domain.AttachmentUpload) ([]*domain.AttachmentDomain, error) {
+	blobCtx, cancel := as.blobContext(ctx)
+	defer cancel()
+
+	attachments := make([]*domain.AttachmentDomain, 0, len(uploads))
+	for _, upload := range uploads {
+		key, err := newStorageKey()
+		if err != nil {
+			as.remove(ctx, attachments)
+			return nil, err
+		}
+
+		//не даём записать больше заявленного размера
+		content := io.LimitReader(upload.Content, upload.Size)
+		if err := as.blobs.Put(blobCtx, key, content, upload.Size, upload.ContentType); err != nil {
+			logging.Logger(ctx, as.logger).Error("не удалось сохранить вложение", zap.String("key", key), zap.Error(err))
+			as.remove(ctx, attachments)
+			return nil, err
+		}
+
+		attachments = append(attachments, &domain.AttachmentDomain{
+			FileName:    upload.FileName,
+			ContentType: upload.ContentType,
+			Size:        upload.Size,
+			StorageKey:  key,
+		})
+	}
+
+	return attachments, nil
+}
+
+// remove удаляет содержимое вложений вместе с превью, ошибки только логируются
+func (as *AttachmentStorage) remove(ctx context.Context, attachments []*domain.AttachmentDomain) {
+	for _, attachment := range attachments {
+		if err := as.delete(ctx, attachment); err != nil {
+			logging.Logger(ctx, as.logger).Warn("не удалось удалить содержимое вложения", zap.String("key", attachment.StorageKey), zap.Error(err))
+		}
+	}
+}
+
+// removeThumbnails удаляет превью, которые не удалось сохранить в базе
+func (as *AttachmentStorage) removeThumbnails(ctx context.Context, thumbnails []*domain.ThumbnailDomain) {
+	keys := make([]string, 0, len(thumbnails))
+	for _, thumbnail := range thumbnails {
+		keys = append(keys, thumbnail.StorageKey)
+	}
+	if err := as.deleteKeys(ctx, keys); err != nil {
+		logging.Logger(ctx, as.logger).Warn("не удалось удалить превью", zap.Error(err))
+	}
+}
+
+// delete удаляет содержимое одного вложения. Превью удаляются раньше оригинала:
+// если удаление прервётся, запись останется и воркер очистки повторит попытку
+func (as *AttachmentStorage) delete(ctx context.Context, attachment *domain.AttachmentDomain) error {
+	keys := make([]string, 0, len(attachment.Thumbnails)+1)
+	for _, thumbnail := range attachment.Thumbnails {
+		keys = append(keys, thumbnail.StorageKey)
+	}
+	return as.deleteKeys(ctx, append(keys, attachment.StorageKey))
+}
+
+// deleteKeys удаляет объекты по очереди и останавливается на первой ошибке
+func (as *AttachmentStorage) deleteKeys(ctx context.Context, keys []string) error {
+	blobCtx, cancel := as.blobContext(ctx)
+	defer cancel()
+
+	for _, key := range keys {
+		if err := as.blobs.Delete(blobCtx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put сохраняет готовое содержимое под заданным ключом
+func (as *AttachmentStorage) put(ctx context.Context, key string, content []byte, contentType string) error {
+	blobCtx, cancel := as.blobContext(ctx)
+	defer cancel()
+
+	return as.blobs.Put(blobCtx, key, bytes.NewReader(content), int64(len(content)), contentType)
+}
+
+// open открывает объект на чтение, таймаут снимается при закрытии
+func (as *AttachmentStorage) open(ctx context.Context, key string) (io.ReadCloser, error) {
+	blobCtx, cancel := as.blobContext(ctx)
+
+	reader, err := as.blobs.Get(blobCtx, key)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &cancelOnClose{ReadCloser: reader, cancel: cancel}, nil
+}
+
+func (as *AttachmentStorage) blobContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), as.timeout)
+}
+
+func (as *AttachmentStorage) typeAllowed(contentType string) bool {
+	for _, allowed := range as.limits.AllowedTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffContentType читает начало файла, определяет тип и возвращает прочитанное обратно в поток
+func sniffContentType(upload *domain.AttachmentUpload) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	head = head[:n]
+
+	upload.Content = io.MultiReader(bytes.NewReader(head), upload.Content)
+
+	//параметры вроде charset в проверке типа не участвуют
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return contentType, nil
+}
+
+// newStorageKey генерирует случайный ключ, первые два символа - подкаталог, чтобы не копить файлы в одном каталоге
+func newStorageKey() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(raw)
+	return key[:2] + "/" + key, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testtask5/internal/domain"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockBlobStore struct {
+	mock.Mock
+}
+
+func (m *MockBlobStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	args := m.Called(ctx, key, content, size, contentType)
+	return args.Error(0)
+}
+
+func (m *MockBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *MockBlobStore) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// testAttachmentLimits - не больше двух вложений по 1 КБ, только картинки и текст
+var testAttachmentLimits = AttachmentLimits{
+	MaxSize:      1024,
+	MaxCount:     2,
+	AllowedTypes: []string{"image/*", "text/plain"},
+}
+
+func setupAttachmentStorage() (*AttachmentStorage, *MockBlobStore) {
+	blobs := new(MockBlobStore)
+	return NewAttachmentStorage(blobs, testAttachmentLimits, time.Minute, zap.NewNop()), blobs
+}
+
+// pngHeader - сигнатура PNG, по которой http.DetectContentType определяет image/png
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func textUpload(name string, content string) *domain.AttachmentUpload {
+	return &domain.AttachmentUpload{FileName: name, Size: int64(len(content)), Content: strings.NewReader(content)}
+}
+
+func TestAttachmentStorage_Validate(t *testing.T) {
+	t.Run("тип определяется по содержимому, а не по заголовку клиента", func(t *testing.T) {
+		storage, _ := setupAttachmentStorage()
+		upload := textUpload("photo.png", pngHeader+"data")
+		upload.ContentType = "application/x-msdownload"
+
+		err := storage.validate([]*domain.AttachmentUpload{upload})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "image/png", upload.ContentType)
+
+		//прочитанное для определения типа возвращается в поток
+		content, _ := io.ReadAll(upload.Content)
+		assert.Equal(t, pngHeader+"data", string(content))
+	})
+
+	t.Run("параметры типа отбрасываются", func(t *testing.T) {
+		storage, _ := setupAttachmentStorage()
+		upload := textUpload("notes.txt", "hello")
+
+		assert.NoError(t, storage.validate([]*domain.AttachmentUpload{upload}))
+		assert.Equal(t, "text/plain", upload.ContentType)
+	})
+
+	t.Run("недопустимый тип", func(t *testing.T) {
+		storage, _ := setupAttachmentStorage()
+		upload := textUpload("doc.pdf", "%PDF-1.7 content")
+
+		err := storage.validate([]*domain.AttachmentUpload{upload})
+
+		assert.ErrorIs(t, err, domain.ErrAttachmentType)
+	})
+
+	t.Run("файл больше лимита", func(t *testing.T) {
+		storage, _ := setupAttachmentStorage()
+		upload := textUpload("big.txt", strings.Repeat("a", 1025))
+
+		err := storage.validate([]*domain.AttachmentUpload{upload})
+
+		assert.ErrorIs(t, err, domain.ErrAttachmentTooLarge)
+	})
+
+	t.Run("слишком много файлов", func(t *testing.T) {
+		storage, _ := setupAttachmentStorage()
+		uploads := []*domain.AttachmentUpload{
+			textUpload("1.txt", "1"), textUpload("2.txt", "2"), textUpload("3.txt", "3"),
+		}
+
+		err := storage.validate(uploads)
+
+		assert.ErrorIs(t, err, domain.ErrTooManyAttachments)
+	})
+}
+
+func TestAttachmentStorage_Store(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("файлы сохраняются под случайными ключами", func(t *testing.T) {
+		storage, blobs := setupAttachmentStorage()
+		uploads := []*domain.AttachmentUpload{textUpload("a.txt", "a"), textUpload("b.txt", "b")}
+		for _, upload := range uploads {
+			upload.ContentType = "text/plain"
+		}
+
+		blobs.On("Put", mock.Anything, mock.Anything, mock.Anything, int64(1), "text/plain").Return(nil).Twice()
+
+		attachments, err := storage.store(ctx, uploads)
+
+		assert.NoError(t, err)
+		if assert.Len(t, attachments, 2) {
+			assert.Equal(t, "a.txt", attachments[0].FileName)
+			assert.NotEqual(t, attachments[0].StorageKey, attachments[1].StorageKey)
+			assert.Regexp(t, `^[0-9a-f]{2}/[0-9a-f]{32}$`, attachments[0].StorageKey)
+		}
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("при ошибке уже сохранённые файлы удаляются", func(t *testing.T) {
+		storage, blobs := setupAttachmentStorage()
+		uploads := []*domain.AttachmentUpload{textUpload("a.txt", "a"), textUpload("b.txt", "b")}
+
+		var firstKey string
+		blobs.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { firstKey = args.String(1) }).
+			Return(nil).Once()
+		blobs.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(errors.New("disk full")).Once()
+		blobs.On("Delete", mock.Anything, mock.MatchedBy(func(key string) bool { return key == firstKey })).
+			Return(nil).Once()
+
+		attachments, err := storage.store(ctx, uploads)
+
+		assert.Nil(t, attachments)
+		assert.Error(t, err)
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("таймаут хранилища не зависит от отмены запроса", func(t *testing.T) {
+		storage, blobs := setupAttachmentStorage()
+		reqCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		blobs.On("Put", mock.MatchedBy(func(blobCtx context.Context) bool { return blobCtx.Err() == nil }),
+			mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+		_, err := storage.store(reqCtx, []*domain.AttachmentUpload{textUpload("a.txt", "a")})
+
+		assert.NoError(t, err)
+		blobs.AssertExpectations(t)
+	})
+}
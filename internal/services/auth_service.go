@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/repo"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AuthService struct {
+	userRepo      repo.UserRepository
+	tokens        *auth.TokenManager
+	serviceLogger *zap.Logger
+}
+
+func NewAuthService(uRepo repo.UserRepository, tokens *auth.TokenManager, appLogger *zap.Logger) *AuthService {
+	serviceLogger := appLogger.Named("auth_service")
+	return &AuthService{
+		userRepo:      uRepo,
+		tokens:        tokens,
+		serviceLogger: serviceLogger,
+	}
+}
+
+// Register создаёт пользователя, пароль хранится только в виде bcrypt-хэша
+func (as *AuthService) Register(ctx context.Context, username string, password string) (*domain.UserDomain, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		logging.Logger(ctx, as.serviceLogger).Error("не удалось захэшировать пароль", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := as.userRepo.CreateUser(ctx, &domain.UserDomain{
+		Username:     username,
+		PasswordHash: string(hash),
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrUserAlreadyExists) {
+			logging.Logger(ctx, as.serviceLogger).Error("не удалось создать пользователя", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Login проверяет пароль и выдаёт пару токенов
+func (as *AuthService) Login(ctx context.Context, username string, password string) (*domain.TokenPair, error) {
+	user, err := as.userRepo.FindUserByUsername(ctx, username)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		logging.Logger(ctx, as.serviceLogger).Error("не удалось найти пользователя", zap.Error(err))
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	return as.issue(ctx, user)
+}
+
+// Refresh обменивает refresh-токен на новую пару, если пользователь ещё существует
+func (as *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	claimed, err := as.tokens.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := as.userRepo.FindUserById(ctx, claimed.ID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, domain.ErrInvalidToken
+	}
+	if err != nil {
+		logging.Logger(ctx, as.serviceLogger).Error("не удалось найти пользователя", zap.Error(err))
+		return nil, err
+	}
+
+	return as.issue(ctx, user)
+}
+
+// ParseAccessToken проверяет access-токен и возвращает пользователя из него
+func (as *AuthService) ParseAccessToken(token string) (*domain.UserDomain, error) {
+	return as.tokens.ParseAccessToken(token)
+}
+
+func (as *AuthService) issue(ctx context.Context, user *domain.UserDomain) (*domain.TokenPair, error) {
+	pair, err := as.tokens.IssuePair(user)
+	if err != nil {
+		logging.Logger(ctx, as.serviceLogger).Error("не удалось выпустить токены", zap.Error(err))
+		return nil, err
+	}
+	return pair, nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) CreateUser(ctx context.Context, data *domain.UserDomain) (*domain.UserDomain, error) {
+	args := m.Called(ctx, data)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserDomain), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserByUsername(ctx context.Context, username string) (*domain.UserDomain, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserDomain), args.Error(1)
+}
+
+func (m *MockUserRepository) FindUserById(ctx context.Context, userID int) (*domain.UserDomain, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserDomain), args.Error(1)
+}
+
+func setupAuthTest(t *testing.T) (*AuthService, *MockUserRepository) {
+	mockUserRepo := new(MockUserRepository)
+	tokens := auth.NewTokenManager([]byte("test-secret"), time.Minute, time.Hour)
+	svc := NewAuthService(mockUserRepo, tokens, zap.NewNop())
+	return svc, mockUserRepo
+}
+
+func TestAuthService_Register(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("пароль сохраняется только в виде хэша", func(t *testing.T) {
+		svc, mockUserRepo := setupAuthTest(t)
+		created := &domain.UserDomain{ID: 1, Username: "alice"}
+
+		mockUserRepo.On("CreateUser", ctx, mock.MatchedBy(func(u *domain.UserDomain) bool {
+			return u.Username == "alice" &&
+				u.PasswordHash != "password123" &&
+				bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("password123")) == nil
+		})).Return(created, nil)
+
+		result, err := svc.Register(ctx, "alice", "password123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, created, result)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("пользователь уже существует", func(t *testing.T) {
+		svc, mockUserRepo := setupAuthTest(t)
+
+		mockUserRepo.On("CreateUser", ctx, mock.Anything).Return(nil, domain.ErrUserAlreadyExists)
+
+		result, err := svc.Register(ctx, "alice", "password123")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrUserAlreadyExists)
+	})
+}
+
+func TestAuthService_Login(t *testing.T) {
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &domain.UserDomain{ID: 5, Username: "alice", PasswordHash: string(hash)}
+
+	t.Run("успешный вход - access-токен валиден", func(t *testing.T) {
+		svc, mockUserRepo := setupAuthTest(t)
+
+		mockUserRepo.On("FindUserByUsername", ctx, "alice").Return(user, nil)
+
+		pair, err := svc.Login(ctx, "alice", "password123")
+
+		assert.NoError(t, err)
+		parsed, err := svc.ParseAccessToken(pair.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, parsed.ID)
+		assert.Equal(t, "alice", parsed.Username)
+	})
+
+	t.Run("refresh-токен не принимается как access", func(t *testing.T) {
+		svc, mockUserRepo := setupAuthTest(t)
+
+		mockUserRepo.On("FindUserByUsername", ctx, "alice").Return(user, nil)
+
+		pair, err := svc.Login(ctx, "alice", "password123")
+		assert.NoError(t, err)
+
+		_, err = svc.ParseAccessToken(pair.RefreshToken)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("неверный пароль", func(t *testing.T) {
+		svc, mockUserRepo := setupAuthTest(t)
+
+		mockUserRepo.On("FindUserByUsername", ctx, "alice").Return(user, nil)
+
+		pair, err := svc.Login(ctx, "alice", "wrong-password")
+
+		assert.Nil(t, pair)
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	})
+
+	t.Run("несуществующий пользователь", func(t *testing.T) {
+		svc, mockUserRepo := setupAuthTest(t)
+
+		mockUserRepo.On("FindUserByUsername", ctx, "bob").Return(nil, domain.ErrUserNotFound)
+
+		pair, err := svc.Login(ctx, "bob", "password123")
+
+		assert.Nil(t, pair)
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	})
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	ctx := context.Background()
+	user := &domain.UserDomain{ID: 5, Username: "alice"}
+
+	t.Run("обмен refresh-токена на новую пару", func(t *testing.T) {
+		svc, mockUserRepo := setupAuthTest(t)
+		tokens := auth.NewTokenManager([]byte("test-secret"), time.Minute, time.Hour)
+		pair, _ := tokens.IssuePair(user)
+
+		mockUserRepo.On("FindUserById", ctx, 5).Return(user, nil)
+
+		refreshed, err := svc.Refresh(ctx, pair.RefreshToken)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, refreshed.AccessToken)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("access-токен не подходит для refresh", func(t *testing.T) {
+		svc, _ := setupAuthTest(t)
+		tokens := auth.NewTokenManager([]byte("test-secret"), time.Minute, time.Hour)
+		pair, _ := tokens.IssuePair(user)
+
+		refreshed, err := svc.Refresh(ctx, pair.AccessToken)
+
+		assert.Nil(t, refreshed)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("токен подписан чужим ключом", func(t *testing.T) {
+		svc, _ := setupAuthTest(t)
+		tokens := auth.NewTokenManager([]byte("other-secret"), time.Minute, time.Hour)
+		pair, _ := tokens.IssuePair(user)
+
+		refreshed, err := svc.Refresh(ctx, pair.RefreshToken)
+
+		assert.Nil(t, refreshed)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+}
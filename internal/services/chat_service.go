@@ -2,32 +2,73 @@ package services
 
 import (
 	"context"
+	"errors"
+	"testtask5/internal/auth"
 	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/realtime"
 	"testtask5/internal/repo"
+	"testtask5/internal/tracing"
+	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type ChatService struct {
 	chatRepo      repo.ChatRepostiory
 	messageRepo   repo.MessageRepostiory
+	memberRepo    repo.ChatMemberRepository
+	pinRepo       repo.PinRepository
+	guard         memberGuard
+	events        *EventStream
+	attachments   *AttachmentStorage
+	pinLimit      int
+	retention     time.Duration
+	tracer        trace.Tracer
 	serviceLogger *zap.Logger
 }
 
-func NewChatService(mRepo repo.MessageRepostiory, cRepo repo.ChatRepostiory, appLogger *zap.Logger) *ChatService {
+// archivedChatsBatch - сколько архивных чатов воркер удаляет за один проход
+const archivedChatsBatch = 100
+
+// pinLimit - сколько сообщений можно закрепить в одном чате,
+// retention - сколько чат хранится в архиве, после этого его удаляет воркер очистки
+func NewChatService(mRepo repo.MessageRepostiory, cRepo repo.ChatRepostiory, memberRepo repo.ChatMemberRepository, pinRepo repo.PinRepository, events *EventStream, attachments *AttachmentStorage, pinLimit int, retention time.Duration, appLogger *zap.Logger) *ChatService {
 	serviceLogger := appLogger.Named("chat_service")
 	return &ChatService{
 		chatRepo:      cRepo,
 		messageRepo:   mRepo,
+		memberRepo:    memberRepo,
+		pinRepo:       pinRepo,
+		guard:         memberGuard{memberRepo: memberRepo},
+		events:        events,
+		attachments:   attachments,
+		pinLimit:      pinLimit,
+		retention:     retention,
+		tracer:        tracing.Tracer("chat_service"),
 		serviceLogger: serviceLogger,
 	}
 }
 
 func (cs *ChatService) ChatExists(ctx context.Context, param repo.FilterParam) (bool, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.ChatExists")
+	defer span.End()
+
 	return cs.chatRepo.ChatExists(ctx, param)
 }
 
+// CreateChat создаёт чат, создатель становится его владельцем
 func (cs *ChatService) CreateChat(ctx context.Context, chat *domain.ChatDomain) (*domain.ChatDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.CreateChat")
+	defer span.End()
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	chat.OwnerID = user.ID
+
 	chatExists, err := cs.ChatExists(ctx, repo.FilterParam{Field: "title", Value: chat.Title})
 
 	if chatExists {
@@ -47,7 +88,10 @@ func (cs *ChatService) CreateChat(ctx context.Context, chat *domain.ChatDomain)
 	return chat, nil
 }
 
-func (cs *ChatService) GetChatById(ctx context.Context, chatID int, limit int) (*domain.ChatDomain, error) {
+func (cs *ChatService) GetChatById(ctx context.Context, chatID int, cursor repo.CursorParam) (*domain.ChatDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.GetChatById")
+	defer span.End()
+
 	chatDomain := &domain.ChatDomain{
 		ID: chatID,
 	}
@@ -57,14 +101,164 @@ func (cs *ChatService) GetChatById(ctx context.Context, chatID int, limit int) (
 		return nil, domain.ErrChatNotFound
 	}
 
-	messages := cs.messageRepo.GetMessagesByChaWithLimit(ctx, chat.ID, limit)
+	//архивный чат не читается, пока его не восстановят
+	if chat.ArchivedAt != nil {
+		return nil, domain.ErrChatNotFound
+	}
+
+	if _, err := cs.guard.requireRole(ctx, chat.ID, domain.RoleMember); err != nil {
+		return nil, err
+	}
 
-	chat.Messages = messages
+	page, err := cs.messageRepo.GetMessagesByChatWithCursor(ctx, chat.ID, cursor)
+	if err != nil {
+		logging.Logger(ctx, cs.serviceLogger).Error("не удалось получить сообщения чата", zap.Error(err))
+		return nil, err
+	}
+
+	chat.Messages = page.Messages
+	chat.NextCursor, chat.PrevCursor = page.Cursors()
+
+	chat.Pinned, err = cs.pinRepo.ListPinned(ctx, chat.ID)
+	if err != nil {
+		logging.Logger(ctx, cs.serviceLogger).Error("не удалось получить закреплённые сообщения", zap.Error(err))
+		return nil, err
+	}
 
 	return chat, nil
 }
 
-func (cs *ChatService) DeleteChatByID(ctx context.Context, chatID int) error {
+// ListChats возвращает только чаты, в которых состоит текущий пользователь.
+// Архивные чаты попадают в список, только если они запрошены явно
+func (cs *ChatService) ListChats(ctx context.Context, params repo.ListParam) (*domain.ChatList, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.ListChats")
+	defer span.End()
+
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	params.MemberID = user.ID
+
+	chats, err := cs.chatRepo.ListChats(ctx, params)
+	if err != nil {
+		if !errors.Is(err, domain.ErrFieldIsNotAllowed) && !errors.Is(err, domain.ErrInvalidCursor) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось получить список чатов", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return chats, nil
+}
+
+// RenameChat меняет название чата, доступно админам и владельцу.
+// version - версия чата, которую видел клиент (0 - без проверки)
+func (cs *ChatService) RenameChat(ctx context.Context, chatID int, title string, version int) (*domain.ChatDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.RenameChat")
+	defer span.End()
+
+	if _, err := cs.activeChat(ctx, chatID); err != nil {
+		return nil, err
+	}
+
+	if _, err := cs.guard.requireRole(ctx, chatID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	titleTaken, err := cs.ChatExists(ctx, repo.FilterParam{Field: "title", Value: title})
+	if err != nil {
+		return nil, domain.ErrFieldIsNotAllowed
+	}
+
+	if titleTaken {
+		return nil, domain.ErrChatAlreadyExists
+	}
+
+	chat, err := cs.chatRepo.UpdateChatTitle(ctx, chatID, title, version)
+	if err != nil {
+		if !errors.Is(err, domain.ErrChatNotFound) && !errors.Is(err, domain.ErrVersionMismatch) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось переименовать чат", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	cs.events.Publish(ctx, &domain.ChatEvent{
+		Type:   domain.EventChatRenamed,
+		ChatID: chatID,
+		Chat:   chat,
+	})
+
+	return chat, nil
+}
+
+// ArchiveChat переносит чат в архив. Сообщения сохраняются, чат можно восстановить, пока не истёк срок хранения
+func (cs *ChatService) ArchiveChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.ArchiveChat")
+	defer span.End()
+
+	return cs.setArchived(ctx, chatID, version, true)
+}
+
+// RestoreChat возвращает чат из архива
+func (cs *ChatService) RestoreChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.RestoreChat")
+	defer span.End()
+
+	return cs.setArchived(ctx, chatID, version, false)
+}
+
+// setArchived переносит чат в архив или обратно, это может только владелец
+func (cs *ChatService) setArchived(ctx context.Context, chatID int, version int, archive bool) (*domain.ChatDomain, error) {
+	chatExists, err := cs.ChatExists(ctx, repo.FilterParam{Field: "id", Value: chatID})
+	if err != nil {
+		return nil, domain.ErrFieldIsNotAllowed
+	}
+
+	if !chatExists {
+		return nil, domain.ErrChatNotFound
+	}
+
+	if _, err := cs.guard.requireRole(ctx, chatID, domain.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	eventType := domain.EventChatRestored
+	change := cs.chatRepo.RestoreChat
+	if archive {
+		eventType = domain.EventChatArchived
+		change = cs.chatRepo.ArchiveChat
+	}
+
+	chat, err := change(ctx, chatID, version)
+	if err != nil {
+		if !errors.Is(err, domain.ErrChatNotFound) && !errors.Is(err, domain.ErrChatArchived) &&
+			!errors.Is(err, domain.ErrChatNotArchived) && !errors.Is(err, domain.ErrVersionMismatch) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось изменить архивный статус чата", zap.Bool("archive", archive), zap.Error(err))
+		}
+		return nil, err
+	}
+
+	cs.events.Publish(ctx, &domain.ChatEvent{
+		Type:   eventType,
+		ChatID: chatID,
+		Chat:   chat,
+	})
+
+	return chat, nil
+}
+
+// PurgeArchivedChats безвозвратно удаляет чаты, которые пролежали в архиве дольше срока хранения
+func (cs *ChatService) PurgeArchivedChats(ctx context.Context) (int64, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.PurgeArchivedChats")
+	defer span.End()
+
+	return cs.chatRepo.PurgeArchivedChats(ctx, time.Now().Add(-cs.retention), archivedChatsBatch)
+}
+
+// DeleteChatByID удаляет чат безвозвратно вместе с сообщениями
+func (cs *ChatService) DeleteChatByID(ctx context.Context, chatID int, version int) error {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.DeleteChatByID")
+	defer span.End()
 
 	chatExists, err := cs.ChatExists(ctx, repo.FilterParam{Field: "id", Value: chatID})
 
@@ -76,11 +270,24 @@ func (cs *ChatService) DeleteChatByID(ctx context.Context, chatID int) error {
 		return domain.ErrChatNotFound
 	}
 
-	if err := cs.chatRepo.DeleteChat(ctx, chatID); err != nil {
-		cs.serviceLogger.Error("не удалось удалить чат", zap.Error(err))
+	//удалить чат может только владелец
+	if _, err := cs.guard.requireRole(ctx, chatID, domain.RoleOwner); err != nil {
+		return err
+	}
+
+	if err := cs.chatRepo.DeleteChat(ctx, chatID, version); err != nil {
+		if !errors.Is(err, domain.ErrChatNotFound) && !errors.Is(err, domain.ErrVersionMismatch) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось удалить чат", zap.Error(err))
+		}
 		return err
 	}
 
+	cs.events.Publish(ctx, &domain.ChatEvent{
+		Type:   domain.EventChatDeleted,
+		ChatID: chatID,
+		Chat:   &domain.ChatDomain{ID: chatID},
+	})
+
 	//УДАЛЯЕМ ВСЕ СООБЩЕНИЯ, не обязательно, так как в бд уже есть ON_DELETE
 	// if err := cs.messageRepo.DeleteMessages(ctx, chatID); err != nil {
 	// 	cs.serviceLogger.Error("не удалось удалить чат", zap.Error(err))
@@ -91,22 +298,406 @@ func (cs *ChatService) DeleteChatByID(ctx context.Context, chatID int) error {
 }
 
 func (cs *ChatService) SendMessage(ctx context.Context, message *domain.MessageDomain) (*domain.MessageDomain, error) {
-	chatExists, err := cs.ChatExists(ctx, repo.FilterParam{Field: "id", Value: message.ChatID})
+	ctx, span := cs.tracer.Start(ctx, "ChatService.SendMessage")
+	defer span.End()
 
-	if err != nil {
-		return nil, domain.ErrFieldIsNotAllowed
+	return cs.SendMessageWithAttachments(ctx, message, nil)
+}
+
+// MaxUploadSize - предельный размер запроса на отправку сообщения с вложениями
+func (cs *ChatService) MaxUploadSize() int64 {
+	return cs.attachments.maxRequestSize()
+}
+
+// SendMessageWithAttachments создаёт сообщение с вложениями. Текст можно не указывать, если есть хотя бы одно вложение
+func (cs *ChatService) SendMessageWithAttachments(ctx context.Context, message *domain.MessageDomain, uploads []*domain.AttachmentUpload) (*domain.MessageDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.SendMessageWithAttachments")
+	defer span.End()
+
+	if message.Text == "" && len(uploads) == 0 {
+		return nil, domain.ErrEmptyMessage
 	}
 
-	if !chatExists {
-		return nil, domain.ErrChatNotFound
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	message.AuthorID = &user.ID
+
+	//в архивный чат писать нельзя
+	if _, err := cs.activeChat(ctx, message.ChatID); err != nil {
+		return nil, err
+	}
+
+	if _, err := cs.guard.requireRole(ctx, message.ChatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	if message.ParentMessageID != nil {
+		if err := cs.resolveParent(ctx, message); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(uploads) > 0 {
+		if err := cs.attachments.validate(uploads); err != nil {
+			return nil, err
+		}
+
+		attachments, err := cs.attachments.store(ctx, uploads)
+		if err != nil {
+			return nil, err
+		}
+		message.Attachments = attachments
 	}
 
 	//создаём сообщение
-	message, err = cs.messageRepo.CreateMessage(ctx, message)
+	created, err := cs.messageRepo.CreateMessage(ctx, message)
 	if err != nil {
-		cs.serviceLogger.Error("не удалось создать сообщение", zap.Error(err))
+		logging.Logger(ctx, cs.serviceLogger).Error("не удалось создать сообщение", zap.Error(err))
+		//содержимое без записи в базе никому не доступно, удаляем его сразу
+		if len(message.Attachments) > 0 {
+			cs.attachments.remove(ctx, message.Attachments)
+		}
 		return nil, err
 	}
+	message = created
+
+	cs.events.publishMessage(ctx, domain.EventMessageCreated, message)
 
 	return message, nil
 }
+
+// SubscribeChat подписывает участника чата на события в реальном времени.
+// Подписку нужно вернуть через Unsubscribe, когда соединение закрыто
+func (cs *ChatService) SubscribeChat(ctx context.Context, chatID int) (*realtime.Subscription, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.SubscribeChat")
+	defer span.End()
+
+	if err := cs.readableChat(ctx, chatID); err != nil {
+		return nil, err
+	}
+
+	member, err := cs.guard.requireRole(ctx, chatID, domain.RoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	//подписка закрывается, когда истекает токен или участника исключают из чата
+	user, _ := auth.UserFromContext(ctx)
+	return cs.events.Subscribe(chatID, member.UserID, user.TokenExpiresAt), nil
+}
+
+func (cs *ChatService) Unsubscribe(sub *realtime.Subscription) {
+	cs.events.Unsubscribe(sub)
+}
+
+// ReplayEvents отдаёт участнику события чата из журнала, пропущенные после afterID
+func (cs *ChatService) ReplayEvents(ctx context.Context, chatID int, afterID int64, limit int) ([]*domain.ChatEvent, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.ReplayEvents")
+	defer span.End()
+
+	if err := cs.readableChat(ctx, chatID); err != nil {
+		return nil, err
+	}
+
+	if _, err := cs.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	events, err := cs.events.EventsAfter(ctx, chatID, afterID, limit)
+	if err != nil {
+		logging.Logger(ctx, cs.serviceLogger).Error("не удалось прочитать журнал событий", zap.Error(err))
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// activeChat возвращает чат, если он существует и не в архиве
+func (cs *ChatService) activeChat(ctx context.Context, chatID int) (*domain.ChatDomain, error) {
+	chat, err := cs.chatRepo.FindChatById(ctx, &domain.ChatDomain{ID: chatID})
+	if err != nil {
+		if !errors.Is(err, domain.ErrChatNotFound) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось получить чат", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	if chat.ArchivedAt != nil {
+		return nil, domain.ErrChatArchived
+	}
+
+	return chat, nil
+}
+
+// readableChat проверяет, что чат существует и не в архиве. Архивный чат для чтения скрыт,
+// поэтому вместо ErrChatArchived возвращается ErrChatNotFound, как в GetChatById
+func (cs *ChatService) readableChat(ctx context.Context, chatID int) error {
+	_, err := cs.activeChat(ctx, chatID)
+	if errors.Is(err, domain.ErrChatArchived) {
+		return domain.ErrChatNotFound
+	}
+	return err
+}
+
+// resolveParent проверяет, что родитель ответа из того же чата, и привязывает ответ к корню треда
+func (cs *ChatService) resolveParent(ctx context.Context, message *domain.MessageDomain) error {
+	parent, err := cs.messageRepo.FindMessageById(ctx, message.ChatID, *message.ParentMessageID)
+	if errors.Is(err, domain.ErrMessageNotFound) {
+		return domain.ErrParentNotInChat
+	}
+	if err != nil {
+		return err
+	}
+
+	if parent.DeletedAt != nil {
+		return domain.ErrMessageDeleted
+	}
+
+	//треды одноуровневые: ответ на ответ уходит в тред корня
+	if parent.ParentMessageID != nil {
+		message.ParentMessageID = parent.ParentMessageID
+	}
+
+	return nil
+}
+
+func (cs *ChatService) ListMembers(ctx context.Context, chatID int) ([]*domain.ChatMemberDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.ListMembers")
+	defer span.End()
+
+	if err := cs.readableChat(ctx, chatID); err != nil {
+		return nil, err
+	}
+
+	if _, err := cs.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	members, err := cs.memberRepo.ListMembers(ctx, chatID)
+	if err != nil {
+		logging.Logger(ctx, cs.serviceLogger).Error("не удалось получить участников чата", zap.Error(err))
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// AddMember добавляет участника. Участников добавляют админы, админов - только владелец
+func (cs *ChatService) AddMember(ctx context.Context, member *domain.ChatMemberDomain) (*domain.ChatMemberDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.AddMember")
+	defer span.End()
+
+	actor, err := cs.guard.requireRole(ctx, member.ChatID, domain.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if !member.Role.IsValid() || member.Role == domain.RoleOwner {
+		return nil, domain.ErrInvalidRole
+	}
+	if member.Role == domain.RoleAdmin && actor.Role != domain.RoleOwner {
+		return nil, domain.ErrForbidden
+	}
+
+	added, err := cs.memberRepo.AddMember(ctx, member)
+	if err != nil {
+		if !errors.Is(err, domain.ErrMemberExists) && !errors.Is(err, domain.ErrUserNotFound) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось добавить участника", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return added, nil
+}
+
+// ChangeMemberRole меняет роль участника, доступно только владельцу. Владение передаётся через TransferOwnership
+func (cs *ChatService) ChangeMemberRole(ctx context.Context, chatID int, userID int, role domain.ChatRole) (*domain.ChatMemberDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.ChangeMemberRole")
+	defer span.End()
+
+	actor, err := cs.guard.requireRole(ctx, chatID, domain.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if !role.IsValid() || role == domain.RoleOwner || userID == actor.UserID {
+		return nil, domain.ErrInvalidRole
+	}
+
+	updated, err := cs.memberRepo.UpdateMemberRole(ctx, chatID, userID, role)
+	if err != nil {
+		if !errors.Is(err, domain.ErrMemberNotFound) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось изменить роль участника", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// RemoveMember исключает участника с ролью ниже своей или позволяет выйти из чата самому
+func (cs *ChatService) RemoveMember(ctx context.Context, chatID int, userID int) error {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.RemoveMember")
+	defer span.End()
+
+	actor, err := cs.guard.requireRole(ctx, chatID, domain.RoleMember)
+	if err != nil {
+		return err
+	}
+
+	if userID == actor.UserID {
+		if actor.Role == domain.RoleOwner {
+			return domain.ErrOwnerCannotLeave
+		}
+	} else {
+		if !actor.Role.AtLeast(domain.RoleAdmin) {
+			return domain.ErrForbidden
+		}
+
+		target, err := cs.memberRepo.FindMember(ctx, chatID, userID)
+		if err != nil {
+			return err
+		}
+		if target.Role.AtLeast(actor.Role) {
+			return domain.ErrForbidden
+		}
+	}
+
+	if err := cs.memberRepo.RemoveMember(ctx, chatID, userID); err != nil {
+		if !errors.Is(err, domain.ErrMemberNotFound) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось исключить участника", zap.Error(err))
+		}
+		return err
+	}
+
+	//событие закрывает открытые подписки исключённого участника
+	cs.events.Publish(ctx, &domain.ChatEvent{
+		Type:     domain.EventMemberRemoved,
+		ChatID:   chatID,
+		ActorID:  &actor.UserID,
+		MemberID: &userID,
+	})
+
+	return nil
+}
+
+// TransferOwnership передаёт владение другому участнику, прежний владелец становится админом
+func (cs *ChatService) TransferOwnership(ctx context.Context, chatID int, toUserID int) error {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.TransferOwnership")
+	defer span.End()
+
+	actor, err := cs.guard.requireRole(ctx, chatID, domain.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if toUserID == actor.UserID {
+		return domain.ErrInvalidRole
+	}
+
+	if err := cs.memberRepo.TransferOwnership(ctx, chatID, actor.UserID, toUserID); err != nil {
+		if !errors.Is(err, domain.ErrMemberNotFound) && !errors.Is(err, domain.ErrForbidden) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось передать владение чатом", zap.Error(err))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ListPinned возвращает закреплённые сообщения чата
+func (cs *ChatService) ListPinned(ctx context.Context, chatID int) ([]*domain.PinDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.ListPinned")
+	defer span.End()
+
+	if err := cs.readableChat(ctx, chatID); err != nil {
+		return nil, err
+	}
+
+	if _, err := cs.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	return cs.pinRepo.ListPinned(ctx, chatID)
+}
+
+// PinMessage закрепляет сообщение в чате. Закреплять могут администраторы и владелец
+func (cs *ChatService) PinMessage(ctx context.Context, chatID int, messageID int) (*domain.PinDomain, error) {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.PinMessage")
+	defer span.End()
+
+	if _, err := cs.activeChat(ctx, chatID); err != nil {
+		return nil, err
+	}
+
+	member, err := cs.guard.requireRole(ctx, chatID, domain.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := cs.messageRepo.FindMessageById(ctx, chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.DeletedAt != nil {
+		return nil, domain.ErrMessageDeleted
+	}
+
+	pin, err := cs.pinRepo.PinMessage(ctx, &domain.PinDomain{
+		ChatID:    chatID,
+		MessageID: messageID,
+		PinnedBy:  &member.UserID,
+	}, cs.pinLimit)
+	if err != nil {
+		if !errors.Is(err, domain.ErrAlreadyPinned) && !errors.Is(err, domain.ErrPinLimitReached) {
+			logging.Logger(ctx, cs.serviceLogger).Error("не удалось закрепить сообщение", zap.Error(err))
+		}
+		return nil, err
+	}
+	pin.Message = message
+
+	cs.events.Publish(ctx, &domain.ChatEvent{
+		Type:    domain.EventMessagePinned,
+		ChatID:  chatID,
+		Message: message,
+		ActorID: &member.UserID,
+	})
+
+	return pin, nil
+}
+
+// UnpinMessage открепляет сообщение, права те же, что на закрепление
+func (cs *ChatService) UnpinMessage(ctx context.Context, chatID int, messageID int) error {
+	ctx, span := cs.tracer.Start(ctx, "ChatService.UnpinMessage")
+	defer span.End()
+
+	if _, err := cs.activeChat(ctx, chatID); err != nil {
+		return err
+	}
+
+	member, err := cs.guard.requireRole(ctx, chatID, domain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	message, err := cs.messageRepo.FindMessageById(ctx, chatID, messageID)
+	if err != nil {
+		return err
+	}
+
+	if err := cs.pinRepo.UnpinMessage(ctx, chatID, messageID); err != nil {
+		return err
+	}
+
+	cs.events.Publish(ctx, &domain.ChatEvent{
+		Type:    domain.EventMessageUnpinned,
+		ChatID:  chatID,
+		Message: message,
+		ActorID: &member.UserID,
+	})
+
+	return nil
+}
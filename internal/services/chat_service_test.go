@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"testtask5/internal/auth"
 	"testtask5/internal/domain"
+	"testtask5/internal/realtime"
 	"testtask5/internal/repo"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -48,58 +51,330 @@ func (m *MockChatRepository) FindChatById(ctx context.Context, data *domain.Chat
 	return args.Get(0).(*domain.ChatDomain), args.Error(1)
 }
 
-func (m *MockChatRepository) DeleteChat(ctx context.Context, chatID int) error {
-	args := m.Called(ctx, chatID)
+func (m *MockChatRepository) ListChats(ctx context.Context, params repo.ListParam) (*domain.ChatList, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChatList), args.Error(1)
+}
+
+func (m *MockChatRepository) UpdateChatTitle(ctx context.Context, chatID int, title string, version int) (*domain.ChatDomain, error) {
+	args := m.Called(ctx, chatID, title, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChatDomain), args.Error(1)
+}
+
+func (m *MockChatRepository) ArchiveChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error) {
+	args := m.Called(ctx, chatID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChatDomain), args.Error(1)
+}
+
+func (m *MockChatRepository) RestoreChat(ctx context.Context, chatID int, version int) (*domain.ChatDomain, error) {
+	args := m.Called(ctx, chatID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChatDomain), args.Error(1)
+}
+
+func (m *MockChatRepository) DeleteChat(ctx context.Context, chatID int, version int) error {
+	args := m.Called(ctx, chatID, version)
 	return args.Error(0)
 }
 
+func (m *MockChatRepository) PurgeArchivedChats(ctx context.Context, archivedBefore time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, archivedBefore, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockMessageRepository) CreateMessage(ctx context.Context, data *domain.MessageDomain) (*domain.MessageDomain, error) {
 	args := m.Called(ctx, data)
 	return args.Get(0).(*domain.MessageDomain), args.Error(1)
 }
 
-func (m *MockMessageRepository) GetMessagesByChaWithLimit(ctx context.Context, chatID int, limit int) []*domain.MessageDomain {
-	args := m.Called(ctx, chatID, limit)
-	return args.Get(0).([]*domain.MessageDomain)
+func (m *MockMessageRepository) GetMessagesByChatWithCursor(ctx context.Context, chatID int, cursor repo.CursorParam) (*domain.MessagePage, error) {
+	args := m.Called(ctx, chatID, cursor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessagePage), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetThreadWithCursor(ctx context.Context, chatID int, rootID int, cursor repo.CursorParam) (*domain.MessagePage, error) {
+	args := m.Called(ctx, chatID, rootID, cursor)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessagePage), args.Error(1)
+}
+
+func (m *MockMessageRepository) FindMessageById(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error) {
+	args := m.Called(ctx, chatID, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessageDomain), args.Error(1)
+}
+
+func (m *MockMessageRepository) UpdateMessageText(ctx context.Context, data *domain.MessageDomain) (*domain.MessageDomain, error) {
+	args := m.Called(ctx, data)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessageDomain), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetMessageRevisions(ctx context.Context, messageID int) ([]*domain.MessageRevisionDomain, error) {
+	args := m.Called(ctx, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.MessageRevisionDomain), args.Error(1)
+}
+
+func (m *MockMessageRepository) SoftDeleteMessage(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error) {
+	args := m.Called(ctx, chatID, messageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessageDomain), args.Error(1)
+}
+
+func (m *MockMessageRepository) RestoreMessage(ctx context.Context, chatID int, messageID int, deletedAfter time.Time) (*domain.MessageDomain, error) {
+	args := m.Called(ctx, chatID, messageID, deletedAfter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessageDomain), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetChangesSince(ctx context.Context, chatID int, sinceSeq int64, limit int) (*domain.MessageChanges, error) {
+	args := m.Called(ctx, chatID, sinceSeq, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessageChanges), args.Error(1)
+}
+
+func (m *MockMessageRepository) PurgeDeletedMessages(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageRepository) AddReaction(ctx context.Context, reaction *domain.ReactionDomain) error {
+	args := m.Called(ctx, reaction)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) RemoveReaction(ctx context.Context, reaction *domain.ReactionDomain) error {
+	args := m.Called(ctx, reaction)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) GetReactionCounts(ctx context.Context, messageIDs []int) (map[int][]domain.ReactionCount, error) {
+	args := m.Called(ctx, messageIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int][]domain.ReactionCount), args.Error(1)
+}
+
+func (m *MockMessageRepository) SearchMessages(ctx context.Context, params repo.SearchParam) (*domain.MessageSearchResult, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessageSearchResult), args.Error(1)
 }
 
 func (m *MockMessageRepository) DeleteMessages(ctx context.Context, chatID int) error {
 	args := m.Called(ctx, chatID)
 	return args.Error(0)
 }
-func setupTest(t *testing.T) (*ChatService, *MockChatRepository, *MockMessageRepository) {
+
+func (m *MockMessageRepository) FindAttachment(ctx context.Context, chatID int, messageID int, attachmentID int) (*domain.AttachmentDomain, error) {
+	args := m.Called(ctx, chatID, messageID, attachmentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AttachmentDomain), args.Error(1)
+}
+
+func (m *MockMessageRepository) ListOrphanAttachments(ctx context.Context, limit int) ([]*domain.AttachmentDomain, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AttachmentDomain), args.Error(1)
+}
+
+func (m *MockMessageRepository) DeleteAttachments(ctx context.Context, attachmentIDs []int) error {
+	args := m.Called(ctx, attachmentIDs)
+	return args.Error(0)
+}
+
+type MockChatMemberRepository struct {
+	mock.Mock
+}
+
+func (m *MockChatMemberRepository) AddMember(ctx context.Context, member *domain.ChatMemberDomain) (*domain.ChatMemberDomain, error) {
+	args := m.Called(ctx, member)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChatMemberDomain), args.Error(1)
+}
+
+func (m *MockChatMemberRepository) FindMember(ctx context.Context, chatID int, userID int) (*domain.ChatMemberDomain, error) {
+	args := m.Called(ctx, chatID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChatMemberDomain), args.Error(1)
+}
+
+func (m *MockChatMemberRepository) ListMembers(ctx context.Context, chatID int) ([]*domain.ChatMemberDomain, error) {
+	args := m.Called(ctx, chatID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ChatMemberDomain), args.Error(1)
+}
+
+func (m *MockChatMemberRepository) UpdateMemberRole(ctx context.Context, chatID int, userID int, role domain.ChatRole) (*domain.ChatMemberDomain, error) {
+	args := m.Called(ctx, chatID, userID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ChatMemberDomain), args.Error(1)
+}
+
+func (m *MockChatMemberRepository) RemoveMember(ctx context.Context, chatID int, userID int) error {
+	args := m.Called(ctx, chatID, userID)
+	return args.Error(0)
+}
+
+func (m *MockChatMemberRepository) TransferOwnership(ctx context.Context, chatID int, fromUserID int, toUserID int) error {
+	args := m.Called(ctx, chatID, fromUserID, toUserID)
+	return args.Error(0)
+}
+
+type MockPinRepository struct {
+	mock.Mock
+}
+
+func (m *MockPinRepository) PinMessage(ctx context.Context, pin *domain.PinDomain, limit int) (*domain.PinDomain, error) {
+	args := m.Called(ctx, pin, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PinDomain), args.Error(1)
+}
+
+func (m *MockPinRepository) UnpinMessage(ctx context.Context, chatID int, messageID int) error {
+	args := m.Called(ctx, chatID, messageID)
+	return args.Error(0)
+}
+
+func (m *MockPinRepository) ListPinned(ctx context.Context, chatID int) ([]*domain.PinDomain, error) {
+	args := m.Called(ctx, chatID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.PinDomain), args.Error(1)
+}
+
+type MockChatEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockChatEventRepository) SaveEvent(ctx context.Context, event *domain.ChatEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockChatEventRepository) ListEventsAfter(ctx context.Context, chatID int, afterID int64, limit int) ([]*domain.ChatEvent, error) {
+	args := m.Called(ctx, chatID, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.ChatEvent), args.Error(1)
+}
+
+// setupEventStream - поток событий с журналом-моком, который принимает любые события
+func setupEventStream() (*EventStream, *MockChatEventRepository) {
+	mockEventRepo := new(MockChatEventRepository)
+	mockEventRepo.On("SaveEvent", mock.Anything, mock.Anything).Return(nil).Maybe()
+	logger := zap.NewNop()
+	return NewEventStream(mockEventRepo, realtime.NewBroadcaster(8, logger), logger), mockEventRepo
+}
+
+func setupTest(t *testing.T) (*ChatService, *MockChatRepository, *MockMessageRepository, *MockChatMemberRepository) {
 	logger := zap.NewNop()
 	mockChatRepo := new(MockChatRepository)
 	mockMessageRepo := new(MockMessageRepository)
-	svc := NewChatService(mockMessageRepo, mockChatRepo, logger)
-	return svc, mockChatRepo, mockMessageRepo
+	mockMemberRepo := new(MockChatMemberRepository)
+	//закреплённые сообщения проверяются отдельно в TestChatService_Pins
+	mockPinRepo := new(MockPinRepository)
+	mockPinRepo.On("ListPinned", mock.Anything, mock.Anything).Return([]*domain.PinDomain{}, nil).Maybe()
+	events, _ := setupEventStream()
+	attachments, _ := setupAttachmentStorage()
+	svc := NewChatService(mockMessageRepo, mockChatRepo, mockMemberRepo, mockPinRepo, events, attachments, testPinLimit, testChatRetention, logger)
+	return svc, mockChatRepo, mockMessageRepo, mockMemberRepo
+}
+
+// testPinLimit - лимит закреплённых сообщений в тестах
+const testPinLimit = 3
+
+// testChatRetention - срок хранения архивных чатов в тестах
+const testChatRetention = 24 * time.Hour
+
+// userCtx возвращает контекст с аутентифицированным пользователем
+func userCtx(userID int) context.Context {
+	return auth.WithUser(context.Background(), &domain.UserDomain{ID: userID, Username: "user"})
+}
+
+// inCtx матчит контекст, производный от ctx: сервис передаёт в репозитории контекст со своим спаном
+func inCtx(ctx context.Context) any {
+	want, _ := auth.UserFromContext(ctx)
+	return mock.MatchedBy(func(c context.Context) bool {
+		got, _ := auth.UserFromContext(c)
+		return got == want
+	})
 }
 
 func TestChatService_CreateChat(t *testing.T) {
-	ctx := context.Background()
+	ctx := userCtx(42)
 
 	t.Run("чата не существует - успешное создание", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // Инициализация ВНУТРИ теста
+		svc, mockChatRepo, _, _ := setupTest(t) // Инициализация ВНУТРИ теста
 		chat := &domain.ChatDomain{Title: "test"}
 
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "title", Value: "test"}).
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "title", Value: "test"}).
 			Return(false, nil)
 
-		mockChatRepo.On("CreateChat", ctx, chat).
+		mockChatRepo.On("CreateChat", inCtx(ctx), chat).
 			Return(chat, nil)
 
 		result, err := svc.CreateChat(ctx, chat)
 
 		assert.NoError(t, err)
 		assert.Equal(t, chat, result)
+		assert.Equal(t, 42, result.OwnerID)
 		mockChatRepo.AssertExpectations(t)
 	})
 
 	t.Run("чат уже существует — ошибка", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // Инициализация ВНУТРИ теста
+		svc, mockChatRepo, _, _ := setupTest(t) // Инициализация ВНУТРИ теста
 		chat := &domain.ChatDomain{Title: "existing"}
 
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "title", Value: "existing"}).
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "title", Value: "existing"}).
 			Return(true, nil)
 
 		result, err := svc.CreateChat(ctx, chat)
@@ -110,10 +385,10 @@ func TestChatService_CreateChat(t *testing.T) {
 	})
 
 	t.Run("ошибка при проверке существования — возвращается ошибка", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // Инициализация ВНУТРИ теста
+		svc, mockChatRepo, _, _ := setupTest(t) // Инициализация ВНУТРИ теста
 		chat := &domain.ChatDomain{Title: "invalid"}
 
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "title", Value: "invalid"}).
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "title", Value: "invalid"}).
 			Return(false, errors.New("field not allowed"))
 
 		result, err := svc.CreateChat(ctx, chat)
@@ -122,15 +397,25 @@ func TestChatService_CreateChat(t *testing.T) {
 		assert.ErrorIs(t, err, domain.ErrFieldIsNotAllowed)
 		mockChatRepo.AssertExpectations(t)
 	})
+
+	t.Run("без аутентификации чат не создать", func(t *testing.T) {
+		svc, mockChatRepo, _, _ := setupTest(t)
+
+		result, err := svc.CreateChat(context.Background(), &domain.ChatDomain{Title: "test"})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockChatRepo.AssertNotCalled(t, "CreateChat", mock.Anything, mock.Anything)
+	})
 }
 
 func TestChatService_ChatExists(t *testing.T) {
 	ctx := context.Background()
-	svc, mockChatRepo, _ := setupTest(t)
+	svc, mockChatRepo, _, _ := setupTest(t)
 
 	param := repo.FilterParam{Field: "title", Value: "test"}
 
-	mockChatRepo.On("ChatExists", ctx, param).
+	mockChatRepo.On("ChatExists", inCtx(ctx), param).
 		Return(true, nil)
 
 	exists, err := svc.ChatExists(ctx, param)
@@ -141,133 +426,458 @@ func TestChatService_ChatExists(t *testing.T) {
 }
 
 func TestChatService_GetChatById(t *testing.T) {
-	ctx := context.Background()
+	userID := 42
+	ctx := userCtx(userID)
+	member := &domain.ChatMemberDomain{ChatID: 123, UserID: userID, Role: domain.RoleMember}
 
 	t.Run("успешное получение чата с сообщениями", func(t *testing.T) {
-		svc, mockChatRepo, mockMessageRepo := setupTest(t) // Инициализация ВНУТРИ теста
+		svc, mockChatRepo, mockMessageRepo, mockMemberRepo := setupTest(t) // Инициализация ВНУТРИ теста
 		chatID := 123
-		limit := 10
+		cursor := repo.CursorParam{Limit: 10}
 
 		chatFromRepo := &domain.ChatDomain{
 			ID:    chatID,
 			Title: "test chat",
 		}
 		messages := []*domain.MessageDomain{
-			{ID: 1, Text: "msg1"},
 			{ID: 2, Text: "msg2"},
+			{ID: 1, Text: "msg1"},
 		}
 
-		mockChatRepo.On("FindChatById", ctx, &domain.ChatDomain{ID: chatID}).
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: chatID}).
 			Return(chatFromRepo, nil)
-		mockMessageRepo.On("GetMessagesByChaWithLimit", ctx, chatID, limit).
-			Return(messages)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).Return(member, nil)
+		mockMessageRepo.On("GetMessagesByChatWithCursor", inCtx(ctx), chatID, cursor).
+			Return(&domain.MessagePage{Messages: messages}, nil)
 
-		result, err := svc.GetChatById(ctx, chatID, limit)
+		result, err := svc.GetChatById(ctx, chatID, cursor)
 
 		assert.NoError(t, err)
 		assert.Equal(t, chatFromRepo.ID, result.ID)
 		assert.Equal(t, chatFromRepo.Title, result.Title)
 		assert.Equal(t, messages, result.Messages)
+		assert.Nil(t, result.NextCursor)
+		assert.Nil(t, result.PrevCursor)
 		mockChatRepo.AssertExpectations(t)
 		mockMessageRepo.AssertExpectations(t)
 	})
 
+	t.Run("курсоры указывают на крайние сообщения страницы", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, mockMemberRepo := setupTest(t)
+		chatID := 123
+		cursor := repo.CursorParam{Before: 50, Limit: 2}
+
+		messages := []*domain.MessageDomain{
+			{ID: 49, Text: "msg49"},
+			{ID: 47, Text: "msg47"},
+		}
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: chatID}).
+			Return(&domain.ChatDomain{ID: chatID}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).Return(member, nil)
+		mockMessageRepo.On("GetMessagesByChatWithCursor", inCtx(ctx), chatID, cursor).
+			Return(&domain.MessagePage{Messages: messages, HasOlder: true, HasNewer: true}, nil)
+
+		result, err := svc.GetChatById(ctx, chatID, cursor)
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, result.NextCursor) && assert.NotNil(t, result.PrevCursor) {
+			assert.Equal(t, 47, *result.NextCursor)
+			assert.Equal(t, 49, *result.PrevCursor)
+		}
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("ошибка при получении сообщений", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, mockMemberRepo := setupTest(t)
+		chatID := 123
+		cursor := repo.CursorParam{Limit: 10}
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: chatID}).
+			Return(&domain.ChatDomain{ID: chatID}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).Return(member, nil)
+		mockMessageRepo.On("GetMessagesByChatWithCursor", inCtx(ctx), chatID, cursor).
+			Return(nil, errors.New("db error"))
+
+		result, err := svc.GetChatById(ctx, chatID, cursor)
+
+		assert.Nil(t, result)
+		assert.Error(t, err)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
 	t.Run("чат не найден", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // Инициализация ВНУТРИ теста
+		svc, mockChatRepo, _, _ := setupTest(t) // Инициализация ВНУТРИ теста
 		chatID := 999
-		limit := 5
+		cursor := repo.CursorParam{Limit: 5}
 
-		mockChatRepo.On("FindChatById", ctx, &domain.ChatDomain{ID: chatID}).
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: chatID}).
 			Return(nil, errors.New("record not found"))
 
-		result, err := svc.GetChatById(ctx, chatID, limit)
+		result, err := svc.GetChatById(ctx, chatID, cursor)
 
 		assert.Nil(t, result)
 		assert.ErrorIs(t, err, domain.ErrChatNotFound)
 		mockChatRepo.AssertExpectations(t)
 	})
+
+	t.Run("пользователь не участник чата", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, mockMemberRepo := setupTest(t)
+		chatID := 123
+		cursor := repo.CursorParam{Limit: 10}
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: chatID}).
+			Return(&domain.ChatDomain{ID: chatID}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).
+			Return(nil, domain.ErrMemberNotFound)
+
+		result, err := svc.GetChatById(ctx, chatID, cursor)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrNotChatMember)
+		mockMessageRepo.AssertNotCalled(t, "GetMessagesByChatWithCursor", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestChatService_ListChats(t *testing.T) {
+	ctx := userCtx(42)
+
+	t.Run("успешное получение списка", func(t *testing.T) {
+		svc, mockChatRepo, _, _ := setupTest(t)
+		params := repo.ListParam{
+			Filters: []repo.FilterParam{{Field: "title", Value: "te", Operator: repo.FilterPrefix}},
+			Limit:   10,
+		}
+		expected := params
+		expected.MemberID = 42
+		list := &domain.ChatList{
+			Chats: []*domain.ChatDomain{{ID: 1, Title: "test"}},
+			Total: 1,
+		}
+
+		mockChatRepo.On("ListChats", inCtx(ctx), expected).Return(list, nil)
+
+		result, err := svc.ListChats(ctx, params)
+
+		assert.NoError(t, err)
+		assert.Equal(t, list, result)
+		mockChatRepo.AssertExpectations(t)
+	})
+
+	t.Run("поле не разрешено", func(t *testing.T) {
+		svc, mockChatRepo, _, _ := setupTest(t)
+		params := repo.ListParam{SortField: "password", Limit: 10}
+
+		mockChatRepo.On("ListChats", inCtx(ctx), repo.ListParam{SortField: "password", Limit: 10, MemberID: 42}).Return(nil, domain.ErrFieldIsNotAllowed)
+
+		result, err := svc.ListChats(ctx, params)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrFieldIsNotAllowed)
+		mockChatRepo.AssertExpectations(t)
+	})
 }
 
 func TestChatService_DeleteChatByID(t *testing.T) {
-	ctx := context.Background()
+	userID := 42
+	ctx := userCtx(userID)
 
 	t.Run("успешное удаление", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // НОВЫЙ МОК
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t) // НОВЫЙ МОК
 		chatID := 123
 
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "id", Value: chatID}).
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).
 			Return(true, nil)
-		mockChatRepo.On("DeleteChat", ctx, chatID).
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).
+			Return(&domain.ChatMemberDomain{ChatID: chatID, UserID: userID, Role: domain.RoleOwner}, nil)
+		mockChatRepo.On("DeleteChat", inCtx(ctx), chatID, 0).
 			Return(nil)
 
-		err := svc.DeleteChatByID(ctx, chatID)
+		err := svc.DeleteChatByID(ctx, chatID, 0)
 
 		assert.NoError(t, err)
 		mockChatRepo.AssertExpectations(t)
 	})
 
+	t.Run("удаление устаревшей версии", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+		chatID := 123
+
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).
+			Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).
+			Return(&domain.ChatMemberDomain{ChatID: chatID, UserID: userID, Role: domain.RoleOwner}, nil)
+		mockChatRepo.On("DeleteChat", inCtx(ctx), chatID, 2).
+			Return(domain.ErrVersionMismatch)
+
+		err := svc.DeleteChatByID(ctx, chatID, 2)
+
+		assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	})
+
+	t.Run("админ не может удалить чат", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+		chatID := 123
+
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).
+			Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).
+			Return(&domain.ChatMemberDomain{ChatID: chatID, UserID: userID, Role: domain.RoleAdmin}, nil)
+
+		err := svc.DeleteChatByID(ctx, chatID, 0)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockChatRepo.AssertNotCalled(t, "DeleteChat", inCtx(ctx), chatID, mock.Anything)
+	})
+
 	t.Run("чат не найден", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // НОВЫЙ МОК
+		svc, mockChatRepo, _, _ := setupTest(t) // НОВЫЙ МОК
 		chatID := 999
 
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "id", Value: chatID}).
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).
 			Return(false, nil)
 
-		err := svc.DeleteChatByID(ctx, chatID)
+		err := svc.DeleteChatByID(ctx, chatID, 0)
 
 		assert.ErrorIs(t, err, domain.ErrChatNotFound)
 		mockChatRepo.AssertExpectations(t)
 	})
 
 	t.Run("ошибка при проверке существования", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // НОВЫЙ МОК
+		svc, mockChatRepo, _, _ := setupTest(t) // НОВЫЙ МОК
 		chatID := 123
 
 		// Теперь этот On не будет конфликтовать с первым тестом, так как мок чистый
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "id", Value: chatID}).
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).
 			Return(false, errors.New("field not allowed"))
 
-		err := svc.DeleteChatByID(ctx, chatID)
+		err := svc.DeleteChatByID(ctx, chatID, 0)
 
 		assert.ErrorIs(t, err, domain.ErrFieldIsNotAllowed)
 		mockChatRepo.AssertExpectations(t)
 	})
 }
 
-func TestChatService_SendMessage(t *testing.T) {
-	ctx := context.Background()
+func TestChatService_ArchiveChat(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+	chatID := 123
+	owner := &domain.ChatMemberDomain{ChatID: chatID, UserID: userID, Role: domain.RoleOwner}
 
-	t.Run("успешная отправка сообщения", func(t *testing.T) {
-		svc, mockChatRepo, mockMessageRepo := setupTest(t)
-		message := &domain.MessageDomain{
-			ChatID: 123,
-			Text:   "hello",
-		}
-		savedMessage := &domain.MessageDomain{
-			ID:     1,
-			ChatID: 123,
-			Text:   "hello",
-		}
+	t.Run("владелец переносит чат в архив - подписчики получают событие", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+		archivedAt := time.Now()
+		archived := &domain.ChatDomain{ID: chatID, Title: "general", ArchivedAt: &archivedAt}
 
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "id", Value: 123}).
-			Return(true, nil)
-		mockMessageRepo.On("CreateMessage", ctx, message).
-			Return(savedMessage, nil)
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: chatID}).Return(&domain.ChatDomain{ID: chatID}, nil)
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).Return(owner, nil)
+		mockChatRepo.On("ArchiveChat", inCtx(ctx), chatID, 0).Return(archived, nil)
 
-		result, err := svc.SendMessage(ctx, message)
+		sub, err := svc.SubscribeChat(ctx, chatID)
+		assert.NoError(t, err)
+		defer svc.Unsubscribe(sub)
+
+		result, err := svc.ArchiveChat(ctx, chatID, 0)
 
 		assert.NoError(t, err)
-		assert.Equal(t, savedMessage, result)
+		assert.Equal(t, archived, result)
+		mockChatRepo.AssertNotCalled(t, "DeleteChat", mock.Anything, mock.Anything, mock.Anything)
+		event := <-sub.Events
+		assert.Equal(t, domain.EventChatArchived, event.Type)
+		assert.True(t, event.Type.EndsStream())
+	})
+
+	t.Run("админ не может архивировать чат", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).
+			Return(&domain.ChatMemberDomain{ChatID: chatID, UserID: userID, Role: domain.RoleAdmin}, nil)
+
+		result, err := svc.ArchiveChat(ctx, chatID, 0)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockChatRepo.AssertNotCalled(t, "ArchiveChat", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("чат уже в архиве", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).Return(owner, nil)
+		mockChatRepo.On("ArchiveChat", inCtx(ctx), chatID, 0).Return(nil, domain.ErrChatArchived)
+
+		result, err := svc.ArchiveChat(ctx, chatID, 0)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrChatArchived)
+	})
+
+	t.Run("архивный чат скрыт от чтения", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, _ := setupTest(t)
+		archivedAt := time.Now()
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: chatID}).
+			Return(&domain.ChatDomain{ID: chatID, ArchivedAt: &archivedAt}, nil)
+
+		chat, err := svc.GetChatById(ctx, chatID, repo.CursorParam{Limit: 10})
+		assert.Nil(t, chat)
+		assert.ErrorIs(t, err, domain.ErrChatNotFound)
+
+		sub, err := svc.SubscribeChat(ctx, chatID)
+		assert.Nil(t, sub)
+		assert.ErrorIs(t, err, domain.ErrChatNotFound)
+
+		mockMessageRepo.AssertNotCalled(t, "GetMessagesByChatWithCursor", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("восстановление из архива", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+		restored := &domain.ChatDomain{ID: chatID, Title: "general"}
+
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).Return(owner, nil)
+		mockChatRepo.On("RestoreChat", inCtx(ctx), chatID, 0).Return(restored, nil)
+
+		result, err := svc.RestoreChat(ctx, chatID, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, restored, result)
+	})
+
+	t.Run("восстановить можно только архивный чат", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "id", Value: chatID}).Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), chatID, userID).Return(owner, nil)
+		mockChatRepo.On("RestoreChat", inCtx(ctx), chatID, 0).Return(nil, domain.ErrChatNotArchived)
+
+		result, err := svc.RestoreChat(ctx, chatID, 0)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrChatNotArchived)
+	})
+
+	t.Run("воркер удаляет чаты с истёкшим сроком хранения", func(t *testing.T) {
+		svc, mockChatRepo, _, _ := setupTest(t)
+		before := time.Now().Add(-testChatRetention)
+
+		mockChatRepo.On("PurgeArchivedChats", inCtx(ctx), mock.MatchedBy(func(archivedBefore time.Time) bool {
+			return !archivedBefore.Before(before) && archivedBefore.Before(time.Now().Add(-testChatRetention+time.Minute))
+		}), archivedChatsBatch).Return(int64(2), nil)
+
+		purged, err := svc.PurgeArchivedChats(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), purged)
+	})
+}
+
+func TestChatService_SendMessage(t *testing.T) {
+	authorID := 42
+	ctx := auth.WithUser(context.Background(), &domain.UserDomain{ID: authorID, Username: "alice"})
+	member := &domain.ChatMemberDomain{ChatID: 123, UserID: authorID, Role: domain.RoleMember}
+
+	t.Run("успешная отправка сообщения", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, mockMemberRepo := setupTest(t)
+		message := &domain.MessageDomain{
+			ChatID: 123,
+			Text:   "hello",
+		}
+		savedMessage := &domain.MessageDomain{
+			ID:       1,
+			ChatID:   123,
+			Text:     "hello",
+			AuthorID: &authorID,
+		}
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 123}).
+			Return(&domain.ChatDomain{ID: 123}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 123, authorID).Return(member, nil)
+		mockMessageRepo.On("CreateMessage", inCtx(ctx), message).
+			Return(savedMessage, nil)
+
+		sub, err := svc.SubscribeChat(ctx, 123)
+		assert.NoError(t, err)
+		defer svc.Unsubscribe(sub)
+
+		result, err := svc.SendMessage(ctx, message)
+
+		assert.NoError(t, err)
+		assert.Equal(t, savedMessage, result)
+		assert.Equal(t, &authorID, message.AuthorID)
 		mockChatRepo.AssertExpectations(t)
 		mockMessageRepo.AssertExpectations(t)
+
+		//подписчики чата получают новое сообщение
+		select {
+		case event := <-sub.Events:
+			assert.Equal(t, domain.EventMessageCreated, event.Type)
+			assert.Equal(t, savedMessage, event.Message)
+		default:
+			t.Fatal("событие о новом сообщении не опубликовано")
+		}
+	})
+
+	t.Run("ответ на ответ привязывается к корню треда", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, mockMemberRepo := setupTest(t)
+		parentID, rootID := 5, 2
+		message := &domain.MessageDomain{ChatID: 123, Text: "reply", ParentMessageID: &parentID}
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 123}).
+			Return(&domain.ChatDomain{ID: 123}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 123, authorID).Return(member, nil)
+		mockMessageRepo.On("FindMessageById", inCtx(ctx), 123, parentID).
+			Return(&domain.MessageDomain{ID: parentID, ChatID: 123, ParentMessageID: &rootID}, nil)
+		mockMessageRepo.On("CreateMessage", inCtx(ctx), mock.MatchedBy(func(m *domain.MessageDomain) bool {
+			return m.ParentMessageID != nil && *m.ParentMessageID == rootID
+		})).Return(message, nil)
+
+		result, err := svc.SendMessage(ctx, message)
+
+		assert.NoError(t, err)
+		assert.Equal(t, rootID, *result.ParentMessageID)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("родитель из другого чата", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, mockMemberRepo := setupTest(t)
+		parentID := 7
+		message := &domain.MessageDomain{ChatID: 123, Text: "reply", ParentMessageID: &parentID}
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 123}).
+			Return(&domain.ChatDomain{ID: 123}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 123, authorID).Return(member, nil)
+		mockMessageRepo.On("FindMessageById", inCtx(ctx), 123, parentID).
+			Return(nil, domain.ErrMessageNotFound)
+
+		result, err := svc.SendMessage(ctx, message)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrParentNotInChat)
+		mockMessageRepo.AssertNotCalled(t, "CreateMessage", inCtx(ctx), message)
+	})
+
+	t.Run("без аутентификации сообщение не отправить", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, _ := setupTest(t)
+		message := &domain.MessageDomain{ChatID: 123, Text: "hi"}
+
+		result, err := svc.SendMessage(context.Background(), message)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockChatRepo.AssertNotCalled(t, "FindChatById", mock.Anything, mock.Anything)
+		mockMessageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
 	})
 
 	t.Run("чат не найден", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t)
+		svc, mockChatRepo, _, _ := setupTest(t)
 		message := &domain.MessageDomain{ChatID: 999, Text: "hi"}
 
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "id", Value: 999}).
-			Return(false, nil)
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 999}).
+			Return(nil, domain.ErrChatNotFound)
 
 		result, err := svc.SendMessage(ctx, message)
 
@@ -276,18 +886,549 @@ func TestChatService_SendMessage(t *testing.T) {
 		mockChatRepo.AssertExpectations(t)
 	})
 
-	t.Run("ошибка при проверке существования", func(t *testing.T) {
-		svc, mockChatRepo, _ := setupTest(t) // Чистый мок!
+	t.Run("в архивный чат писать нельзя", func(t *testing.T) {
+		svc, mockChatRepo, mockMessageRepo, _ := setupTest(t)
 		message := &domain.MessageDomain{ChatID: 123, Text: "hi"}
+		archivedAt := time.Now()
 
-		// Теперь ChatExists вернет ошибку, и код не пойдет дальше создавать сообщение
-		mockChatRepo.On("ChatExists", ctx, repo.FilterParam{Field: "id", Value: 123}).
-			Return(false, errors.New("field not allowed"))
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 123}).
+			Return(&domain.ChatDomain{ID: 123, ArchivedAt: &archivedAt}, nil)
 
 		result, err := svc.SendMessage(ctx, message)
 
 		assert.Nil(t, result)
-		assert.ErrorIs(t, err, domain.ErrFieldIsNotAllowed)
+		assert.ErrorIs(t, err, domain.ErrChatArchived)
+		mockMessageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка при получении чата", func(t *testing.T) {
+		svc, mockChatRepo, _, _ := setupTest(t)
+		message := &domain.MessageDomain{ChatID: 123, Text: "hi"}
+		dbErr := errors.New("connection refused")
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 123}).Return(nil, dbErr)
+
+		result, err := svc.SendMessage(ctx, message)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, dbErr)
 		mockChatRepo.AssertExpectations(t)
 	})
 }
+
+func TestChatService_SendMessageWithAttachments(t *testing.T) {
+	authorID := 42
+	ctx := userCtx(authorID)
+	member := &domain.ChatMemberDomain{ChatID: 123, UserID: authorID, Role: domain.RoleMember}
+
+	setup := func() (*ChatService, *MockMessageRepository, *MockBlobStore) {
+		mockChatRepo := new(MockChatRepository)
+		mockMessageRepo := new(MockMessageRepository)
+		mockMemberRepo := new(MockChatMemberRepository)
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 123}).Return(&domain.ChatDomain{ID: 123}, nil).Maybe()
+		mockMemberRepo.On("FindMember", inCtx(ctx), 123, authorID).Return(member, nil).Maybe()
+		events, _ := setupEventStream()
+		attachments, blobs := setupAttachmentStorage()
+		svc := NewChatService(mockMessageRepo, mockChatRepo, mockMemberRepo, new(MockPinRepository), events, attachments, testPinLimit, testChatRetention, zap.NewNop())
+		return svc, mockMessageRepo, blobs
+	}
+
+	t.Run("сообщение без текста с вложением", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setup()
+		message := &domain.MessageDomain{ChatID: 123}
+
+		blobs.On("Put", mock.Anything, mock.Anything, mock.Anything, int64(5), "text/plain").Return(nil)
+		mockMessageRepo.On("CreateMessage", inCtx(ctx), mock.MatchedBy(func(m *domain.MessageDomain) bool {
+			return len(m.Attachments) == 1 && m.Attachments[0].FileName == "a.txt"
+		})).Return(message, nil)
+
+		result, err := svc.SendMessageWithAttachments(ctx, message, []*domain.AttachmentUpload{textUpload("a.txt", "hello")})
+
+		assert.NoError(t, err)
+		assert.Equal(t, message, result)
+		blobs.AssertExpectations(t)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("ни текста, ни вложений", func(t *testing.T) {
+		svc, mockMessageRepo, _ := setup()
+
+		result, err := svc.SendMessageWithAttachments(ctx, &domain.MessageDomain{ChatID: 123}, nil)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrEmptyMessage)
+		mockMessageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("недопустимый файл не попадает в хранилище", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setup()
+
+		result, err := svc.SendMessageWithAttachments(ctx, &domain.MessageDomain{ChatID: 123, Text: "hi"},
+			[]*domain.AttachmentUpload{textUpload("doc.pdf", "%PDF-1.7")})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrAttachmentType)
+		blobs.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockMessageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка базы - сохранённые файлы удаляются", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setup()
+
+		var storedKey string
+		blobs.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { storedKey = args.String(1) }).Return(nil)
+		mockMessageRepo.On("CreateMessage", inCtx(ctx), mock.Anything).
+			Return((*domain.MessageDomain)(nil), errors.New("db error"))
+		blobs.On("Delete", mock.Anything, mock.MatchedBy(func(key string) bool { return key == storedKey })).Return(nil)
+
+		result, err := svc.SendMessageWithAttachments(ctx, &domain.MessageDomain{ChatID: 123},
+			[]*domain.AttachmentUpload{textUpload("a.txt", "hello")})
+
+		assert.Nil(t, result)
+		assert.Error(t, err)
+		blobs.AssertExpectations(t)
+	})
+}
+
+func TestChatService_AddMember(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+
+	t.Run("админ добавляет участника", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+		member := &domain.ChatMemberDomain{ChatID: 1, UserID: 7, Role: domain.RoleMember}
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleAdmin}, nil)
+		mockMemberRepo.On("AddMember", inCtx(ctx), member).Return(member, nil)
+
+		result, err := svc.AddMember(ctx, member)
+
+		assert.NoError(t, err)
+		assert.Equal(t, member, result)
+		mockMemberRepo.AssertExpectations(t)
+	})
+
+	t.Run("админ не может назначить админа", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+		member := &domain.ChatMemberDomain{ChatID: 1, UserID: 7, Role: domain.RoleAdmin}
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleAdmin}, nil)
+
+		result, err := svc.AddMember(ctx, member)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockMemberRepo.AssertNotCalled(t, "AddMember", mock.Anything, mock.Anything)
+	})
+
+	t.Run("рядовой участник не может добавлять", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+		member := &domain.ChatMemberDomain{ChatID: 1, UserID: 7, Role: domain.RoleMember}
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleMember}, nil)
+
+		result, err := svc.AddMember(ctx, member)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+	})
+}
+
+func TestChatService_RemoveMember(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+
+	t.Run("владелец не может покинуть чат", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleOwner}, nil)
+
+		err := svc.RemoveMember(ctx, 1, userID)
+
+		assert.ErrorIs(t, err, domain.ErrOwnerCannotLeave)
+		mockMemberRepo.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("участник выходит сам", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleMember}, nil)
+		mockMemberRepo.On("RemoveMember", inCtx(ctx), 1, userID).Return(nil)
+
+		err := svc.RemoveMember(ctx, 1, userID)
+
+		assert.NoError(t, err)
+		mockMemberRepo.AssertExpectations(t)
+	})
+
+	t.Run("подписка вышедшего участника закрывается", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 1}).Return(&domain.ChatDomain{ID: 1}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleMember}, nil)
+		mockMemberRepo.On("RemoveMember", inCtx(ctx), 1, userID).Return(nil)
+
+		sub, err := svc.SubscribeChat(ctx, 1)
+		assert.NoError(t, err)
+
+		assert.NoError(t, svc.RemoveMember(ctx, 1, userID))
+
+		_, ok := <-sub.Events
+		assert.False(t, ok)
+	})
+
+	t.Run("админ не может исключить другого админа", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleAdmin}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, 7).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: 7, Role: domain.RoleAdmin}, nil)
+
+		err := svc.RemoveMember(ctx, 1, 7)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockMemberRepo.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestChatService_TransferOwnership(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+
+	t.Run("владелец передаёт чат", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleOwner}, nil)
+		mockMemberRepo.On("TransferOwnership", inCtx(ctx), 1, userID, 7).Return(nil)
+
+		err := svc.TransferOwnership(ctx, 1, 7)
+
+		assert.NoError(t, err)
+		mockMemberRepo.AssertExpectations(t)
+	})
+
+	t.Run("админ не может передать владение", func(t *testing.T) {
+		svc, _, _, mockMemberRepo := setupTest(t)
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleAdmin}, nil)
+
+		err := svc.TransferOwnership(ctx, 1, 7)
+
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockMemberRepo.AssertNotCalled(t, "TransferOwnership", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestChatService_SubscribeChat(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+
+	t.Run("не участник не может подписаться", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 1}).
+			Return(&domain.ChatDomain{ID: 1}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(nil, domain.ErrMemberNotFound)
+
+		sub, err := svc.SubscribeChat(ctx, 1)
+
+		assert.Nil(t, sub)
+		assert.ErrorIs(t, err, domain.ErrNotChatMember)
+	})
+
+	t.Run("чат не найден", func(t *testing.T) {
+		svc, mockChatRepo, _, _ := setupTest(t)
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 999}).
+			Return(nil, domain.ErrChatNotFound)
+
+		sub, err := svc.SubscribeChat(ctx, 999)
+
+		assert.Nil(t, sub)
+		assert.ErrorIs(t, err, domain.ErrChatNotFound)
+	})
+}
+
+func TestChatService_RenameChat(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+
+	t.Run("админ переименовывает чат - подписчики получают событие", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+		renamed := &domain.ChatDomain{ID: 1, Title: "new"}
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 1}).Return(&domain.ChatDomain{ID: 1}, nil)
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "title", Value: "new"}).Return(false, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleAdmin}, nil)
+		mockChatRepo.On("UpdateChatTitle", inCtx(ctx), 1, "new", 0).Return(renamed, nil)
+
+		sub, err := svc.SubscribeChat(ctx, 1)
+		assert.NoError(t, err)
+		defer svc.Unsubscribe(sub)
+
+		result, err := svc.RenameChat(ctx, 1, "new", 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, renamed, result)
+		event := <-sub.Events
+		assert.Equal(t, domain.EventChatRenamed, event.Type)
+		assert.Equal(t, "new", event.Chat.Title)
+	})
+
+	t.Run("рядовой участник не может переименовать", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 1}).Return(&domain.ChatDomain{ID: 1}, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleMember}, nil)
+
+		result, err := svc.RenameChat(ctx, 1, "new", 0)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockChatRepo.AssertNotCalled(t, "UpdateChatTitle", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("чат изменили после того, как клиент его прочитал", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 1}).Return(&domain.ChatDomain{ID: 1, Version: 4}, nil)
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "title", Value: "new"}).Return(false, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleAdmin}, nil)
+		mockChatRepo.On("UpdateChatTitle", inCtx(ctx), 1, "new", 3).Return(nil, domain.ErrVersionMismatch)
+
+		sub, err := svc.SubscribeChat(ctx, 1)
+		assert.NoError(t, err)
+		defer svc.Unsubscribe(sub)
+
+		result, err := svc.RenameChat(ctx, 1, "new", 3)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+		select {
+		case event := <-sub.Events:
+			t.Fatalf("неожиданное событие %s", event.Type)
+		default:
+		}
+	})
+
+	t.Run("название занято", func(t *testing.T) {
+		svc, mockChatRepo, _, mockMemberRepo := setupTest(t)
+
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 1}).Return(&domain.ChatDomain{ID: 1}, nil)
+		mockChatRepo.On("ChatExists", inCtx(ctx), repo.FilterParam{Field: "title", Value: "taken"}).Return(true, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleOwner}, nil)
+
+		result, err := svc.RenameChat(ctx, 1, "taken", 0)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrChatAlreadyExists)
+	})
+}
+
+func TestChatService_ReplayEvents(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+
+	setup := func(chat *domain.ChatDomain) (*ChatService, *MockChatMemberRepository, *MockChatEventRepository) {
+		mockChatRepo := new(MockChatRepository)
+		mockMemberRepo := new(MockChatMemberRepository)
+		events, mockEventRepo := setupEventStream()
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 1}).Return(chat, nil)
+		svc := NewChatService(new(MockMessageRepository), mockChatRepo, mockMemberRepo, new(MockPinRepository), events, nil, testPinLimit, testChatRetention, zap.NewNop())
+		return svc, mockMemberRepo, mockEventRepo
+	}
+
+	t.Run("события после Last-Event-ID читаются из журнала", func(t *testing.T) {
+		svc, mockMemberRepo, mockEventRepo := setup(&domain.ChatDomain{ID: 1})
+		missed := []*domain.ChatEvent{
+			{ID: 11, Type: domain.EventMessageCreated, ChatID: 1},
+			{ID: 12, Type: domain.EventChatRenamed, ChatID: 1},
+		}
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 1, UserID: userID, Role: domain.RoleMember}, nil)
+		mockEventRepo.On("ListEventsAfter", inCtx(ctx), 1, int64(10), 100).Return(missed, nil)
+
+		result, err := svc.ReplayEvents(ctx, 1, 10, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, missed, result)
+		mockEventRepo.AssertExpectations(t)
+	})
+
+	t.Run("исключённый участник не получает журнал", func(t *testing.T) {
+		svc, mockMemberRepo, mockEventRepo := setup(&domain.ChatDomain{ID: 1})
+
+		mockMemberRepo.On("FindMember", inCtx(ctx), 1, userID).Return(nil, domain.ErrMemberNotFound)
+
+		result, err := svc.ReplayEvents(ctx, 1, 10, 100)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrNotChatMember)
+		mockEventRepo.AssertNotCalled(t, "ListEventsAfter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("архивный чат не отдаёт ни журнал, ни участников", func(t *testing.T) {
+		archivedAt := time.Now()
+		svc, mockMemberRepo, mockEventRepo := setup(&domain.ChatDomain{ID: 1, ArchivedAt: &archivedAt})
+
+		_, err := svc.ReplayEvents(ctx, 1, 10, 100)
+		assert.ErrorIs(t, err, domain.ErrChatNotFound)
+
+		_, err = svc.ListMembers(ctx, 1)
+		assert.ErrorIs(t, err, domain.ErrChatNotFound)
+
+		mockEventRepo.AssertNotCalled(t, "ListEventsAfter", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockMemberRepo.AssertNotCalled(t, "ListMembers", mock.Anything, mock.Anything)
+	})
+}
+
+func TestChatService_Pins(t *testing.T) {
+	userID := 42
+	ctx := userCtx(userID)
+	message := &domain.MessageDomain{ID: 5, ChatID: 123, Text: "announcement"}
+
+	setupChat := func(role domain.ChatRole, chat *domain.ChatDomain) (*ChatService, *MockMessageRepository, *MockPinRepository, *MockChatEventRepository) {
+		mockMessageRepo := new(MockMessageRepository)
+		mockChatRepo := new(MockChatRepository)
+		mockMemberRepo := new(MockChatMemberRepository)
+		mockPinRepo := new(MockPinRepository)
+		mockChatRepo.On("FindChatById", inCtx(ctx), &domain.ChatDomain{ID: 123}).Return(chat, nil)
+		mockMemberRepo.On("FindMember", inCtx(ctx), 123, userID).
+			Return(&domain.ChatMemberDomain{ChatID: 123, UserID: userID, Role: role}, nil)
+		events, mockEventRepo := setupEventStream()
+		svc := NewChatService(mockMessageRepo, mockChatRepo, mockMemberRepo, mockPinRepo, events, nil, testPinLimit, testChatRetention, zap.NewNop())
+		return svc, mockMessageRepo, mockPinRepo, mockEventRepo
+	}
+	setup := func(role domain.ChatRole) (*ChatService, *MockMessageRepository, *MockPinRepository, *MockChatEventRepository) {
+		return setupChat(role, &domain.ChatDomain{ID: 123})
+	}
+
+	t.Run("администратор закрепляет сообщение, уходит событие", func(t *testing.T) {
+		svc, mockMessageRepo, mockPinRepo, mockEventRepo := setup(domain.RoleAdmin)
+
+		mockMessageRepo.On("FindMessageById", inCtx(ctx), 123, 5).Return(message, nil)
+		mockPinRepo.On("PinMessage", inCtx(ctx), mock.MatchedBy(func(pin *domain.PinDomain) bool {
+			return pin.ChatID == 123 && pin.MessageID == 5 && *pin.PinnedBy == userID
+		}), testPinLimit).Return(&domain.PinDomain{ChatID: 123, MessageID: 5, PinnedBy: &userID}, nil)
+
+		pin, err := svc.PinMessage(ctx, 123, 5)
+
+		assert.NoError(t, err)
+		assert.Equal(t, message, pin.Message)
+		mockEventRepo.AssertCalled(t, "SaveEvent", inCtx(ctx), mock.MatchedBy(func(event *domain.ChatEvent) bool {
+			return event.Type == domain.EventMessagePinned && event.Message == message && *event.ActorID == userID
+		}))
+	})
+
+	t.Run("обычный участник закреплять не может", func(t *testing.T) {
+		svc, mockMessageRepo, mockPinRepo, _ := setup(domain.RoleMember)
+
+		pin, err := svc.PinMessage(ctx, 123, 5)
+
+		assert.Nil(t, pin)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockMessageRepo.AssertNotCalled(t, "FindMessageById", mock.Anything, mock.Anything, mock.Anything)
+		mockPinRepo.AssertNotCalled(t, "PinMessage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("удалённое сообщение не закрепить", func(t *testing.T) {
+		svc, mockMessageRepo, mockPinRepo, _ := setup(domain.RoleOwner)
+		deletedAt := time.Now()
+
+		mockMessageRepo.On("FindMessageById", inCtx(ctx), 123, 5).
+			Return(&domain.MessageDomain{ID: 5, ChatID: 123, DeletedAt: &deletedAt}, nil)
+
+		_, err := svc.PinMessage(ctx, 123, 5)
+
+		assert.ErrorIs(t, err, domain.ErrMessageDeleted)
+		mockPinRepo.AssertNotCalled(t, "PinMessage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("лимит закреплений", func(t *testing.T) {
+		svc, mockMessageRepo, mockPinRepo, mockEventRepo := setup(domain.RoleAdmin)
+
+		mockMessageRepo.On("FindMessageById", inCtx(ctx), 123, 5).Return(message, nil)
+		mockPinRepo.On("PinMessage", inCtx(ctx), mock.Anything, testPinLimit).Return(nil, domain.ErrPinLimitReached)
+
+		_, err := svc.PinMessage(ctx, 123, 5)
+
+		assert.ErrorIs(t, err, domain.ErrPinLimitReached)
+		mockEventRepo.AssertNotCalled(t, "SaveEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("открепление", func(t *testing.T) {
+		svc, mockMessageRepo, mockPinRepo, mockEventRepo := setup(domain.RoleAdmin)
+
+		mockMessageRepo.On("FindMessageById", inCtx(ctx), 123, 5).Return(message, nil)
+		mockPinRepo.On("UnpinMessage", inCtx(ctx), 123, 5).Return(nil)
+
+		err := svc.UnpinMessage(ctx, 123, 5)
+
+		assert.NoError(t, err)
+		mockEventRepo.AssertCalled(t, "SaveEvent", inCtx(ctx), mock.MatchedBy(func(event *domain.ChatEvent) bool {
+			return event.Type == domain.EventMessageUnpinned
+		}))
+	})
+
+	t.Run("сообщение не закреплено", func(t *testing.T) {
+		svc, mockMessageRepo, mockPinRepo, mockEventRepo := setup(domain.RoleAdmin)
+
+		mockMessageRepo.On("FindMessageById", inCtx(ctx), 123, 5).Return(message, nil)
+		mockPinRepo.On("UnpinMessage", inCtx(ctx), 123, 5).Return(domain.ErrNotPinned)
+
+		err := svc.UnpinMessage(ctx, 123, 5)
+
+		assert.ErrorIs(t, err, domain.ErrNotPinned)
+		mockEventRepo.AssertNotCalled(t, "SaveEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("в архивном чате закрепления не меняются и не видны", func(t *testing.T) {
+		archivedAt := time.Now()
+		svc, mockMessageRepo, mockPinRepo, mockEventRepo := setupChat(domain.RoleOwner, &domain.ChatDomain{ID: 123, ArchivedAt: &archivedAt})
+
+		_, err := svc.PinMessage(ctx, 123, 5)
+		assert.ErrorIs(t, err, domain.ErrChatArchived)
+
+		err = svc.UnpinMessage(ctx, 123, 5)
+		assert.ErrorIs(t, err, domain.ErrChatArchived)
+
+		_, err = svc.ListPinned(ctx, 123)
+		assert.ErrorIs(t, err, domain.ErrChatNotFound)
+
+		mockMessageRepo.AssertNotCalled(t, "FindMessageById", mock.Anything, mock.Anything, mock.Anything)
+		mockPinRepo.AssertNotCalled(t, "PinMessage", mock.Anything, mock.Anything, mock.Anything)
+		mockPinRepo.AssertNotCalled(t, "UnpinMessage", mock.Anything, mock.Anything, mock.Anything)
+		mockPinRepo.AssertNotCalled(t, "ListPinned", mock.Anything, mock.Anything)
+		mockEventRepo.AssertNotCalled(t, "SaveEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("закреплённые сообщения отдаются вместе с чатом", func(t *testing.T) {
+		svc, mockMessageRepo, mockPinRepo, _ := setup(domain.RoleMember)
+		cursor := repo.CursorParam{Limit: 10}
+		pins := []*domain.PinDomain{{ChatID: 123, MessageID: 5, Message: message}}
+
+		mockMessageRepo.On("GetMessagesByChatWithCursor", inCtx(ctx), 123, cursor).Return(&domain.MessagePage{}, nil)
+		mockPinRepo.On("ListPinned", inCtx(ctx), 123).Return(pins, nil)
+
+		chat, err := svc.GetChatById(ctx, 123, cursor)
+
+		assert.NoError(t, err)
+		assert.Equal(t, pins, chat.Pinned)
+	})
+}
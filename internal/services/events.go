@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/realtime"
+	"testtask5/internal/repo"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EventBus - шина событий чатов, через которую изменения доходят до подписчиков
+type EventBus interface {
+	Publish(event *domain.ChatEvent)
+	Subscribe(chatID int, userID int, expiresAt time.Time) *realtime.Subscription
+	Unsubscribe(sub *realtime.Subscription)
+}
+
+// publishStripes - на сколько замков делятся чаты при публикации
+const publishStripes = 64
+
+// EventStream сохраняет события чатов в журнал (для повтора после переподключения)
+// и рассылает их подписчикам в реальном времени
+type EventStream struct {
+	eventRepo repo.ChatEventRepository
+	bus       EventBus
+	logger    *zap.Logger
+
+	//запись в журнал и отправка в шину идут под замком чата. Иначе два издателя могут отдать
+	//события в шину не в порядке id, и поток, уже отдавший больший id, отбросит меньший
+	publishMu [publishStripes]sync.Mutex
+}
+
+func NewEventStream(eventRepo repo.ChatEventRepository, bus EventBus, appLogger *zap.Logger) *EventStream {
+	return &EventStream{
+		eventRepo: eventRepo,
+		bus:       bus,
+		logger:    appLogger.Named("event_stream"),
+	}
+}
+
+// Publish не возвращает ошибку: изменение уже сохранено, и ронять запрос из-за журнала нельзя.
+// Событие, которое не удалось записать, всё равно уходит живым подписчикам, но без ID
+func (es *EventStream) Publish(ctx context.Context, event *domain.ChatEvent) {
+	mu := &es.publishMu[event.ChatID%publishStripes]
+	mu.Lock()
+	defer mu.Unlock()
+
+	event.OccurredAt = time.Now()
+
+	if err := es.eventRepo.SaveEvent(ctx, event); err != nil {
+		logging.Logger(ctx, es.logger).Error("не удалось записать событие в журнал",
+			zap.Int("chat_id", event.ChatID), zap.String("type", string(event.Type)), zap.Error(err))
+	}
+
+	es.bus.Publish(event)
+}
+
+func (es *EventStream) Subscribe(chatID int, userID int, expiresAt time.Time) *realtime.Subscription {
+	return es.bus.Subscribe(chatID, userID, expiresAt)
+}
+
+func (es *EventStream) Unsubscribe(sub *realtime.Subscription) {
+	es.bus.Unsubscribe(sub)
+}
+
+// EventsAfter читает из журнала события чата после afterID
+func (es *EventStream) EventsAfter(ctx context.Context, chatID int, afterID int64, limit int) ([]*domain.ChatEvent, error) {
+	return es.eventRepo.ListEventsAfter(ctx, chatID, afterID, limit)
+}
+
+func (es *EventStream) publishMessage(ctx context.Context, eventType domain.ChatEventType, message *domain.MessageDomain) {
+	es.Publish(ctx, &domain.ChatEvent{
+		Type:    eventType,
+		ChatID:  message.ChatID,
+		Message: message,
+	})
+}
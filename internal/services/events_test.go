@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testtask5/internal/domain"
+	"testtask5/internal/realtime"
+	"testtask5/internal/repo"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// slowEventRepo выдаёт id по порядку, как журнал под advisory-блокировкой, и отвечает с задержкой,
+// как коммит транзакции. Первый издатель отвечает дольше следующих
+type slowEventRepo struct {
+	repo.ChatEventRepository
+	mu     sync.Mutex
+	lastID int64
+}
+
+func (r *slowEventRepo) SaveEvent(ctx context.Context, event *domain.ChatEvent) error {
+	r.mu.Lock()
+	r.lastID++
+	event.ID = r.lastID
+	r.mu.Unlock()
+
+	if event.ID == 1 {
+		time.Sleep(20 * time.Millisecond)
+	}
+	return nil
+}
+
+// recordingBus запоминает id событий в порядке публикации
+type recordingBus struct {
+	mu  sync.Mutex
+	ids []int64
+}
+
+func (b *recordingBus) Publish(event *domain.ChatEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ids = append(b.ids, event.ID)
+}
+
+func (b *recordingBus) Subscribe(chatID int, userID int, expiresAt time.Time) *realtime.Subscription {
+	return nil
+}
+
+func (b *recordingBus) Unsubscribe(sub *realtime.Subscription) {}
+
+func TestEventStream_PublishesInIDOrder(t *testing.T) {
+	bus := &recordingBus{}
+	es := NewEventStream(&slowEventRepo{}, bus, zap.NewNop())
+
+	first := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		close(first)
+		es.Publish(context.Background(), &domain.ChatEvent{Type: domain.EventMessageCreated, ChatID: 1})
+	}()
+	<-first
+	time.Sleep(5 * time.Millisecond)
+
+	//остальные издатели получают id, пока первый ещё не отдал своё событие в шину
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			es.Publish(context.Background(), &domain.ChatEvent{Type: domain.EventMessageCreated, ChatID: 1})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6}, bus.ids)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testtask5/internal/domain"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// errShuttingDown - сервис останавливается, новые запросы на него слать не надо
+var errShuttingDown = errors.New("идёт остановка сервиса")
+
+// HealthCheck - проверка готовности сервиса принимать запросы, например доступность базы
+type HealthCheck func(ctx context.Context) error
+
+type namedHealthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// HealthService собирает проверки готовности для /readyz
+type HealthService struct {
+	mu            sync.RWMutex
+	checks        []namedHealthCheck
+	shuttingDown  atomic.Bool
+	timeout       time.Duration
+	serviceLogger *zap.Logger
+}
+
+// timeout - сколько ждать каждую проверку, зависшая база не должна вешать пробу
+func NewHealthService(timeout time.Duration, appLogger *zap.Logger) *HealthService {
+	serviceLogger := appLogger.Named("health_service")
+	return &HealthService{
+		timeout:       timeout,
+		serviceLogger: serviceLogger,
+	}
+}
+
+// AddCheck добавляет проверку готовности
+func (hs *HealthService) AddCheck(name string, check HealthCheck) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.checks = append(hs.checks, namedHealthCheck{name: name, check: check})
+}
+
+// StartShutdown переводит сервис в неготовый, чтобы балансировщик перестал слать трафик
+// до того, как сервер закроет соединения
+func (hs *HealthService) StartShutdown() {
+	hs.shuttingDown.Store(true)
+}
+
+// Ready выполняет все проверки параллельно и возвращает результат каждой
+func (hs *HealthService) Ready(ctx context.Context) *domain.HealthReport {
+	hs.mu.RLock()
+	checks := append([]namedHealthCheck(nil), hs.checks...)
+	hs.mu.RUnlock()
+
+	report := &domain.HealthReport{
+		Checks: make([]domain.HealthCheckResult, len(checks), len(checks)+1),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, hs.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.check(checkCtx)
+			report.Checks[i] = domain.HealthCheckResult{Name: check.name, Err: err, Duration: time.Since(start)}
+		}()
+	}
+	wg.Wait()
+
+	var shutdownErr error
+	if hs.shuttingDown.Load() {
+		shutdownErr = errShuttingDown
+	}
+	report.Checks = append(report.Checks, domain.HealthCheckResult{Name: "shutdown", Err: shutdownErr})
+
+	for _, check := range report.Checks {
+		if check.Err != nil {
+			hs.serviceLogger.Warn("проверка готовности не прошла", zap.String("check", check.Name), zap.Error(check.Err))
+		}
+	}
+
+	return report
+}
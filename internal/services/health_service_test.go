@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestHealthService_Ready(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+
+	t.Run("все проверки прошли", func(t *testing.T) {
+		svc := NewHealthService(time.Second, zap.NewNop())
+		svc.AddCheck("database", ok)
+		svc.AddCheck("migrations", ok)
+
+		report := svc.Ready(context.Background())
+
+		assert.True(t, report.Ready())
+		assert.Len(t, report.Checks, 3)
+		assert.Equal(t, "database", report.Checks[0].Name)
+		assert.Equal(t, "migrations", report.Checks[1].Name)
+		assert.Equal(t, "shutdown", report.Checks[2].Name)
+	})
+
+	t.Run("одна проверка упала", func(t *testing.T) {
+		svc := NewHealthService(time.Second, zap.NewNop())
+		dbErr := errors.New("connection refused")
+		svc.AddCheck("database", func(ctx context.Context) error { return dbErr })
+		svc.AddCheck("workers", ok)
+
+		report := svc.Ready(context.Background())
+
+		assert.False(t, report.Ready())
+		assert.ErrorIs(t, report.Checks[0].Err, dbErr)
+		assert.NoError(t, report.Checks[1].Err)
+	})
+
+	t.Run("зависшая проверка прерывается по таймауту", func(t *testing.T) {
+		svc := NewHealthService(10*time.Millisecond, zap.NewNop())
+		svc.AddCheck("database", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		report := svc.Ready(context.Background())
+
+		assert.False(t, report.Ready())
+		assert.ErrorIs(t, report.Checks[0].Err, context.DeadlineExceeded)
+	})
+
+	t.Run("после начала остановки сервис не готов", func(t *testing.T) {
+		svc := NewHealthService(time.Second, zap.NewNop())
+		svc.AddCheck("database", ok)
+
+		svc.StartShutdown()
+		report := svc.Ready(context.Background())
+
+		assert.False(t, report.Ready())
+		assert.NoError(t, report.Checks[0].Err)
+		assert.ErrorIs(t, report.Checks[1].Err, errShuttingDown)
+	})
+}
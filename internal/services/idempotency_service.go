@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/repo"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// IdempotencyService хранит ответы на запросы с Idempotency-Key, чтобы повтор запроса
+// не выполнял его второй раз. Ключи живут в пределах пользователя
+type IdempotencyService struct {
+	idempotencyRepo repo.IdempotencyRepository
+	ttl             time.Duration
+	lease           time.Duration
+	serviceLogger   *zap.Logger
+}
+
+// ttl - сколько хранится ответ, после этого ключ удаляет воркер очистки.
+// lease - сколько ключ занят запросом, который ещё выполняется. Если процесс упал, не сохранив ответ,
+// по истечении lease повтор выполнится заново, а не будет получать 409 весь ttl
+func NewIdempotencyService(idempotencyRepo repo.IdempotencyRepository, ttl time.Duration, lease time.Duration, appLogger *zap.Logger) *IdempotencyService {
+	serviceLogger := appLogger.Named("idempotency_service")
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		lease:           lease,
+		serviceLogger:   serviceLogger,
+	}
+}
+
+// Begin занимает ключ под запрос. Если запрос с этим ключом уже выполнен, возвращается сохранённый ответ,
+// если ещё выполняется - ErrIdempotencyBusy. Тот же ключ с другим запросом - ErrIdempotencyReused
+func (is *IdempotencyService) Begin(ctx context.Context, key string, scope string, requestHash string) (*domain.IdempotencyRecord, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+
+	existing, reserved, err := is.idempotencyRepo.Reserve(ctx, &domain.IdempotencyRecord{
+		UserID:      user.ID,
+		Key:         key,
+		Scope:       scope,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(is.lease),
+	})
+	if err != nil {
+		logging.Logger(ctx, is.serviceLogger).Error("не удалось сохранить ключ идемпотентности", zap.Error(err))
+		return nil, err
+	}
+
+	if reserved {
+		return nil, nil
+	}
+
+	if existing.Scope != scope || existing.RequestHash != requestHash {
+		return nil, domain.ErrIdempotencyReused
+	}
+
+	if !existing.Completed() {
+		return nil, domain.ErrIdempotencyBusy
+	}
+
+	return existing, nil
+}
+
+// Complete сохраняет ответ на запрос, занявший ключ, и продлевает ключ на весь ttl
+func (is *IdempotencyService) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	err := is.idempotencyRepo.Complete(ctx, &domain.IdempotencyRecord{
+		UserID:      user.ID,
+		Key:         key,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+		ExpiresAt:   time.Now().Add(is.ttl),
+	})
+	if err != nil {
+		logging.Logger(ctx, is.serviceLogger).Error("не удалось сохранить ответ по ключу идемпотентности", zap.Error(err))
+	}
+	return err
+}
+
+// Release освобождает ключ, если запрос не удался по вине сервера и его можно повторить
+func (is *IdempotencyService) Release(ctx context.Context, key string) error {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+
+	if err := is.idempotencyRepo.Release(ctx, user.ID, key); err != nil {
+		logging.Logger(ctx, is.serviceLogger).Error("не удалось освободить ключ идемпотентности", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// PurgeExpired удаляет ключи с истёкшим сроком хранения
+func (is *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return is.idempotencyRepo.PurgeExpired(ctx, time.Now())
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"testtask5/internal/domain"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	args := m.Called(ctx, record)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*domain.IdempotencyRecord), args.Bool(1), args.Error(2)
+}
+
+func (m *MockIdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) Release(ctx context.Context, userID int, key string) error {
+	args := m.Called(ctx, userID, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	ctx := userCtx(testUserID)
+	const (
+		key   = "retry-1"
+		scope = "POST /chats/1/messages/"
+		hash  = "abc"
+	)
+
+	setup := func() (*IdempotencyService, *MockIdempotencyRepository) {
+		mockRepo := new(MockIdempotencyRepository)
+		return NewIdempotencyService(mockRepo, time.Hour, time.Minute, zap.NewNop()), mockRepo
+	}
+
+	t.Run("первый запрос занимает ключ на короткий срок", func(t *testing.T) {
+		svc, mockRepo := setup()
+
+		//если процесс упадёт до сохранения ответа, ключ освободится через lease, а не через ttl
+		mockRepo.On("Reserve", ctx, mock.MatchedBy(func(record *domain.IdempotencyRecord) bool {
+			return record.UserID == testUserID && record.Key == key && record.Scope == scope &&
+				record.RequestHash == hash && record.ExpiresAt.Before(time.Now().Add(time.Minute+time.Second))
+		})).Return(nil, true, nil)
+
+		record, err := svc.Begin(ctx, key, scope, hash)
+
+		assert.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("повтор получает сохранённый ответ", func(t *testing.T) {
+		svc, mockRepo := setup()
+		stored := &domain.IdempotencyRecord{Key: key, Scope: scope, RequestHash: hash, StatusCode: 201, Body: []byte(`{"id":5}`)}
+
+		mockRepo.On("Reserve", ctx, mock.Anything).Return(stored, false, nil)
+
+		record, err := svc.Begin(ctx, key, scope, hash)
+
+		assert.NoError(t, err)
+		assert.Equal(t, stored, record)
+	})
+
+	t.Run("ключ с другим телом запроса", func(t *testing.T) {
+		svc, mockRepo := setup()
+
+		mockRepo.On("Reserve", ctx, mock.Anything).
+			Return(&domain.IdempotencyRecord{Key: key, Scope: scope, RequestHash: "other", StatusCode: 201}, false, nil)
+
+		record, err := svc.Begin(ctx, key, scope, hash)
+
+		assert.Nil(t, record)
+		assert.ErrorIs(t, err, domain.ErrIdempotencyReused)
+	})
+
+	t.Run("ключ с другим запросом", func(t *testing.T) {
+		svc, mockRepo := setup()
+
+		mockRepo.On("Reserve", ctx, mock.Anything).
+			Return(&domain.IdempotencyRecord{Key: key, Scope: "POST /chats", RequestHash: hash, StatusCode: 201}, false, nil)
+
+		_, err := svc.Begin(ctx, key, scope, hash)
+
+		assert.ErrorIs(t, err, domain.ErrIdempotencyReused)
+	})
+
+	t.Run("первый запрос ещё выполняется", func(t *testing.T) {
+		svc, mockRepo := setup()
+
+		mockRepo.On("Reserve", ctx, mock.Anything).
+			Return(&domain.IdempotencyRecord{Key: key, Scope: scope, RequestHash: hash}, false, nil)
+
+		_, err := svc.Begin(ctx, key, scope, hash)
+
+		assert.ErrorIs(t, err, domain.ErrIdempotencyBusy)
+	})
+
+	t.Run("без аутентификации", func(t *testing.T) {
+		svc, mockRepo := setup()
+
+		_, err := svc.Begin(context.Background(), key, scope, hash)
+
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockRepo.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything)
+	})
+}
+
+func TestIdempotencyService_Complete(t *testing.T) {
+	ctx := userCtx(testUserID)
+	mockRepo := new(MockIdempotencyRepository)
+	svc := NewIdempotencyService(mockRepo, time.Hour, time.Minute, zap.NewNop())
+
+	//сохранённый ответ хранится весь ttl
+	mockRepo.On("Complete", ctx, mock.MatchedBy(func(record *domain.IdempotencyRecord) bool {
+		return record.UserID == testUserID && record.Key == "retry-1" && record.StatusCode == 201 &&
+			record.ContentType == "application/json" && string(record.Body) == `{"id":5}` &&
+			record.ExpiresAt.After(time.Now().Add(59*time.Minute))
+	})).Return(nil)
+	mockRepo.On("Release", ctx, testUserID, "retry-2").Return(nil)
+
+	assert.NoError(t, svc.Complete(ctx, "retry-1", 201, "application/json", []byte(`{"id":5}`)))
+	assert.NoError(t, svc.Release(ctx, "retry-2"))
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/repo"
+)
+
+// memberGuard проверяет, что текущий пользователь состоит в чате с ролью не ниже требуемой
+type memberGuard struct {
+	memberRepo repo.ChatMemberRepository
+}
+
+func (g memberGuard) requireRole(ctx context.Context, chatID int, minRole domain.ChatRole) (*domain.ChatMemberDomain, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+
+	member, err := g.memberRepo.FindMember(ctx, chatID, user.ID)
+	if errors.Is(err, domain.ErrMemberNotFound) {
+		return nil, domain.ErrNotChatMember
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !member.Role.AtLeast(minRole) {
+		return nil, domain.ErrForbidden
+	}
+
+	return member, nil
+}
@@ -1,20 +1,391 @@
 package services
 
 import (
-	"testtask5/internal/repo/postgres"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testtask5/internal/auth"
+	"testtask5/internal/domain"
+	"testtask5/internal/logging"
+	"testtask5/internal/repo"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type MessageService struct {
-	messageRepo   *postgres.MessageRepoPostgres
+	messageRepo   repo.MessageRepostiory
+	guard         memberGuard
+	events        *EventStream
+	attachments   *AttachmentStorage
+	restoreWindow time.Duration
 	serviceLogger *zap.Logger
 }
 
-func NewMessageService(mRepo *postgres.MessageRepoPostgres, appLogger *zap.Logger) *MessageService {
+// restoreWindow - сколько удалённое сообщение можно восстановить, после этого его удаляет воркер очистки
+func NewMessageService(mRepo repo.MessageRepostiory, memberRepo repo.ChatMemberRepository, events *EventStream, attachments *AttachmentStorage, restoreWindow time.Duration, appLogger *zap.Logger) *MessageService {
 	serviceLogger := appLogger.Named("message_service")
 	return &MessageService{
 		messageRepo:   mRepo,
+		guard:         memberGuard{memberRepo: memberRepo},
+		events:        events,
+		attachments:   attachments,
+		restoreWindow: restoreWindow,
 		serviceLogger: serviceLogger,
 	}
 }
+
+// EditMessage меняет текст сообщения, предыдущая версия попадает в историю правок. Править может только автор
+func (ms *MessageService) EditMessage(ctx context.Context, message *domain.MessageDomain) (*domain.MessageDomain, error) {
+	member, err := ms.guard.requireRole(ctx, message.ChatID, domain.RoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := ms.messageRepo.FindMessageById(ctx, message.ChatID, message.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAuthor(current, member) {
+		return nil, domain.ErrForbidden
+	}
+
+	if current.DeletedAt != nil {
+		return nil, domain.ErrMessageDeleted
+	}
+
+	//текст не изменился - ревизию не плодим
+	if current.Text == message.Text {
+		return current, nil
+	}
+
+	updated, err := ms.messageRepo.UpdateMessageText(ctx, message)
+	if err != nil {
+		if !errors.Is(err, domain.ErrMessageNotFound) && !errors.Is(err, domain.ErrMessageDeleted) {
+			logging.Logger(ctx, ms.serviceLogger).Error("не удалось отредактировать сообщение", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	ms.events.publishMessage(ctx, domain.EventMessageEdited, updated)
+
+	return updated, nil
+}
+
+// GetMessageRevisions отдаёт историю правок автору и модераторам (админы и владелец чата)
+func (ms *MessageService) GetMessageRevisions(ctx context.Context, chatID int, messageID int) ([]*domain.MessageRevisionDomain, error) {
+	if _, err := ms.requireAuthorOrModerator(ctx, chatID, messageID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := ms.messageRepo.GetMessageRevisions(ctx, messageID)
+	if err != nil {
+		logging.Logger(ctx, ms.serviceLogger).Error("не удалось получить историю правок", zap.Error(err))
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// DeleteMessage мягко удаляет сообщение, в истории чата остаётся tombstone. Удаляет автор или модератор
+func (ms *MessageService) DeleteMessage(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error) {
+	if _, err := ms.requireAuthorOrModerator(ctx, chatID, messageID); err != nil {
+		return nil, err
+	}
+
+	tombstone, err := ms.messageRepo.SoftDeleteMessage(ctx, chatID, messageID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrMessageNotFound) && !errors.Is(err, domain.ErrMessageDeleted) {
+			logging.Logger(ctx, ms.serviceLogger).Error("не удалось удалить сообщение", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	ms.events.publishMessage(ctx, domain.EventMessageDeleted, tombstone)
+
+	return tombstone, nil
+}
+
+// RestoreMessage восстанавливает сообщение, если окно восстановления ещё не закрылось
+func (ms *MessageService) RestoreMessage(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error) {
+	if _, err := ms.requireAuthorOrModerator(ctx, chatID, messageID); err != nil {
+		return nil, err
+	}
+
+	deletedAfter := time.Now().Add(-ms.restoreWindow)
+
+	message, err := ms.messageRepo.RestoreMessage(ctx, chatID, messageID, deletedAfter)
+	if err != nil {
+		if !errors.Is(err, domain.ErrMessageNotFound) &&
+			!errors.Is(err, domain.ErrMessageNotDeleted) &&
+			!errors.Is(err, domain.ErrRestoreExpired) {
+			logging.Logger(ctx, ms.serviceLogger).Error("не удалось восстановить сообщение", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	ms.events.publishMessage(ctx, domain.EventMessageRestored, message)
+
+	return message, nil
+}
+
+// PurgeExpiredMessages окончательно удаляет сообщения, у которых истекло окно восстановления
+func (ms *MessageService) PurgeExpiredMessages(ctx context.Context) (int64, error) {
+	return ms.messageRepo.PurgeDeletedMessages(ctx, time.Now().Add(-ms.restoreWindow))
+}
+
+// orphanAttachmentsBatch - сколько вложений без сообщения удаляется за один проход воркера
+const orphanAttachmentsBatch = 100
+
+// OpenAttachment открывает содержимое вложения для участника чата. Reader нужно закрыть после чтения
+func (ms *MessageService) OpenAttachment(ctx context.Context, chatID int, messageID int, attachmentID int) (*domain.AttachmentDomain, io.ReadCloser, error) {
+	attachment, err := ms.findAttachment(ctx, chatID, messageID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := ms.openBlob(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// OpenThumbnail открывает превью картинки с большей стороной maxSide
+func (ms *MessageService) OpenThumbnail(ctx context.Context, chatID int, messageID int, attachmentID int, maxSide int) (*domain.ThumbnailDomain, io.ReadCloser, error) {
+	attachment, err := ms.findAttachment(ctx, chatID, messageID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	thumbnail, ok := attachment.Thumbnail(maxSide)
+	if !ok {
+		return nil, nil, domain.ErrAttachmentNotFound
+	}
+
+	content, err := ms.openBlob(ctx, thumbnail.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return thumbnail, content, nil
+}
+
+// findAttachment ищет вложение сообщения, доступное текущему пользователю
+func (ms *MessageService) findAttachment(ctx context.Context, chatID int, messageID int, attachmentID int) (*domain.AttachmentDomain, error) {
+	if _, err := ms.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	message, err := ms.messageRepo.FindMessageById(ctx, chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	//вложения удалённого сообщения скрыты так же, как его текст
+	if message.DeletedAt != nil {
+		return nil, domain.ErrAttachmentNotFound
+	}
+
+	return ms.messageRepo.FindAttachment(ctx, chatID, messageID, attachmentID)
+}
+
+func (ms *MessageService) openBlob(ctx context.Context, key string) (io.ReadCloser, error) {
+	content, err := ms.attachments.open(ctx, key)
+	if err != nil && !errors.Is(err, domain.ErrAttachmentNotFound) {
+		logging.Logger(ctx, ms.serviceLogger).Error("не удалось открыть содержимое вложения", zap.String("key", key), zap.Error(err))
+	}
+	return content, err
+}
+
+// PurgeOrphanAttachments удаляет вложения, сообщения которых уже очищены.
+// Запись в базе удаляется только после содержимого, чтобы не терять ключи файлов
+func (ms *MessageService) PurgeOrphanAttachments(ctx context.Context) (int, error) {
+	orphans, err := ms.messageRepo.ListOrphanAttachments(ctx, orphanAttachmentsBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, 0, len(orphans))
+	for _, attachment := range orphans {
+		if err := ms.attachments.delete(ctx, attachment); err != nil {
+			logging.Logger(ctx, ms.serviceLogger).Warn("не удалось удалить содержимое вложения", zap.String("key", attachment.StorageKey), zap.Error(err))
+			continue
+		}
+		ids = append(ids, attachment.ID)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if err := ms.messageRepo.DeleteAttachments(ctx, ids); err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// SearchMessages выполняет полнотекстовый поиск по сообщениям чатов, где состоит пользователь
+func (ms *MessageService) SearchMessages(ctx context.Context, params repo.SearchParam) (*domain.MessageSearchResult, error) {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	params.MemberID = user.ID
+
+	result, err := ms.messageRepo.SearchMessages(ctx, params)
+	if err != nil {
+		if !errors.Is(err, domain.ErrInvalidCursor) {
+			logging.Logger(ctx, ms.serviceLogger).Error("не удалось выполнить поиск сообщений", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetChanges отдаёт участнику изменения чата после sinceSeq для дельта-синхронизации
+func (ms *MessageService) GetChanges(ctx context.Context, chatID int, sinceSeq int64, limit int) (*domain.MessageChanges, error) {
+	if _, err := ms.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	changes, err := ms.messageRepo.GetChangesSince(ctx, chatID, sinceSeq, limit)
+	if err != nil {
+		if !errors.Is(err, domain.ErrResyncRequired) && !errors.Is(err, domain.ErrChatNotFound) {
+			logging.Logger(ctx, ms.serviceLogger).Error("не удалось получить изменения чата", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetThread возвращает корень треда и страницу ответов. Если передан id ответа - отдаётся тред его корня
+func (ms *MessageService) GetThread(ctx context.Context, chatID int, messageID int, cursor repo.CursorParam) (*domain.MessageThread, error) {
+	if _, err := ms.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	root, err := ms.messageRepo.FindMessageById(ctx, chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.ParentMessageID != nil {
+		root, err = ms.messageRepo.FindMessageById(ctx, chatID, *root.ParentMessageID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	page, err := ms.messageRepo.GetThreadWithCursor(ctx, chatID, root.ID, cursor)
+	if err != nil {
+		logging.Logger(ctx, ms.serviceLogger).Error("не удалось получить тред", zap.Error(err))
+		return nil, err
+	}
+
+	thread := &domain.MessageThread{
+		Root:    root,
+		Replies: page.Messages,
+	}
+	thread.NextCursor, thread.PrevCursor = page.Cursors()
+
+	return thread, nil
+}
+
+// AddReaction ставит реакцию на сообщение чата и возвращает актуальные счётчики по эмодзи
+func (ms *MessageService) AddReaction(ctx context.Context, chatID int, reaction *domain.ReactionDomain) ([]domain.ReactionCount, error) {
+	if err := setReactor(ctx, reaction); err != nil {
+		return nil, err
+	}
+
+	if _, err := ms.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	message, err := ms.messageRepo.FindMessageById(ctx, chatID, reaction.MessageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.DeletedAt != nil {
+		return nil, domain.ErrMessageDeleted
+	}
+
+	if err := ms.messageRepo.AddReaction(ctx, reaction); err != nil {
+		logging.Logger(ctx, ms.serviceLogger).Error("не удалось поставить реакцию", zap.Error(err))
+		return nil, err
+	}
+
+	return ms.reactionCounts(ctx, reaction.MessageID)
+}
+
+// RemoveReaction снимает реакцию участника, снятие несуществующей реакции не считается ошибкой
+func (ms *MessageService) RemoveReaction(ctx context.Context, chatID int, reaction *domain.ReactionDomain) ([]domain.ReactionCount, error) {
+	if err := setReactor(ctx, reaction); err != nil {
+		return nil, err
+	}
+
+	if _, err := ms.guard.requireRole(ctx, chatID, domain.RoleMember); err != nil {
+		return nil, err
+	}
+
+	if _, err := ms.messageRepo.FindMessageById(ctx, chatID, reaction.MessageID); err != nil {
+		return nil, err
+	}
+
+	if err := ms.messageRepo.RemoveReaction(ctx, reaction); err != nil {
+		logging.Logger(ctx, ms.serviceLogger).Error("не удалось снять реакцию", zap.Error(err))
+		return nil, err
+	}
+
+	return ms.reactionCounts(ctx, reaction.MessageID)
+}
+
+// setReactor - реакцию ставит аутентифицированный пользователь, а не то, что прислал клиент
+func setReactor(ctx context.Context, reaction *domain.ReactionDomain) error {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return domain.ErrUnauthorized
+	}
+	reaction.Reactor = strconv.Itoa(user.ID)
+	return nil
+}
+
+func (ms *MessageService) reactionCounts(ctx context.Context, messageID int) ([]domain.ReactionCount, error) {
+	counts, err := ms.messageRepo.GetReactionCounts(ctx, []int{messageID})
+	if err != nil {
+		logging.Logger(ctx, ms.serviceLogger).Error("не удалось посчитать реакции", zap.Error(err))
+		return nil, err
+	}
+
+	return counts[messageID], nil
+}
+
+// requireAuthorOrModerator пропускает автора сообщения и участников с ролью не ниже админа
+func (ms *MessageService) requireAuthorOrModerator(ctx context.Context, chatID int, messageID int) (*domain.MessageDomain, error) {
+	member, err := ms.guard.requireRole(ctx, chatID, domain.RoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := ms.messageRepo.FindMessageById(ctx, chatID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAuthor(message, member) && !member.Role.AtLeast(domain.RoleAdmin) {
+		return nil, domain.ErrForbidden
+	}
+
+	return message, nil
+}
+
+func isAuthor(message *domain.MessageDomain, member *domain.ChatMemberDomain) bool {
+	return message.AuthorID != nil && *message.AuthorID == member.UserID
+}
@@ -0,0 +1,563 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testtask5/internal/domain"
+	"testtask5/internal/repo"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// testUserID - пользователь, от имени которого выполняются запросы в тестах сообщений
+const testUserID = 7
+
+func setupMessageTest(t *testing.T) (*MessageService, *MockMessageRepository) {
+	return setupMessageTestAs(t, domain.RoleMember)
+}
+
+// setupMessageTestAs делает testUserID участником любого чата с указанной ролью
+func setupMessageTestAs(t *testing.T, role domain.ChatRole) (*MessageService, *MockMessageRepository) {
+	svc, mockMessageRepo, _ := setupMessageTestWithBlobs(t, role)
+	return svc, mockMessageRepo
+}
+
+// setupMessageTestWithBlobs дополнительно отдаёт мок хранилища вложений
+func setupMessageTestWithBlobs(t *testing.T, role domain.ChatRole) (*MessageService, *MockMessageRepository, *MockBlobStore) {
+	mockMessageRepo := new(MockMessageRepository)
+	mockMemberRepo := new(MockChatMemberRepository)
+	mockMemberRepo.On("FindMember", mock.Anything, mock.Anything, testUserID).
+		Return(&domain.ChatMemberDomain{UserID: testUserID, Role: role}, nil).Maybe()
+	events, _ := setupEventStream()
+	attachments, blobs := setupAttachmentStorage()
+	svc := NewMessageService(mockMessageRepo, mockMemberRepo, events, attachments, 15*time.Minute, zap.NewNop())
+	return svc, mockMessageRepo, blobs
+}
+
+func TestMessageService_EditMessage(t *testing.T) {
+	ctx := userCtx(testUserID)
+	authorID := testUserID
+
+	t.Run("успешное редактирование", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		editedAt := time.Now()
+		message := &domain.MessageDomain{ID: 1, ChatID: 10, Text: "new"}
+		updated := &domain.MessageDomain{ID: 1, ChatID: 10, Text: "new", EditedAt: &editedAt}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, Text: "old", AuthorID: &authorID}, nil)
+		mockMessageRepo.On("UpdateMessageText", ctx, message).
+			Return(updated, nil)
+
+		result, err := svc.EditMessage(ctx, message)
+
+		assert.NoError(t, err)
+		assert.Equal(t, updated, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("текст не изменился - ревизия не создаётся", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		current := &domain.MessageDomain{ID: 1, ChatID: 10, Text: "same", AuthorID: &authorID}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).Return(current, nil)
+
+		result, err := svc.EditMessage(ctx, &domain.MessageDomain{ID: 1, ChatID: 10, Text: "same"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, current, result)
+		mockMessageRepo.AssertNotCalled(t, "UpdateMessageText", ctx, current)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("удалённое сообщение нельзя редактировать", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		deletedAt := time.Now()
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, DeletedAt: &deletedAt, AuthorID: &authorID}, nil)
+
+		result, err := svc.EditMessage(ctx, &domain.MessageDomain{ID: 1, ChatID: 10, Text: "new"})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrMessageDeleted)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("сообщение не найдено", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 99).
+			Return(nil, domain.ErrMessageNotFound)
+
+		result, err := svc.EditMessage(ctx, &domain.MessageDomain{ID: 99, ChatID: 10, Text: "new"})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrMessageNotFound)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("чужое сообщение не отредактировать даже админу", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTestAs(t, domain.RoleAdmin)
+		otherID := 8
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, Text: "old", AuthorID: &otherID}, nil)
+
+		result, err := svc.EditMessage(ctx, &domain.MessageDomain{ID: 1, ChatID: 10, Text: "new"})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockMessageRepo.AssertNotCalled(t, "UpdateMessageText", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMessageService_GetMessageRevisions(t *testing.T) {
+	ctx := userCtx(testUserID)
+	authorID := testUserID
+
+	t.Run("успешное получение истории", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		revisions := []*domain.MessageRevisionDomain{
+			{ID: 2, MessageID: 1, Text: "v2"},
+			{ID: 1, MessageID: 1, Text: "v1"},
+		}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, AuthorID: &authorID}, nil)
+		mockMessageRepo.On("GetMessageRevisions", ctx, 1).
+			Return(revisions, nil)
+
+		result, err := svc.GetMessageRevisions(ctx, 10, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, revisions, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("сообщение из другого чата", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+
+		mockMessageRepo.On("FindMessageById", ctx, 11, 1).
+			Return(nil, domain.ErrMessageNotFound)
+
+		result, err := svc.GetMessageRevisions(ctx, 11, 1)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrMessageNotFound)
+		mockMessageRepo.AssertExpectations(t)
+	})
+}
+
+func TestMessageService_DeleteMessage(t *testing.T) {
+	ctx := userCtx(testUserID)
+	authorID := testUserID
+
+	t.Run("успешное удаление - возвращается tombstone", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		deletedAt := time.Now()
+		tombstone := &domain.MessageDomain{ID: 1, ChatID: 10, DeletedAt: &deletedAt}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, AuthorID: &authorID}, nil)
+		mockMessageRepo.On("SoftDeleteMessage", ctx, 10, 1).Return(tombstone, nil)
+
+		result, err := svc.DeleteMessage(ctx, 10, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tombstone, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("сообщение уже удалено", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, AuthorID: &authorID}, nil)
+		mockMessageRepo.On("SoftDeleteMessage", ctx, 10, 1).Return(nil, domain.ErrMessageDeleted)
+
+		result, err := svc.DeleteMessage(ctx, 10, 1)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrMessageDeleted)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("админ удаляет чужое сообщение", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTestAs(t, domain.RoleAdmin)
+		otherID := 8
+		deletedAt := time.Now()
+		tombstone := &domain.MessageDomain{ID: 1, ChatID: 10, DeletedAt: &deletedAt}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, AuthorID: &otherID}, nil)
+		mockMessageRepo.On("SoftDeleteMessage", ctx, 10, 1).Return(tombstone, nil)
+
+		result, err := svc.DeleteMessage(ctx, 10, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, tombstone, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("участник не может удалить чужое сообщение", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		otherID := 8
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, AuthorID: &otherID}, nil)
+
+		result, err := svc.DeleteMessage(ctx, 10, 1)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+		mockMessageRepo.AssertNotCalled(t, "SoftDeleteMessage", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMessageService_RestoreMessage(t *testing.T) {
+	ctx := userCtx(testUserID)
+	authorID := testUserID
+
+	t.Run("граница окна восстановления передаётся в репозиторий", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		restored := &domain.MessageDomain{ID: 1, ChatID: 10, Text: "hello"}
+		before := time.Now().Add(-15 * time.Minute)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, AuthorID: &authorID}, nil)
+		mockMessageRepo.On("RestoreMessage", ctx, 10, 1, mock.MatchedBy(func(deletedAfter time.Time) bool {
+			return !deletedAfter.Before(before) && deletedAfter.Before(time.Now().Add(-14*time.Minute))
+		})).Return(restored, nil)
+
+		result, err := svc.RestoreMessage(ctx, 10, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, restored, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("окно восстановления истекло", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, AuthorID: &authorID}, nil)
+		mockMessageRepo.On("RestoreMessage", ctx, 10, 1, mock.Anything).Return(nil, domain.ErrRestoreExpired)
+
+		result, err := svc.RestoreMessage(ctx, 10, 1)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrRestoreExpired)
+		mockMessageRepo.AssertExpectations(t)
+	})
+}
+
+func TestMessageService_SearchMessages(t *testing.T) {
+	ctx := userCtx(testUserID)
+
+	t.Run("успешный поиск", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		params := repo.SearchParam{Query: "привет", ChatID: 10, MemberID: testUserID, Limit: 20}
+		found := &domain.MessageSearchResult{
+			Hits: []*domain.MessageSearchHit{
+				{Message: &domain.MessageDomain{ID: 1, ChatID: 10, Text: "привет мир"}, Rank: 0.6, Snippet: "<mark>привет</mark> мир"},
+			},
+		}
+
+		mockMessageRepo.On("SearchMessages", ctx, params).Return(found, nil)
+
+		result, err := svc.SearchMessages(ctx, params)
+
+		assert.NoError(t, err)
+		assert.Equal(t, found, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("некорректный курсор", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		params := repo.SearchParam{Query: "привет", Cursor: "broken", MemberID: testUserID, Limit: 20}
+
+		mockMessageRepo.On("SearchMessages", ctx, params).Return(nil, domain.ErrInvalidCursor)
+
+		result, err := svc.SearchMessages(ctx, params)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+		mockMessageRepo.AssertExpectations(t)
+	})
+}
+
+func TestMessageService_GetThread(t *testing.T) {
+	ctx := userCtx(testUserID)
+
+	t.Run("тред по id ответа отдаётся от корня", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		rootID := 1
+		cursor := repo.CursorParam{Limit: 2}
+		root := &domain.MessageDomain{ID: rootID, ChatID: 10, Text: "root"}
+		replies := []*domain.MessageDomain{
+			{ID: 4, ChatID: 10, ParentMessageID: &rootID},
+			{ID: 3, ChatID: 10, ParentMessageID: &rootID},
+		}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 3).
+			Return(&domain.MessageDomain{ID: 3, ChatID: 10, ParentMessageID: &rootID}, nil)
+		mockMessageRepo.On("FindMessageById", ctx, 10, rootID).Return(root, nil)
+		mockMessageRepo.On("GetThreadWithCursor", ctx, 10, rootID, cursor).
+			Return(&domain.MessagePage{Messages: replies, HasOlder: true}, nil)
+
+		thread, err := svc.GetThread(ctx, 10, 3, cursor)
+
+		assert.NoError(t, err)
+		assert.Equal(t, root, thread.Root)
+		assert.Equal(t, replies, thread.Replies)
+		if assert.NotNil(t, thread.NextCursor) {
+			assert.Equal(t, 3, *thread.NextCursor)
+		}
+		assert.Nil(t, thread.PrevCursor)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("сообщение не найдено", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 99).Return(nil, domain.ErrMessageNotFound)
+
+		thread, err := svc.GetThread(ctx, 10, 99, repo.CursorParam{Limit: 20})
+
+		assert.Nil(t, thread)
+		assert.ErrorIs(t, err, domain.ErrMessageNotFound)
+		mockMessageRepo.AssertExpectations(t)
+	})
+}
+
+func TestMessageService_AddReaction(t *testing.T) {
+	ctx := userCtx(testUserID)
+
+	t.Run("реакция поставлена - возвращаются счётчики", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		reaction := &domain.ReactionDomain{MessageID: 1, Reactor: "7", Emoji: "👍"}
+		counts := []domain.ReactionCount{{Emoji: "👍", Count: 2}}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10}, nil)
+		mockMessageRepo.On("AddReaction", ctx, reaction).Return(nil)
+		mockMessageRepo.On("GetReactionCounts", ctx, []int{1}).
+			Return(map[int][]domain.ReactionCount{1: counts}, nil)
+
+		result, err := svc.AddReaction(ctx, 10, reaction)
+
+		assert.NoError(t, err)
+		assert.Equal(t, counts, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("на удалённое сообщение реакцию не поставить", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		deletedAt := time.Now()
+		reaction := &domain.ReactionDomain{MessageID: 1, Reactor: "7", Emoji: "👍"}
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, DeletedAt: &deletedAt}, nil)
+
+		result, err := svc.AddReaction(ctx, 10, reaction)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrMessageDeleted)
+		mockMessageRepo.AssertNotCalled(t, "AddReaction", ctx, reaction)
+	})
+}
+
+func TestMessageService_RemoveReaction(t *testing.T) {
+	ctx := userCtx(testUserID)
+	svc, mockMessageRepo := setupMessageTest(t)
+	reaction := &domain.ReactionDomain{MessageID: 1, Reactor: "7", Emoji: "👍"}
+
+	mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+		Return(&domain.MessageDomain{ID: 1, ChatID: 10}, nil)
+	mockMessageRepo.On("RemoveReaction", ctx, reaction).Return(nil)
+	mockMessageRepo.On("GetReactionCounts", ctx, []int{1}).
+		Return(map[int][]domain.ReactionCount{}, nil)
+
+	result, err := svc.RemoveReaction(ctx, 10, reaction)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestMessageService_GetChanges(t *testing.T) {
+	ctx := userCtx(testUserID)
+
+	t.Run("изменения после since_seq", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+		deletedAt := time.Now()
+		changes := &domain.MessageChanges{
+			Messages: []*domain.MessageDomain{
+				{ID: 3, ChatID: 10, Seq: 6, Text: "new"},
+				{ID: 1, ChatID: 10, Seq: 7, DeletedAt: &deletedAt},
+			},
+			ChatSeq: 7,
+		}
+
+		mockMessageRepo.On("GetChangesSince", ctx, 10, int64(5), 100).Return(changes, nil)
+
+		result, err := svc.GetChanges(ctx, 10, 5, 100)
+
+		assert.NoError(t, err)
+		assert.Equal(t, changes, result)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("изменения уже очищены - нужна полная синхронизация", func(t *testing.T) {
+		svc, mockMessageRepo := setupMessageTest(t)
+
+		mockMessageRepo.On("GetChangesSince", ctx, 10, int64(1), 100).Return(nil, domain.ErrResyncRequired)
+
+		result, err := svc.GetChanges(ctx, 10, 1, 100)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, domain.ErrResyncRequired)
+	})
+}
+
+func TestMessageService_OpenAttachment(t *testing.T) {
+	ctx := userCtx(testUserID)
+	attachment := &domain.AttachmentDomain{ID: 3, MessageID: 1, FileName: "a.txt", StorageKey: "ab/abcd"}
+
+	t.Run("участник чата получает содержимое", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setupMessageTestWithBlobs(t, domain.RoleMember)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).Return(&domain.MessageDomain{ID: 1, ChatID: 10}, nil)
+		mockMessageRepo.On("FindAttachment", ctx, 10, 1, 3).Return(attachment, nil)
+		blobs.On("Get", mock.Anything, "ab/abcd").Return(io.NopCloser(strings.NewReader("hello")), nil)
+
+		result, content, err := svc.OpenAttachment(ctx, 10, 1, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, attachment, result)
+		data, _ := io.ReadAll(content)
+		assert.Equal(t, "hello", string(data))
+		assert.NoError(t, content.Close())
+	})
+
+	t.Run("вложения удалённого сообщения недоступны", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setupMessageTestWithBlobs(t, domain.RoleMember)
+		deletedAt := time.Now()
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).
+			Return(&domain.MessageDomain{ID: 1, ChatID: 10, DeletedAt: &deletedAt}, nil)
+
+		result, content, err := svc.OpenAttachment(ctx, 10, 1, 3)
+
+		assert.Nil(t, result)
+		assert.Nil(t, content)
+		assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+		blobs.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+
+	t.Run("без аутентификации вложение не скачать", func(t *testing.T) {
+		svc, mockMessageRepo, _ := setupMessageTestWithBlobs(t, domain.RoleMember)
+
+		_, _, err := svc.OpenAttachment(context.Background(), 10, 1, 3)
+
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockMessageRepo.AssertNotCalled(t, "FindAttachment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMessageService_OpenThumbnail(t *testing.T) {
+	ctx := userCtx(testUserID)
+	attachment := &domain.AttachmentDomain{
+		ID:         3,
+		StorageKey: "ab/photo",
+		Thumbnails: []*domain.ThumbnailDomain{{MaxSide: 160, StorageKey: "ab/photo.thumb160"}},
+	}
+
+	t.Run("превью нужного размера", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setupMessageTestWithBlobs(t, domain.RoleMember)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).Return(&domain.MessageDomain{ID: 1, ChatID: 10}, nil)
+		mockMessageRepo.On("FindAttachment", ctx, 10, 1, 3).Return(attachment, nil)
+		blobs.On("Get", mock.Anything, "ab/photo.thumb160").Return(io.NopCloser(strings.NewReader("thumb")), nil)
+
+		thumbnail, content, err := svc.OpenThumbnail(ctx, 10, 1, 3, 160)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 160, thumbnail.MaxSide)
+		assert.NoError(t, content.Close())
+	})
+
+	t.Run("такого размера нет", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setupMessageTestWithBlobs(t, domain.RoleMember)
+
+		mockMessageRepo.On("FindMessageById", ctx, 10, 1).Return(&domain.MessageDomain{ID: 1, ChatID: 10}, nil)
+		mockMessageRepo.On("FindAttachment", ctx, 10, 1, 3).Return(attachment, nil)
+
+		_, _, err := svc.OpenThumbnail(ctx, 10, 1, 3, 999)
+
+		assert.ErrorIs(t, err, domain.ErrAttachmentNotFound)
+		blobs.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMessageService_PurgeOrphanAttachments(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("запись удаляется только вместе с содержимым", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setupMessageTestWithBlobs(t, domain.RoleMember)
+		orphans := []*domain.AttachmentDomain{
+			{ID: 1, StorageKey: "aa/1"},
+			{ID: 2, StorageKey: "bb/2"},
+		}
+
+		mockMessageRepo.On("ListOrphanAttachments", ctx, orphanAttachmentsBatch).Return(orphans, nil)
+		blobs.On("Delete", mock.Anything, "aa/1").Return(nil)
+		blobs.On("Delete", mock.Anything, "bb/2").Return(errors.New("s3 unavailable"))
+		mockMessageRepo.On("DeleteAttachments", ctx, []int{1}).Return(nil)
+
+		purged, err := svc.PurgeOrphanAttachments(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		mockMessageRepo.AssertExpectations(t)
+	})
+
+	t.Run("вместе с картинкой удаляются её превью", func(t *testing.T) {
+		svc, mockMessageRepo, blobs := setupMessageTestWithBlobs(t, domain.RoleMember)
+		orphans := []*domain.AttachmentDomain{{
+			ID:         1,
+			StorageKey: "aa/1",
+			Thumbnails: []*domain.ThumbnailDomain{{MaxSide: 160, StorageKey: "aa/1.thumb160"}},
+		}}
+
+		mockMessageRepo.On("ListOrphanAttachments", ctx, orphanAttachmentsBatch).Return(orphans, nil)
+		blobs.On("Delete", mock.Anything, "aa/1.thumb160").Return(nil).Once()
+		blobs.On("Delete", mock.Anything, "aa/1").Return(nil).Once()
+		mockMessageRepo.On("DeleteAttachments", ctx, []int{1}).Return(nil)
+
+		purged, err := svc.PurgeOrphanAttachments(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		blobs.AssertExpectations(t)
+	})
+
+	t.Run("нечего удалять", func(t *testing.T) {
+		svc, mockMessageRepo, _ := setupMessageTestWithBlobs(t, domain.RoleMember)
+
+		mockMessageRepo.On("ListOrphanAttachments", ctx, orphanAttachmentsBatch).Return([]*domain.AttachmentDomain{}, nil)
+
+		purged, err := svc.PurgeOrphanAttachments(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, purged)
+		mockMessageRepo.AssertNotCalled(t, "DeleteAttachments", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"strconv"
+	"testtask5/internal/domain"
+	"testtask5/internal/imaging"
+	"testtask5/internal/logging"
+	"testtask5/internal/repo"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// thumbnailBatch - сколько картинок воркер берёт за один проход
+	thumbnailBatch = 10
+	// thumbnailClaimTimeout - через сколько взятая, но не обработанная картинка выдаётся снова
+	thumbnailClaimTimeout = 5 * time.Minute
+	// thumbnailMaxAttempts - после стольких падений картинка помечается как failed
+	thumbnailMaxAttempts = 3
+)
+
+// ThumbnailConfig - размеры превью (по большей стороне) и предельное разрешение оригинала
+type ThumbnailConfig struct {
+	Sizes     []int
+	MaxPixels int
+}
+
+// ThumbnailService строит превью загруженных картинок в фоне
+type ThumbnailService struct {
+	thumbnailRepo repo.ThumbnailRepository
+	attachments   *AttachmentStorage
+	config        ThumbnailConfig
+	serviceLogger *zap.Logger
+}
+
+func NewThumbnailService(thumbnailRepo repo.ThumbnailRepository, attachments *AttachmentStorage, config ThumbnailConfig, appLogger *zap.Logger) *ThumbnailService {
+	serviceLogger := appLogger.Named("thumbnail_service")
+	return &ThumbnailService{
+		thumbnailRepo: thumbnailRepo,
+		attachments:   attachments,
+		config:        config,
+		serviceLogger: serviceLogger,
+	}
+}
+
+// ProcessPending строит превью для очередной пачки картинок и возвращает, сколько обработано
+func (ts *ThumbnailService) ProcessPending(ctx context.Context) (int, error) {
+	images, err := ts.thumbnailRepo.ClaimPendingImages(ctx, thumbnailBatch, time.Now().Add(-thumbnailClaimTimeout), thumbnailMaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, attachment := range images {
+		if ctx.Err() != nil {
+			//сервис останавливается, необработанные картинки выдадутся снова после thumbnailClaimTimeout
+			return processed, nil
+		}
+
+		if err := ts.process(ctx, attachment); err != nil {
+			logging.Logger(ctx, ts.serviceLogger).Warn("не удалось построить превью", zap.Int("attachment_id", attachment.ID), zap.Error(err))
+			continue
+		}
+		processed++
+	}
+
+	return processed, nil
+}
+
+func (ts *ThumbnailService) process(ctx context.Context, attachment *domain.AttachmentDomain) error {
+	img, err := ts.decode(ctx, attachment)
+	if err != nil {
+		//сбой хранилища может быть временным, картинку возьмут снова. Битый файл повторять бессмысленно
+		var blobErr *blobError
+		if !errors.As(err, &blobErr) {
+			if markErr := ts.thumbnailRepo.MarkThumbnailsFailed(ctx, attachment.ID); markErr != nil {
+				return markErr
+			}
+		}
+		return err
+	}
+
+	thumbnails, err := ts.storeThumbnails(ctx, attachment, img)
+	if err != nil {
+		return err
+	}
+
+	bounds := img.Bounds()
+	if err := ts.thumbnailRepo.SaveThumbnails(ctx, attachment.ID, bounds.Dx(), bounds.Dy(), thumbnails); err != nil {
+		ts.attachments.removeThumbnails(ctx, thumbnails)
+		return err
+	}
+
+	return nil
+}
+
+// blobError - ошибка чтения оригинала из хранилища, в отличие от ошибки декодирования
+type blobError struct {
+	err error
+}
+
+func (e *blobError) Error() string { return e.err.Error() }
+func (e *blobError) Unwrap() error { return e.err }
+
+func (ts *ThumbnailService) decode(ctx context.Context, attachment *domain.AttachmentDomain) (image.Image, error) {
+	content, err := ts.attachments.open(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, &blobError{err: err}
+	}
+	defer content.Close()
+
+	return imaging.Decode(content, attachment.ContentType, ts.config.MaxPixels)
+}
+
+// storeThumbnails кладёт превью рядом с оригиналом: ключ оригинала с суффиксом размера
+func (ts *ThumbnailService) storeThumbnails(ctx context.Context, attachment *domain.AttachmentDomain, img image.Image) ([]*domain.ThumbnailDomain, error) {
+	thumbnails := make([]*domain.ThumbnailDomain, 0, len(ts.config.Sizes))
+	for _, maxSide := range ts.config.Sizes {
+		resized, ok := imaging.Fit(img, maxSide)
+		if !ok {
+			continue
+		}
+
+		var buf bytes.Buffer
+		contentType, err := imaging.Encode(&buf, resized, attachment.ContentType)
+		if err != nil {
+			return nil, err
+		}
+
+		thumbnail := &domain.ThumbnailDomain{
+			MaxSide:     maxSide,
+			Width:       resized.Bounds().Dx(),
+			Height:      resized.Bounds().Dy(),
+			ContentType: contentType,
+			Size:        int64(buf.Len()),
+			StorageKey:  attachment.StorageKey + ".thumb" + strconv.Itoa(maxSide),
+		}
+		if err := ts.attachments.put(ctx, thumbnail.StorageKey, buf.Bytes(), contentType); err != nil {
+			ts.attachments.removeThumbnails(ctx, thumbnails)
+			return nil, err
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	return thumbnails, nil
+}
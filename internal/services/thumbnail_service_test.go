@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"testtask5/internal/domain"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockThumbnailRepository struct {
+	mock.Mock
+}
+
+func (m *MockThumbnailRepository) ClaimPendingImages(ctx context.Context, limit int, staleBefore time.Time, maxAttempts int) ([]*domain.AttachmentDomain, error) {
+	args := m.Called(ctx, limit, staleBefore, maxAttempts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.AttachmentDomain), args.Error(1)
+}
+
+func (m *MockThumbnailRepository) SaveThumbnails(ctx context.Context, attachmentID int, width int, height int, thumbnails []*domain.ThumbnailDomain) error {
+	args := m.Called(ctx, attachmentID, width, height, thumbnails)
+	return args.Error(0)
+}
+
+func (m *MockThumbnailRepository) MarkThumbnailsFailed(ctx context.Context, attachmentID int) error {
+	args := m.Called(ctx, attachmentID)
+	return args.Error(0)
+}
+
+func setupThumbnailTest() (*ThumbnailService, *MockThumbnailRepository, *MockBlobStore) {
+	thumbnailRepo := new(MockThumbnailRepository)
+	attachments, blobs := setupAttachmentStorage()
+	config := ThumbnailConfig{Sizes: []int{50, 200}, MaxPixels: 1_000_000}
+	return NewThumbnailService(thumbnailRepo, attachments, config, zap.NewNop()), thumbnailRepo, blobs
+}
+
+func pngBytes(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func claimReturns(thumbnailRepo *MockThumbnailRepository, images ...*domain.AttachmentDomain) {
+	thumbnailRepo.On("ClaimPendingImages", mock.Anything, thumbnailBatch, mock.Anything, thumbnailMaxAttempts).
+		Return(images, nil).Once()
+}
+
+func TestThumbnailService_ProcessPending(t *testing.T) {
+	ctx := context.Background()
+	photo := &domain.AttachmentDomain{ID: 5, ContentType: "image/png", StorageKey: "ab/photo"}
+
+	t.Run("строятся только превью меньше оригинала", func(t *testing.T) {
+		svc, thumbnailRepo, blobs := setupThumbnailTest()
+		claimReturns(thumbnailRepo, photo)
+
+		blobs.On("Get", mock.Anything, "ab/photo").Return(io.NopCloser(bytes.NewReader(pngBytes(t, 100, 40))), nil)
+		blobs.On("Put", mock.Anything, "ab/photo.thumb50", mock.Anything, mock.Anything, "image/png").Return(nil)
+		thumbnailRepo.On("SaveThumbnails", ctx, 5, 100, 40, mock.MatchedBy(func(thumbnails []*domain.ThumbnailDomain) bool {
+			return len(thumbnails) == 1 &&
+				thumbnails[0].MaxSide == 50 && thumbnails[0].Width == 50 && thumbnails[0].Height == 20 &&
+				thumbnails[0].StorageKey == "ab/photo.thumb50"
+		})).Return(nil)
+
+		processed, err := svc.ProcessPending(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, processed)
+		blobs.AssertExpectations(t)
+		thumbnailRepo.AssertExpectations(t)
+	})
+
+	t.Run("битая картинка помечается как failed", func(t *testing.T) {
+		svc, thumbnailRepo, blobs := setupThumbnailTest()
+		claimReturns(thumbnailRepo, photo)
+
+		blobs.On("Get", mock.Anything, "ab/photo").Return(io.NopCloser(bytes.NewReader([]byte("not a png"))), nil)
+		thumbnailRepo.On("MarkThumbnailsFailed", ctx, 5).Return(nil)
+
+		processed, err := svc.ProcessPending(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, processed)
+		thumbnailRepo.AssertExpectations(t)
+		thumbnailRepo.AssertNotCalled(t, "SaveThumbnails", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("сбой хранилища - картинка будет взята снова", func(t *testing.T) {
+		svc, thumbnailRepo, blobs := setupThumbnailTest()
+		claimReturns(thumbnailRepo, photo)
+
+		blobs.On("Get", mock.Anything, "ab/photo").Return(nil, errors.New("connection refused"))
+
+		processed, err := svc.ProcessPending(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, processed)
+		thumbnailRepo.AssertNotCalled(t, "MarkThumbnailsFailed", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ошибка базы - загруженные превью удаляются", func(t *testing.T) {
+		svc, thumbnailRepo, blobs := setupThumbnailTest()
+		claimReturns(thumbnailRepo, photo)
+
+		blobs.On("Get", mock.Anything, "ab/photo").Return(io.NopCloser(bytes.NewReader(pngBytes(t, 300, 300))), nil)
+		blobs.On("Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything, "image/png").Return(nil).Twice()
+		thumbnailRepo.On("SaveThumbnails", ctx, 5, 300, 300, mock.Anything).Return(errors.New("attachment deleted"))
+		blobs.On("Delete", mock.Anything, "ab/photo.thumb50").Return(nil).Once()
+		blobs.On("Delete", mock.Anything, "ab/photo.thumb200").Return(nil).Once()
+
+		processed, err := svc.ProcessPending(ctx)
+
+		assert.NoError(t, err)
+		assert.Zero(t, processed)
+		blobs.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE INDEX idx_messages_chat_id_id ON messages (chat_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_messages_chat_id_id;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE messages ADD COLUMN edited_at TIMESTAMP NULL;
+
+CREATE TABLE message_revisions (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    text VARCHAR(5000) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_revisions_message_id ON message_revisions (message_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE message_revisions;
+ALTER TABLE messages DROP COLUMN edited_at;
+-- +goose StatementEnd
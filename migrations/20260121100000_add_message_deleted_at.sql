@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE messages ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE INDEX idx_messages_deleted_at ON messages (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_messages_deleted_at;
+ALTER TABLE messages DROP COLUMN deleted_at;
+-- +goose StatementEnd
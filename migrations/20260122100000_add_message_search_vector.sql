@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE messages
+    ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('russian', text)) STORED;
+
+CREATE INDEX idx_messages_search_vector ON messages USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_messages_search_vector;
+ALTER TABLE messages DROP COLUMN search_vector;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE messages ADD COLUMN parent_message_id INTEGER NULL REFERENCES messages(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_messages_parent_message_id ON messages (parent_message_id, id) WHERE parent_message_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_messages_parent_message_id;
+ALTER TABLE messages DROP COLUMN parent_message_id;
+-- +goose StatementEnd
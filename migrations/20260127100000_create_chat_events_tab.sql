@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- без внешнего ключа на chats: событие chat.deleted должно пережить сам чат
+CREATE TABLE chat_events (
+    id BIGSERIAL PRIMARY KEY,
+    chat_id INTEGER NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_chat_events_chat_id_id ON chat_events (chat_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE chat_events;
+-- +goose StatementEnd
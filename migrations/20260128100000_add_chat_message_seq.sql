@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- last_seq - последний выданный номер изменения в чате,
+-- purged_seq - наибольший номер среди окончательно удалённых сообщений
+ALTER TABLE chats
+    ADD COLUMN last_seq BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN purged_seq BIGINT NOT NULL DEFAULT 0;
+
+-- seq - номер последнего изменения сообщения (создание, правка, удаление, восстановление)
+ALTER TABLE messages ADD COLUMN seq BIGINT;
+
+UPDATE messages m
+SET seq = s.rn
+FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY chat_id ORDER BY id) AS rn FROM messages) s
+WHERE m.id = s.id;
+
+UPDATE chats c
+SET last_seq = COALESCE((SELECT MAX(seq) FROM messages WHERE chat_id = c.id), 0);
+
+ALTER TABLE messages ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_messages_chat_id_seq ON messages (chat_id, seq);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_messages_chat_id_seq;
+ALTER TABLE messages DROP COLUMN seq;
+ALTER TABLE chats DROP COLUMN last_seq, DROP COLUMN purged_seq;
+-- +goose StatementEnd
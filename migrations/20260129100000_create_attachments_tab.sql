@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- message_id обнуляется, когда сообщение окончательно удалено: такие вложения
+-- подбирает воркер очистки и удаляет их содержимое из хранилища
+CREATE TABLE attachments (
+    id SERIAL PRIMARY KEY,
+    message_id INTEGER REFERENCES messages(id) ON DELETE SET NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(127) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attachments_message_id ON attachments (message_id);
+CREATE INDEX idx_attachments_orphaned ON attachments (id) WHERE message_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE attachments;
+-- +goose StatementEnd
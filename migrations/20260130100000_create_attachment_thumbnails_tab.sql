@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- thumbnail_status: none - превью не нужны, pending - ждёт воркера, processing - взято воркером,
+-- done - превью построены, failed - картинку не удалось обработать
+ALTER TABLE attachments
+    ADD COLUMN width INTEGER,
+    ADD COLUMN height INTEGER,
+    ADD COLUMN thumbnail_status VARCHAR(16) NOT NULL DEFAULT 'none',
+    ADD COLUMN thumbnail_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN thumbnail_claimed_at TIMESTAMP;
+
+UPDATE attachments SET thumbnail_status = 'pending'
+WHERE content_type IN ('image/jpeg', 'image/png', 'image/gif');
+
+CREATE INDEX idx_attachments_thumbnail_queue ON attachments (id)
+    WHERE thumbnail_status IN ('pending', 'processing');
+
+CREATE TABLE attachment_thumbnails (
+    id SERIAL PRIMARY KEY,
+    attachment_id INTEGER NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    max_side INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    content_type VARCHAR(127) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (attachment_id, max_side)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE attachment_thumbnails;
+ALTER TABLE attachments
+    DROP COLUMN width,
+    DROP COLUMN height,
+    DROP COLUMN thumbnail_status,
+    DROP COLUMN thumbnail_attempts,
+    DROP COLUMN thumbnail_claimed_at;
+-- +goose StatementEnd
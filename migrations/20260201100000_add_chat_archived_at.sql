@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+ALTER TABLE chats ADD COLUMN archived_at TIMESTAMP NULL;
+
+CREATE INDEX idx_chats_archived_at ON chats (archived_at) WHERE archived_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP INDEX idx_chats_archived_at;
+ALTER TABLE chats DROP COLUMN archived_at;
+-- +goose StatementEnd
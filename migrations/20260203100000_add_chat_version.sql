@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- version растёт при каждом изменении чата (название, архив), по нему проверяется If-Match
+ALTER TABLE chats ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+ALTER TABLE chats DROP COLUMN version;
+-- +goose StatementEnd
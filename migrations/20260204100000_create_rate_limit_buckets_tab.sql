@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- общие для всех реплик ведра лимитов запросов (RATE_LIMIT_STORE=postgres)
+CREATE TABLE rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    refilled_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_buckets_refilled_at ON rate_limit_buckets (refilled_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+DROP TABLE rate_limit_buckets;
+-- +goose StatementEnd
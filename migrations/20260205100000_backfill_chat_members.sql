@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- чаты, созданные до появления участников, остались без владельца и недоступны никому.
+-- Владельцем становится автор первого сообщения, остальные авторы - участниками
+INSERT INTO chat_members (chat_id, user_id, role)
+SELECT DISTINCT ON (m.chat_id) m.chat_id, m.author_id, 'owner'
+FROM messages m
+WHERE m.author_id IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM chat_members cm WHERE cm.chat_id = m.chat_id AND cm.role = 'owner')
+ORDER BY m.chat_id, m.id
+ON CONFLICT (chat_id, user_id) DO UPDATE SET role = 'owner';
+
+INSERT INTO chat_members (chat_id, user_id, role)
+SELECT DISTINCT m.chat_id, m.author_id, 'member'
+FROM messages m
+WHERE m.author_id IS NOT NULL
+ON CONFLICT (chat_id, user_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- откатывается только схема, восстановленные участники остаются
+-- +goose StatementEnd
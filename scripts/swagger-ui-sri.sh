@@ -0,0 +1,20 @@
+#!/bin/sh
+# Пересчитывает хэши SRI статики Swagger UI в docs_handler_http.go по версии из swaggerUIPage
+set -eu
+
+handler=internal/interfaces/httpAPI/docs_handler_http.go
+version=$(sed -n 's|.*swagger-ui-dist@\([0-9.]*\)/swagger-ui.css.*|\1|p' "$handler")
+
+sri() {
+	printf 'sha384-%s' "$(curl -sSfL "https://unpkg.com/swagger-ui-dist@$version/$1" | openssl dgst -sha384 -binary | openssl base64 -A)"
+}
+
+css=$(sri swagger-ui.css)
+bundle=$(sri swagger-ui-bundle.js)
+
+sed -i \
+	-e "s|swaggerUICSSIntegrity    = \".*\"|swaggerUICSSIntegrity    = \"$css\"|" \
+	-e "s|swaggerUIBundleIntegrity = \".*\"|swaggerUIBundleIntegrity = \"$bundle\"|" \
+	"$handler"
+
+echo "swagger-ui-dist@$version: $css $bundle"